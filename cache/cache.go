@@ -0,0 +1,605 @@
+// Package cache provides an in-memory Discord state cache fed by Gateway dispatch events.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/gateway"
+	"github.com/kolosys/discord-types/payloads"
+)
+
+// Flags select the entity kinds a Cache stores.
+type Flags uint
+
+const (
+	// FlagGuilds caches guilds.
+	FlagGuilds Flags = 1 << iota
+
+	// FlagChannels caches guild channels.
+	FlagChannels
+
+	// FlagRoles caches guild roles.
+	FlagRoles
+
+	// FlagMembers caches guild members. Large guilds only send members
+	// beyond the current user once requested with Request Guild Members.
+	FlagMembers
+
+	// FlagEmojis caches guild emojis.
+	FlagEmojis
+
+	// FlagStickers caches guild stickers.
+	FlagStickers
+
+	// FlagVoiceStates caches the voice states of members in voice channels.
+	FlagVoiceStates
+
+	// FlagMessages caches messages. Without a bounded Config.Messages store
+	// the cache grows with every message received.
+	FlagMessages
+
+	// FlagAll caches every entity kind.
+	FlagAll = FlagGuilds | FlagChannels | FlagRoles | FlagMembers | FlagEmojis | FlagStickers | FlagVoiceStates | FlagMessages
+)
+
+// Has reports whether every flag of flags is set in f.
+func (f Flags) Has(flags Flags) bool {
+	return f&flags == flags
+}
+
+// Channel is a cached guild channel.
+type Channel = gateway.ChannelCreateDispatchData
+
+// Config configures a Cache.
+//
+// The stores set the storage policy of each entity kind, for example
+//
+//	cache.Config{
+//		Flags:    cache.FlagAll,
+//		Members:  cache.NewLRUStore(cache.LRUConfig[payloads.GuildMember]{MaxEntries: 1_000_000}),
+//		Messages: cache.NewLRUStore(cache.LRUConfig[payloads.Message]{TTL: time.Hour}),
+//	}
+//
+// Stores left nil default to a MapStore, which never evicts.
+type Config struct {
+	// Flags are the entity kinds to cache. Events for other kinds are ignored.
+	Flags Flags
+
+	// Guilds through Messages are the stores of each entity kind.
+	Guilds      Store[payloads.Guild]
+	Channels    Store[Channel]
+	Roles       Store[payloads.Role]
+	Members     Store[payloads.GuildMember]
+	Emojis      Store[payloads.Emoji]
+	Stickers    Store[payloads.Sticker]
+	VoiceStates Store[payloads.VoiceState]
+	Messages    Store[payloads.Message]
+}
+
+// Cache is a concurrency-safe store of Discord state, kept up to date by
+// applying the dispatch events received from the Gateway.
+//
+// The accessors return copies of the cached values. The slices and pointers
+// inside them are shared with the cache and must not be modified.
+type Cache struct {
+	flags Flags
+
+	// channelGuilds maps channel ids to the guild whose group holds them.
+	mu            sync.RWMutex
+	channelGuilds map[discord.Snowflake]discord.Snowflake
+
+	guilds      Store[payloads.Guild]
+	channels    Store[Channel]
+	roles       Store[payloads.Role]
+	members     Store[payloads.GuildMember]
+	emojis      Store[payloads.Emoji]
+	stickers    Store[payloads.Sticker]
+	voiceStates Store[payloads.VoiceState]
+	messages    Store[payloads.Message]
+}
+
+// New returns a Cache using the stores of cfg. Stores that already hold
+// entities, such as a loaded SnapshotStore, are used as they are.
+func New(cfg Config) *Cache {
+	c := &Cache{
+		flags:         cfg.Flags,
+		channelGuilds: make(map[discord.Snowflake]discord.Snowflake),
+		guilds:        storeOrDefault(cfg.Guilds),
+		channels:      storeOrDefault(cfg.Channels),
+		roles:         storeOrDefault(cfg.Roles),
+		members:       storeOrDefault(cfg.Members),
+		emojis:        storeOrDefault(cfg.Emojis),
+		stickers:      storeOrDefault(cfg.Stickers),
+		voiceStates:   storeOrDefault(cfg.VoiceStates),
+		messages:      storeOrDefault(cfg.Messages),
+	}
+	c.channels.Range(func(guildID, channelID discord.Snowflake, _ Channel) bool {
+		c.channelGuilds[channelID] = guildID
+		return true
+	})
+	return c
+}
+
+func storeOrDefault[V any](store Store[V]) Store[V] {
+	if store == nil {
+		return NewMapStore[V]()
+	}
+	return store
+}
+
+// Flags returns the entity kinds the cache stores.
+func (c *Cache) Flags() Flags {
+	return c.flags
+}
+
+// Before holds the cached values an event replaced or removed, as they were
+// before the event was applied. Fields are nil when the event did not touch
+// that kind of entity, or when nothing was cached for it.
+type Before struct {
+	// Guild is the guild before GUILD_UPDATE or GUILD_DELETE.
+	Guild *payloads.Guild
+
+	// Channel is the channel before CHANNEL_UPDATE or CHANNEL_DELETE.
+	Channel *Channel
+
+	// Role is the role before GUILD_ROLE_UPDATE or GUILD_ROLE_DELETE.
+	Role *payloads.Role
+
+	// Member is the member before GUILD_MEMBER_UPDATE or GUILD_MEMBER_REMOVE.
+	Member *payloads.GuildMember
+
+	// Emojis are the guild emojis before GUILD_EMOJIS_UPDATE.
+	Emojis []payloads.Emoji
+
+	// Stickers are the guild stickers before GUILD_STICKERS_UPDATE.
+	Stickers []payloads.Sticker
+
+	// VoiceState is the voice state before VOICE_STATE_UPDATE.
+	VoiceState *payloads.VoiceState
+
+	// Message is the message before MESSAGE_UPDATE or MESSAGE_DELETE.
+	Message *payloads.Message
+
+	// Messages are the cached messages removed by MESSAGE_DELETE_BULK.
+	Messages []payloads.Message
+}
+
+// Apply updates the cache with payload and returns the values it replaced or
+// removed. Payloads that do not change cached state are ignored.
+//
+// GUILD_DELETE for an unavailable guild keeps the guild's cached state, since
+// the guild is only temporarily unreachable; any other GUILD_DELETE removes
+// the guild with all of its entities.
+func (c *Cache) Apply(payload gateway.GatewayReceivePayload) Before {
+	var before Before
+
+	switch p := payload.(type) {
+	case gateway.GuildCreateDispatch:
+		c.guildCreate(p.D)
+
+	case gateway.GuildUpdateDispatch:
+		before.Guild = c.setGuild(p.D.Guild)
+		if c.flags.Has(FlagRoles) && p.D.Roles != nil {
+			c.roles.Replace(p.D.ID, rolesByID(p.D.Roles))
+		}
+		if c.flags.Has(FlagEmojis) && p.D.Emojis != nil {
+			c.emojis.Replace(p.D.ID, emojisByID(p.D.Emojis))
+		}
+
+	case gateway.GuildDeleteDispatch:
+		if guild, ok := c.Guild(p.D.ID); ok {
+			before.Guild = &guild
+		}
+		if p.D.Unavailable == nil || !*p.D.Unavailable {
+			c.removeGuild(p.D.ID)
+		}
+
+	case gateway.ChannelCreateDispatch:
+		c.setChannel(p.D)
+
+	case gateway.ChannelUpdateDispatch:
+		before.Channel = c.setChannel(p.D)
+
+	case gateway.ChannelDeleteDispatch:
+		if c.flags.Has(FlagChannels) {
+			c.mu.Lock()
+			delete(c.channelGuilds, p.D.ID)
+			c.mu.Unlock()
+			if old, ok := c.channels.Delete(p.D.GuildID, p.D.ID); ok {
+				before.Channel = &old
+			}
+		}
+		c.messages.DeleteGroup(p.D.ID)
+
+	case gateway.GuildRoleCreateDispatch:
+		if c.flags.Has(FlagRoles) {
+			c.roles.Set(p.D.GuildID, p.D.Role.ID, p.D.Role)
+		}
+
+	case gateway.GuildRoleUpdateDispatch:
+		if c.flags.Has(FlagRoles) {
+			if old, ok := c.roles.Set(p.D.GuildID, p.D.Role.ID, p.D.Role); ok {
+				before.Role = &old
+			}
+		}
+
+	case gateway.GuildRoleDeleteDispatch:
+		if c.flags.Has(FlagRoles) {
+			if old, ok := c.roles.Delete(p.D.GuildID, p.D.RoleID); ok {
+				before.Role = &old
+			}
+		}
+
+	case gateway.GuildMemberAddDispatch:
+		c.setMember(p.D.GuildID, p.D.GuildMember)
+
+	case gateway.GuildMemberUpdateDispatch:
+		member := p.D.GuildMember
+		if cached, ok := c.cachedMember(p.D.GuildID, member.User); ok && p.D.Merge(&cached) == nil {
+			member = cached
+		}
+		before.Member = c.setMember(p.D.GuildID, member)
+
+	case gateway.GuildMemberRemoveDispatch:
+		if c.flags.Has(FlagMembers) {
+			if old, ok := c.members.Delete(p.D.GuildID, p.D.User.ID); ok {
+				before.Member = &old
+			}
+		}
+
+	case gateway.GuildMembersChunkDispatch:
+		for _, member := range p.D.Members {
+			c.setMember(p.D.GuildID, member)
+		}
+
+	case gateway.GuildEmojisUpdateDispatch:
+		if c.flags.Has(FlagEmojis) {
+			before.Emojis = c.emojis.Replace(p.D.GuildID, emojisByID(p.D.Emojis))
+		}
+
+	case gateway.GuildStickersUpdateDispatch:
+		if c.flags.Has(FlagStickers) {
+			before.Stickers = c.stickers.Replace(p.D.GuildID, stickersByID(p.D.Stickers))
+		}
+
+	case gateway.VoiceStateUpdateDispatch:
+		before.VoiceState = c.setVoiceState(p.D.VoiceState)
+
+	case gateway.MessageCreateDispatch:
+		if c.flags.Has(FlagMessages) {
+			if message, ok := toMessage(p.D); ok {
+				c.messages.Set(message.ChannelID, message.ID, message)
+			}
+		}
+
+	case gateway.MessageUpdateDispatch:
+		if c.flags.Has(FlagMessages) {
+			// Only an update of a cached message can be completed; the
+			// others may lack the author and are not stored.
+			if cached, ok := c.messages.Get(p.D.ChannelID, p.D.ID); ok {
+				old := cached
+				if p.D.Merge(&cached) == nil {
+					c.messages.Set(cached.ChannelID, cached.ID, cached)
+					before.Message = &old
+				}
+			}
+		}
+
+	case gateway.MessageDeleteDispatch:
+		if c.flags.Has(FlagMessages) {
+			if old, ok := c.messages.Delete(p.D.ChannelID, p.D.ID); ok {
+				before.Message = &old
+			}
+		}
+
+	case gateway.MessageDeleteBulkDispatch:
+		if c.flags.Has(FlagMessages) {
+			for _, id := range p.D.IDs {
+				if old, ok := c.messages.Delete(p.D.ChannelID, id); ok {
+					before.Messages = append(before.Messages, old)
+				}
+			}
+		}
+	}
+
+	return before
+}
+
+// beforeKey is the context key of the Before value stored by Wrap.
+type beforeKey struct{}
+
+// Wrap returns a gateway.Handler that applies every payload to the cache
+// before passing it to next. next can retrieve the replaced values with
+// BeforeFromContext.
+func (c *Cache) Wrap(next gateway.Handler) gateway.Handler {
+	return func(ctx context.Context, payload gateway.GatewayReceivePayload) {
+		before := c.Apply(payload)
+		if next != nil {
+			next(context.WithValue(ctx, beforeKey{}, before), payload)
+		}
+	}
+}
+
+// BeforeFromContext returns the values replaced by the payload being handled,
+// if it was delivered through Wrap.
+func BeforeFromContext(ctx context.Context) (Before, bool) {
+	before, ok := ctx.Value(beforeKey{}).(Before)
+	return before, ok
+}
+
+func (c *Cache) guildCreate(d gateway.GuildCreateDispatchData) {
+	guildID := d.ID
+	if d.Unavailable != nil && *d.Unavailable {
+		return
+	}
+
+	c.setGuild(d.Guild)
+	if c.flags.Has(FlagChannels) {
+		channels := make(map[discord.Snowflake]Channel, len(d.Channels))
+		for _, channel := range d.Channels {
+			// Channels in GUILD_CREATE do not carry their guild id.
+			channel.GuildID = guildID
+			channel.GuildChannel.GuildID = &guildID
+			channels[channel.ID] = channel
+		}
+		c.mu.Lock()
+		// The snapshot replaces the guild's channels: channels missing from
+		// it were deleted while the guild was out of reach.
+		for channelID, channelGuildID := range c.channelGuilds {
+			if _, ok := channels[channelID]; channelGuildID == guildID && !ok {
+				delete(c.channelGuilds, channelID)
+				c.messages.DeleteGroup(channelID)
+			}
+		}
+		for channelID := range channels {
+			c.channelGuilds[channelID] = guildID
+		}
+		c.mu.Unlock()
+		c.channels.Replace(guildID, channels)
+	}
+	if c.flags.Has(FlagRoles) {
+		c.roles.Replace(guildID, rolesByID(d.Roles))
+	}
+	if c.flags.Has(FlagEmojis) {
+		c.emojis.Replace(guildID, emojisByID(d.Emojis))
+	}
+	if c.flags.Has(FlagStickers) {
+		c.stickers.Replace(guildID, stickersByID(d.Stickers))
+	}
+	if c.flags.Has(FlagMembers) {
+		// Members are only a subset for large guilds, so they are added to
+		// rather than replacing what was requested before.
+		for _, member := range d.Members {
+			c.setMember(guildID, member)
+		}
+	}
+	if c.flags.Has(FlagVoiceStates) {
+		states := make(map[discord.Snowflake]payloads.VoiceState, len(d.VoiceStates))
+		for _, state := range d.VoiceStates {
+			state.GuildID = &guildID
+			states[state.UserID] = state
+		}
+		c.voiceStates.Replace(guildID, states)
+	}
+}
+
+// setGuild stores guild without the entities cached separately and returns
+// the guild it replaced.
+func (c *Cache) setGuild(guild payloads.Guild) *payloads.Guild {
+	if !c.flags.Has(FlagGuilds) {
+		return nil
+	}
+	guild.Roles, guild.Emojis, guild.Stickers = nil, nil, nil
+
+	if old, ok := c.guilds.Set(guild.ID, guild.ID, guild); ok {
+		return &old
+	}
+	return nil
+}
+
+func (c *Cache) setChannel(channel Channel) *Channel {
+	if !c.flags.Has(FlagChannels) {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.channelGuilds[channel.ID] = channel.GuildID
+	c.mu.Unlock()
+	if old, ok := c.channels.Set(channel.GuildID, channel.ID, channel); ok {
+		return &old
+	}
+	return nil
+}
+
+// cachedMember returns the cached member of user, if any.
+func (c *Cache) cachedMember(guildID discord.Snowflake, user *payloads.User) (payloads.GuildMember, bool) {
+	if !c.flags.Has(FlagMembers) || user == nil {
+		return payloads.GuildMember{}, false
+	}
+	return c.members.Get(guildID, user.ID)
+}
+
+func (c *Cache) setMember(guildID discord.Snowflake, member payloads.GuildMember) *payloads.GuildMember {
+	if !c.flags.Has(FlagMembers) || member.User == nil {
+		return nil
+	}
+	if old, ok := c.members.Set(guildID, member.User.ID, member); ok {
+		return &old
+	}
+	return nil
+}
+
+// setVoiceState stores state, or removes it when the user left voice, and
+// returns the state it replaced.
+func (c *Cache) setVoiceState(state payloads.VoiceState) *payloads.VoiceState {
+	if !c.flags.Has(FlagVoiceStates) || state.GuildID == nil {
+		return nil
+	}
+
+	var (
+		old payloads.VoiceState
+		ok  bool
+	)
+	if state.ChannelID == nil {
+		old, ok = c.voiceStates.Delete(*state.GuildID, state.UserID)
+	} else {
+		old, ok = c.voiceStates.Set(*state.GuildID, state.UserID, state)
+	}
+	if !ok {
+		return nil
+	}
+	return &old
+}
+
+func (c *Cache) removeGuild(guildID discord.Snowflake) {
+	c.mu.Lock()
+	for channelID, channelGuildID := range c.channelGuilds {
+		if channelGuildID == guildID {
+			delete(c.channelGuilds, channelID)
+			c.messages.DeleteGroup(channelID)
+		}
+	}
+	c.mu.Unlock()
+
+	c.guilds.DeleteGroup(guildID)
+	c.channels.DeleteGroup(guildID)
+	c.roles.DeleteGroup(guildID)
+	c.members.DeleteGroup(guildID)
+	c.emojis.DeleteGroup(guildID)
+	c.stickers.DeleteGroup(guildID)
+	c.voiceStates.DeleteGroup(guildID)
+}
+
+// Guild returns a cached guild. Its Roles, Emojis and Stickers are not set;
+// use the Roles, Emojis and Stickers methods.
+func (c *Cache) Guild(guildID discord.Snowflake) (payloads.Guild, bool) {
+	return c.guilds.Get(guildID, guildID)
+}
+
+// Guilds returns every cached guild, in no particular order.
+func (c *Cache) Guilds() []payloads.Guild {
+	var guilds []payloads.Guild
+	c.guilds.Range(func(_, _ discord.Snowflake, guild payloads.Guild) bool {
+		guilds = append(guilds, guild)
+		return true
+	})
+	return guilds
+}
+
+// Channel returns a cached channel.
+func (c *Cache) Channel(channelID discord.Snowflake) (Channel, bool) {
+	c.mu.RLock()
+	guildID, ok := c.channelGuilds[channelID]
+	c.mu.RUnlock()
+	if !ok {
+		return Channel{}, false
+	}
+	return c.channels.Get(guildID, channelID)
+}
+
+// Channels returns the cached channels of a guild, in no particular order.
+func (c *Cache) Channels(guildID discord.Snowflake) []Channel {
+	return c.channels.List(guildID)
+}
+
+// Role returns a cached role.
+func (c *Cache) Role(guildID, roleID discord.Snowflake) (payloads.Role, bool) {
+	return c.roles.Get(guildID, roleID)
+}
+
+// Roles returns the cached roles of a guild, in no particular order.
+func (c *Cache) Roles(guildID discord.Snowflake) []payloads.Role {
+	return c.roles.List(guildID)
+}
+
+// Member returns a cached guild member.
+func (c *Cache) Member(guildID, userID discord.Snowflake) (payloads.GuildMember, bool) {
+	return c.members.Get(guildID, userID)
+}
+
+// Members returns the cached members of a guild, in no particular order.
+func (c *Cache) Members(guildID discord.Snowflake) []payloads.GuildMember {
+	return c.members.List(guildID)
+}
+
+// Emoji returns a cached guild emoji.
+func (c *Cache) Emoji(guildID, emojiID discord.Snowflake) (payloads.Emoji, bool) {
+	return c.emojis.Get(guildID, emojiID)
+}
+
+// Emojis returns the cached emojis of a guild, in no particular order.
+func (c *Cache) Emojis(guildID discord.Snowflake) []payloads.Emoji {
+	return c.emojis.List(guildID)
+}
+
+// Sticker returns a cached guild sticker.
+func (c *Cache) Sticker(guildID, stickerID discord.Snowflake) (payloads.Sticker, bool) {
+	return c.stickers.Get(guildID, stickerID)
+}
+
+// Stickers returns the cached stickers of a guild, in no particular order.
+func (c *Cache) Stickers(guildID discord.Snowflake) []payloads.Sticker {
+	return c.stickers.List(guildID)
+}
+
+// VoiceState returns the cached voice state of a user in a guild.
+func (c *Cache) VoiceState(guildID, userID discord.Snowflake) (payloads.VoiceState, bool) {
+	return c.voiceStates.Get(guildID, userID)
+}
+
+// VoiceStates returns the cached voice states of a guild, in no particular order.
+func (c *Cache) VoiceStates(guildID discord.Snowflake) []payloads.VoiceState {
+	return c.voiceStates.List(guildID)
+}
+
+// Message returns a cached message.
+func (c *Cache) Message(channelID, messageID discord.Snowflake) (payloads.Message, bool) {
+	return c.messages.Get(channelID, messageID)
+}
+
+// Messages returns the cached messages of a channel, in no particular order.
+func (c *Cache) Messages(channelID discord.Snowflake) []payloads.Message {
+	return c.messages.List(channelID)
+}
+
+// toMessage converts a message received from the Gateway to the REST
+// message object. The two share their JSON representation, so the
+// conversion goes through it.
+func toMessage(d gateway.MessageCreateDispatchData) (payloads.Message, bool) {
+	var message payloads.Message
+	data, err := json.Marshal(d)
+	if err != nil {
+		return message, false
+	}
+	return message, json.Unmarshal(data, &message) == nil
+}
+
+func rolesByID(roles []payloads.Role) map[discord.Snowflake]payloads.Role {
+	byID := make(map[discord.Snowflake]payloads.Role, len(roles))
+	for _, role := range roles {
+		byID[role.ID] = role
+	}
+	return byID
+}
+
+// emojisByID indexes emojis by id. Guild emojis always have one.
+func emojisByID(emojis []payloads.Emoji) map[discord.Snowflake]payloads.Emoji {
+	byID := make(map[discord.Snowflake]payloads.Emoji, len(emojis))
+	for _, emoji := range emojis {
+		if emoji.ID != nil {
+			byID[*emoji.ID] = emoji
+		}
+	}
+	return byID
+}
+
+func stickersByID(stickers []payloads.Sticker) map[discord.Snowflake]payloads.Sticker {
+	byID := make(map[discord.Snowflake]payloads.Sticker, len(stickers))
+	for _, sticker := range stickers {
+		byID[sticker.ID] = sticker
+	}
+	return byID
+}
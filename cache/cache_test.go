@@ -0,0 +1,393 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/gateway"
+	"github.com/kolosys/discord-types/payloads"
+)
+
+const (
+	guildID = discord.Snowflake("41771983423143937")
+	nelly   = discord.Snowflake("80351110224678912")
+	mason   = discord.Snowflake("53908099506183680")
+)
+
+// apply decodes a dispatch frame and applies it to c.
+func apply(t *testing.T, c *Cache, event, data string) Before {
+	t.Helper()
+
+	payload, err := gateway.DecodeReceivePayload([]byte(`{"op":0,"s":1,"t":"` + event + `","d":` + data + `}`))
+	if err != nil {
+		t.Fatalf("DecodeReceivePayload(%s) error = %v", event, err)
+	}
+	return c.Apply(payload)
+}
+
+// replaySession applies the recorded session in the gateway test data to c.
+func replaySession(t *testing.T, c *Cache) {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("..", "gateway", "testdata", "captures", "session.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	err = gateway.Replay(context.Background(), f, gateway.ReplayConfig{
+		Handler: func(_ context.Context, _ int, payload gateway.GatewayReceivePayload) {
+			c.Apply(payload)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+}
+
+func TestCache_Session(t *testing.T) {
+	c := New(Config{Flags: FlagAll})
+	replaySession(t, c)
+
+	guild, ok := c.Guild(guildID)
+	if !ok || guild.Name != "Discord Developers" {
+		t.Fatalf("Guild() = %q, %v, want Discord Developers", guild.Name, ok)
+	}
+	if guild.Roles != nil {
+		t.Errorf("Guild().Roles = %v, want nil", guild.Roles)
+	}
+	if len(c.Guilds()) != 1 {
+		t.Errorf("len(Guilds()) = %d, want 1", len(c.Guilds()))
+	}
+
+	channel, ok := c.Channel("41771983423143937")
+	if !ok || channel.Name != "general" || channel.GuildID != guildID {
+		t.Errorf("Channel() = %+v, %v, want general in the guild", channel, ok)
+	}
+	if role, ok := c.Role(guildID, guildID); !ok || role.Name != "@everyone" {
+		t.Errorf("Role(@everyone) = %+v, %v", role, ok)
+	}
+	if _, ok := c.Member(guildID, nelly); !ok {
+		t.Error("Member(Nelly) not cached from GUILD_CREATE")
+	}
+	if _, ok := c.Member(guildID, mason); ok {
+		t.Error("Member(Mason) still cached after GUILD_MEMBER_REMOVE")
+	}
+}
+
+func TestCache_GuildCreateReplacesChannels(t *testing.T) {
+	c := New(Config{Flags: FlagAll})
+	apply(t, c, "GUILD_CREATE", `{"id":"41771983423143937","name":"Test","roles":[],"emojis":[],"channels":[`+
+		`{"id":"290926798999357250","type":0,"name":"general"},{"id":"290926798999357251","type":0,"name":"old"}]}`)
+	apply(t, c, "MESSAGE_CREATE", `{"id":"334385199974967042","channel_id":"290926798999357251","guild_id":"41771983423143937","author":{"id":"80351110224678912","username":"Nelly"},"content":"hi","timestamp":"2017-07-11T17:27:07.299000+00:00","type":0}`)
+
+	// The channel was deleted while the guild was unavailable.
+	apply(t, c, "GUILD_CREATE", `{"id":"41771983423143937","name":"Test","roles":[],"emojis":[],"channels":[`+
+		`{"id":"290926798999357250","type":0,"name":"general"}]}`)
+
+	if _, ok := c.Channel("290926798999357251"); ok {
+		t.Error("Channel() still returns the deleted channel")
+	}
+	if _, ok := c.channelGuilds["290926798999357251"]; ok {
+		t.Error("deleted channel still indexed to its guild")
+	}
+	if messages := c.Messages("290926798999357251"); len(messages) != 0 {
+		t.Errorf("Messages() of the deleted channel = %+v, want none", messages)
+	}
+	if channel, ok := c.Channel("290926798999357250"); !ok || channel.GuildID != guildID {
+		t.Errorf("Channel(general) = %+v, %v, want it kept", channel, ok)
+	}
+}
+
+func TestCache_Before(t *testing.T) {
+	c := New(Config{Flags: FlagAll})
+	replaySession(t, c)
+
+	tests := []struct {
+		name  string
+		event string
+		data  string
+		check func(t *testing.T, before Before)
+	}{
+		{
+			name:  "Channel create",
+			event: "CHANNEL_CREATE",
+			data:  `{"id":"222079895583457280","type":2,"guild_id":"41771983423143937","name":"Voice","position":1}`,
+			check: func(t *testing.T, before Before) {
+				if before.Channel != nil {
+					t.Errorf("Before.Channel = %+v, want nil", before.Channel)
+				}
+			},
+		},
+		{
+			name:  "Channel update",
+			event: "CHANNEL_UPDATE",
+			data:  `{"id":"222079895583457280","type":2,"guild_id":"41771983423143937","name":"Lounge","position":1}`,
+			check: func(t *testing.T, before Before) {
+				if before.Channel == nil || before.Channel.Name != "Voice" {
+					t.Errorf("Before.Channel = %+v, want Voice", before.Channel)
+				}
+				if channel, _ := c.Channel("222079895583457280"); channel.Name != "Lounge" {
+					t.Errorf("Channel().Name = %q, want Lounge", channel.Name)
+				}
+			},
+		},
+		{
+			name:  "Role create",
+			event: "GUILD_ROLE_CREATE",
+			data:  `{"guild_id":"41771983423143937","role":{"id":"175928847299117063","name":"Mods","permissions":"0"}}`,
+		},
+		{
+			name:  "Role update",
+			event: "GUILD_ROLE_UPDATE",
+			data:  `{"guild_id":"41771983423143937","role":{"id":"175928847299117063","name":"Moderators","permissions":"8"}}`,
+			check: func(t *testing.T, before Before) {
+				if before.Role == nil || before.Role.Name != "Mods" {
+					t.Errorf("Before.Role = %+v, want Mods", before.Role)
+				}
+			},
+		},
+		{
+			name:  "Role delete",
+			event: "GUILD_ROLE_DELETE",
+			data:  `{"guild_id":"41771983423143937","role_id":"175928847299117063"}`,
+			check: func(t *testing.T, before Before) {
+				if before.Role == nil || before.Role.Name != "Moderators" {
+					t.Errorf("Before.Role = %+v, want Moderators", before.Role)
+				}
+				if len(c.Roles(guildID)) != 1 {
+					t.Errorf("Roles() = %+v, want only @everyone", c.Roles(guildID))
+				}
+			},
+		},
+		{
+			name:  "Emojis update",
+			event: "GUILD_EMOJIS_UPDATE",
+			data:  `{"guild_id":"41771983423143937","emojis":[{"id":"41771983429993937","name":"LUL"}]}`,
+			check: func(t *testing.T, before Before) {
+				if len(before.Emojis) != 0 {
+					t.Errorf("Before.Emojis = %+v, want none", before.Emojis)
+				}
+				if emoji, ok := c.Emoji(guildID, "41771983429993937"); !ok || *emoji.Name != "LUL" {
+					t.Errorf("Emoji() = %+v, %v, want LUL", emoji, ok)
+				}
+			},
+		},
+		{
+			name:  "Emojis removed",
+			event: "GUILD_EMOJIS_UPDATE",
+			data:  `{"guild_id":"41771983423143937","emojis":[]}`,
+			check: func(t *testing.T, before Before) {
+				if len(before.Emojis) != 1 || len(c.Emojis(guildID)) != 0 {
+					t.Errorf("Before.Emojis = %+v, Emojis() = %+v, want one before and none after", before.Emojis, c.Emojis(guildID))
+				}
+			},
+		},
+		{
+			name:  "Stickers update",
+			event: "GUILD_STICKERS_UPDATE",
+			data:  `{"guild_id":"41771983423143937","stickers":[{"id":"749054660769218631","name":"Wave","type":2,"format_type":1}]}`,
+			check: func(t *testing.T, _ Before) {
+				if sticker, ok := c.Sticker(guildID, "749054660769218631"); !ok || sticker.Name != "Wave" {
+					t.Errorf("Sticker() = %+v, %v, want Wave", sticker, ok)
+				}
+			},
+		},
+		{
+			name:  "Voice join",
+			event: "VOICE_STATE_UPDATE",
+			data:  `{"guild_id":"41771983423143937","channel_id":"222079895583457280","user_id":"80351110224678912","session_id":"a"}`,
+			check: func(t *testing.T, before Before) {
+				if before.VoiceState != nil {
+					t.Errorf("Before.VoiceState = %+v, want nil", before.VoiceState)
+				}
+			},
+		},
+		{
+			name:  "Voice leave",
+			event: "VOICE_STATE_UPDATE",
+			data:  `{"guild_id":"41771983423143937","channel_id":null,"user_id":"80351110224678912","session_id":"a"}`,
+			check: func(t *testing.T, before Before) {
+				if before.VoiceState == nil || *before.VoiceState.ChannelID != "222079895583457280" {
+					t.Errorf("Before.VoiceState = %+v, want the joined channel", before.VoiceState)
+				}
+				if _, ok := c.VoiceState(guildID, nelly); ok {
+					t.Error("VoiceState() still cached after leaving")
+				}
+			},
+		},
+		{
+			name:  "Member update",
+			event: "GUILD_MEMBER_UPDATE",
+			data:  `{"guild_id":"41771983423143937","user":{"id":"80351110224678912","username":"Nelly"},"nick":"Nel","roles":[],"joined_at":"2015-04-26T06:26:56.936000+00:00"}`,
+			check: func(t *testing.T, before Before) {
+				if before.Member == nil || before.Member.Nick != nil {
+					t.Errorf("Before.Member = %+v, want the member without nick", before.Member)
+				}
+			},
+		},
+		{
+			name:  "Partial member update",
+			event: "GUILD_MEMBER_UPDATE",
+			data:  `{"guild_id":"41771983423143937","user":{"id":"80351110224678912","username":"Nelly"},"roles":["41771983423143936"]}`,
+			check: func(t *testing.T, before Before) {
+				if before.Member == nil || before.Member.Nick == nil || len(before.Member.Roles) != 0 {
+					t.Errorf("Before.Member = %+v, want the member with nick and without roles", before.Member)
+				}
+				member, _ := c.Member(guildID, nelly)
+				if member.Nick == nil || *member.Nick != "Nel" || len(member.Roles) != 1 {
+					t.Errorf("Member() = %+v, want the nick kept and the role added", member)
+				}
+			},
+		},
+		{
+			name:  "Guild update",
+			event: "GUILD_UPDATE",
+			data:  `{"id":"41771983423143937","name":"Discord API","roles":[{"id":"41771983423143937","name":"@everyone","permissions":"0"}]}`,
+			check: func(t *testing.T, before Before) {
+				if before.Guild == nil || before.Guild.Name != "Discord Developers" {
+					t.Errorf("Before.Guild = %+v, want Discord Developers", before.Guild)
+				}
+			},
+		},
+		{
+			name:  "Guild outage",
+			event: "GUILD_DELETE",
+			data:  `{"id":"41771983423143937","unavailable":true}`,
+			check: func(t *testing.T, before Before) {
+				if before.Guild == nil || before.Guild.Name != "Discord API" {
+					t.Errorf("Before.Guild = %+v, want Discord API", before.Guild)
+				}
+				if _, ok := c.Guild(guildID); !ok {
+					t.Error("Guild() removed by an outage")
+				}
+			},
+		},
+		{
+			name:  "Guild left",
+			event: "GUILD_DELETE",
+			data:  `{"id":"41771983423143937"}`,
+			check: func(t *testing.T, before Before) {
+				if before.Guild == nil {
+					t.Error("Before.Guild = nil, want the guild")
+				}
+				if _, ok := c.Guild(guildID); ok {
+					t.Error("Guild() still cached after leaving")
+				}
+				if _, ok := c.Channel("222079895583457280"); ok || len(c.Roles(guildID)) != 0 || len(c.Members(guildID)) != 0 || len(c.Stickers(guildID)) != 0 {
+					t.Error("guild entities still cached after leaving")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := apply(t, c, tt.event, tt.data)
+			if tt.check != nil {
+				tt.check(t, before)
+			}
+		})
+	}
+}
+
+func TestCache_Flags(t *testing.T) {
+	c := New(Config{Flags: FlagRoles | FlagMembers})
+	replaySession(t, c)
+
+	if len(c.Guilds()) != 0 {
+		t.Error("guild cached without FlagGuilds")
+	}
+	if _, ok := c.Channel("41771983423143937"); ok {
+		t.Error("channel cached without FlagChannels")
+	}
+	if len(c.Roles(guildID)) != 1 {
+		t.Errorf("len(Roles()) = %d, want 1", len(c.Roles(guildID)))
+	}
+	if len(c.Members(guildID)) != 1 {
+		t.Errorf("len(Members()) = %d, want 1", len(c.Members(guildID)))
+	}
+	if !c.Flags().Has(FlagRoles) || c.Flags().Has(FlagGuilds) {
+		t.Errorf("Flags() = %b, want roles and members", c.Flags())
+	}
+}
+
+func TestCache_Wrap(t *testing.T) {
+	c := New(Config{Flags: FlagAll})
+	replaySession(t, c)
+
+	var got *Before
+	handler := c.Wrap(func(ctx context.Context, _ gateway.GatewayReceivePayload) {
+		if before, ok := BeforeFromContext(ctx); ok {
+			got = &before
+		}
+	})
+
+	payload, err := gateway.DecodeReceivePayload([]byte(`{"op":0,"s":9,"t":"GUILD_ROLE_DELETE","d":{"guild_id":"41771983423143937","role_id":"41771983423143937"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler(context.Background(), payload)
+
+	if got == nil || got.Role == nil || got.Role.Name != "@everyone" {
+		t.Errorf("BeforeFromContext() = %+v, want the deleted role", got)
+	}
+	if _, ok := BeforeFromContext(context.Background()); ok {
+		t.Error("BeforeFromContext() without Wrap reported ok")
+	}
+}
+
+func TestCache_Messages(t *testing.T) {
+	c := New(Config{
+		Flags:    FlagMessages,
+		Messages: NewLRUStore(LRUConfig[payloads.Message]{MaxEntries: 1}),
+	})
+	replaySession(t, c)
+
+	const channelID = "290926798999357250"
+	const messageID = "334385199974967042"
+	message, ok := c.Message(channelID, messageID)
+	if !ok || message.Author.Username == "" || message.Timestamp.IsZero() {
+		t.Fatalf("Message() = %+v, %v, want the message from the capture", message, ok)
+	}
+
+	before := apply(t, c, "MESSAGE_UPDATE", `{"id":"334385199974967042","channel_id":"290926798999357250","guild_id":"41771983423143937","content":"Supa Hot Fire","edited_timestamp":"2017-07-11T17:30:00.000000+00:00"}`)
+	if before.Message == nil || before.Message.Content != message.Content {
+		t.Errorf("Before.Message = %+v, want the message before the edit", before.Message)
+	}
+	edited, _ := c.Message(channelID, messageID)
+	if edited.Content != "Supa Hot Fire" || edited.Author.Username != message.Author.Username || edited.EditedTimestamp == nil {
+		t.Errorf("Message() after MESSAGE_UPDATE = %+v, want the edit merged", edited)
+	}
+
+	apply(t, c, "MESSAGE_CREATE", `{"id":"334385199974967043","channel_id":"290926798999357250","author":{"id":"80351110224678912","username":"Nelly"},"content":"second","timestamp":"2017-07-11T17:28:07.299000+00:00","type":0}`)
+	if _, ok := c.Message(channelID, messageID); ok {
+		t.Error("Message() not evicted from a store limited to one entry")
+	}
+
+	before = apply(t, c, "MESSAGE_DELETE_BULK", `{"ids":["334385199974967042","334385199974967043"],"channel_id":"290926798999357250"}`)
+	if len(before.Messages) != 1 || before.Messages[0].Content != "second" {
+		t.Errorf("Before.Messages = %+v, want the cached message", before.Messages)
+	}
+}
+
+func TestCache_Restore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channels.jsonl")
+	channels := NewSnapshotStore[Channel](path, nil)
+	replaySession(t, New(Config{Flags: FlagChannels, Channels: channels}))
+	if err := channels.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	restored := NewSnapshotStore[Channel](path, nil)
+	if err := restored.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	c := New(Config{Flags: FlagChannels, Channels: restored})
+	if channel, ok := c.Channel("41771983423143937"); !ok || channel.Name != "general" {
+		t.Errorf("Channel() after restore = %+v, %v, want general", channel, ok)
+	}
+}
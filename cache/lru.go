@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// LRUConfig configures an LRUStore. Zero limits are unlimited.
+type LRUConfig[V any] struct {
+	// MaxEntries is the most entities the store holds.
+	MaxEntries int
+
+	// MaxBytes is the most bytes, as measured by Size, the store holds.
+	MaxBytes int64
+
+	// Size returns the size of an entity for MaxBytes. Defaults to the
+	// length of its JSON encoding, which approximates its size in memory.
+	Size func(V) int64
+
+	// TTL is how long an entity is kept after it was last set.
+	TTL time.Duration
+}
+
+// LRUStore is a Store that evicts the least recently used entities once
+// it exceeds its entry or byte budget, and expires entities that were not
+// set within their TTL.
+type LRUStore[V any] struct {
+	cfg LRUConfig[V]
+	now func() time.Time
+
+	mu     sync.Mutex
+	groups map[discord.Snowflake]map[discord.Snowflake]*lruEntry[V]
+	recent *list.List // front is the most recently used
+	age    *list.List // front is the most recently set
+	count  int
+	bytes  int64
+}
+
+type lruEntry[V any] struct {
+	group, id discord.Snowflake
+	value     V
+	size      int64
+	expires   time.Time
+	recent    *list.Element
+	age       *list.Element
+}
+
+// NewLRUStore returns an empty LRUStore.
+func NewLRUStore[V any](cfg LRUConfig[V]) *LRUStore[V] {
+	if cfg.Size == nil && cfg.MaxBytes > 0 {
+		cfg.Size = func(v V) int64 {
+			data, _ := json.Marshal(v)
+			return int64(len(data))
+		}
+	}
+	return &LRUStore[V]{
+		cfg:    cfg,
+		now:    time.Now,
+		groups: make(map[discord.Snowflake]map[discord.Snowflake]*lruEntry[V]),
+		recent: list.New(),
+		age:    list.New(),
+	}
+}
+
+// Get implements Store. A successful Get marks the entity as recently used.
+func (s *LRUStore[V]) Get(group, id discord.Snowflake) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	e, ok := s.groups[group][id]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	s.recent.MoveToFront(e.recent)
+	return e.value, true
+}
+
+// Set implements Store.
+func (s *LRUStore[V]) Set(group, id discord.Snowflake, v V) (old V, replaced bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	old, replaced = s.set(group, id, v)
+	s.evict()
+	return old, replaced
+}
+
+// Delete implements Store.
+func (s *LRUStore[V]) Delete(group, id discord.Snowflake) (old V, deleted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	e, ok := s.groups[group][id]
+	if !ok {
+		return old, false
+	}
+	s.remove(e)
+	return e.value, true
+}
+
+// List implements Store. Listing does not mark entities as recently used.
+func (s *LRUStore[V]) List(group discord.Snowflake) []V {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	entities := make([]V, 0, len(s.groups[group]))
+	for _, e := range s.groups[group] {
+		entities = append(entities, e.value)
+	}
+	return entities
+}
+
+// Replace implements Store.
+func (s *LRUStore[V]) Replace(group discord.Snowflake, entities map[discord.Snowflake]V) (old []V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	for _, e := range s.groups[group] {
+		old = append(old, e.value)
+		s.remove(e)
+	}
+	for id, v := range entities {
+		s.set(group, id, v)
+	}
+	s.evict()
+	return old
+}
+
+// DeleteGroup implements Store.
+func (s *LRUStore[V]) DeleteGroup(group discord.Snowflake) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.groups[group] {
+		s.remove(e)
+	}
+}
+
+// Range implements Store.
+func (s *LRUStore[V]) Range(fn func(group, id discord.Snowflake, v V) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	for group, entities := range s.groups {
+		for id, e := range entities {
+			if !fn(group, id, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Len implements Store.
+func (s *LRUStore[V]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	return s.count
+}
+
+// Bytes returns the total size of the stored entities, or 0 if the store
+// has no byte budget.
+func (s *LRUStore[V]) Bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	return s.bytes
+}
+
+func (s *LRUStore[V]) set(group, id discord.Snowflake, v V) (old V, replaced bool) {
+	if e, ok := s.groups[group][id]; ok {
+		old, replaced = e.value, true
+		s.remove(e)
+	}
+
+	e := &lruEntry[V]{group: group, id: id, value: v}
+	if s.cfg.Size != nil {
+		e.size = s.cfg.Size(v)
+	}
+	if s.cfg.TTL > 0 {
+		e.expires = s.now().Add(s.cfg.TTL)
+	}
+	e.recent = s.recent.PushFront(e)
+	e.age = s.age.PushFront(e)
+
+	entities := s.groups[group]
+	if entities == nil {
+		entities = make(map[discord.Snowflake]*lruEntry[V])
+		s.groups[group] = entities
+	}
+	entities[id] = e
+	s.count++
+	s.bytes += e.size
+	return old, replaced
+}
+
+func (s *LRUStore[V]) remove(e *lruEntry[V]) {
+	s.recent.Remove(e.recent)
+	s.age.Remove(e.age)
+
+	entities := s.groups[e.group]
+	delete(entities, e.id)
+	if len(entities) == 0 {
+		delete(s.groups, e.group)
+	}
+	s.count--
+	s.bytes -= e.size
+}
+
+// expire removes the entities whose TTL has passed, oldest first.
+func (s *LRUStore[V]) expire() {
+	if s.cfg.TTL <= 0 {
+		return
+	}
+	now := s.now()
+	for back := s.age.Back(); back != nil; back = s.age.Back() {
+		e := back.Value.(*lruEntry[V])
+		if now.Before(e.expires) {
+			return
+		}
+		s.remove(e)
+	}
+}
+
+// evict removes the least recently used entities until the store is within
+// its budget.
+func (s *LRUStore[V]) evict() {
+	for s.recent.Len() > 0 &&
+		((s.cfg.MaxEntries > 0 && s.count > s.cfg.MaxEntries) || (s.cfg.MaxBytes > 0 && s.bytes > s.cfg.MaxBytes)) {
+		s.remove(s.recent.Back().Value.(*lruEntry[V]))
+	}
+}
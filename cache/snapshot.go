@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// SnapshotStore is a Store that can save its entities to a file and restore
+// them at startup, so a restarted bot does not have to request every member
+// of every guild again.
+//
+// It wraps another Store that holds the entities in memory, and only touches
+// the file when Load or Save is called. Save periodically and on shutdown,
+// and Load before passing the store to New.
+type SnapshotStore[V any] struct {
+	Store[V]
+
+	path string
+}
+
+// snapshotEntry is one line of a snapshot file.
+type snapshotEntry[V any] struct {
+	Group discord.Snowflake `json:"group"`
+	ID    discord.Snowflake `json:"id"`
+	Value V                 `json:"value"`
+}
+
+// NewSnapshotStore returns a SnapshotStore that keeps its entities in store
+// and its snapshot at path. A nil store uses a MapStore.
+func NewSnapshotStore[V any](path string, store Store[V]) *SnapshotStore[V] {
+	if store == nil {
+		store = NewMapStore[V]()
+	}
+	return &SnapshotStore[V]{Store: store, path: path}
+}
+
+// Load adds the entities of the snapshot file to the store. A missing file
+// is not an error, so Load can be called unconditionally at startup.
+func (s *SnapshotStore[V]) Load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cache: load snapshot: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var entry snapshotEntry[V]
+		if err := dec.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("cache: load snapshot %s: %w", s.path, err)
+		}
+		s.Set(entry.Group, entry.ID, entry.Value)
+	}
+}
+
+// Save writes every entity of the store to the snapshot file. The file is
+// replaced atomically, so a failed Save leaves the previous snapshot intact.
+func (s *SnapshotStore[V]) Save() (err error) {
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cache: save snapshot: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	s.Range(func(group, id discord.Snowflake, v V) bool {
+		err = enc.Encode(snapshotEntry[V]{Group: group, ID: id, Value: v})
+		return err == nil
+	})
+	if err != nil {
+		return fmt.Errorf("cache: save snapshot: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("cache: save snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cache: save snapshot: %w", err)
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("cache: save snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// Store holds cached entities of one kind. Entities are grouped by the id of
+// what they belong to: the guild for members, roles, channels, emojis,
+// stickers and voice states, the channel for messages, and the guild itself
+// for guilds.
+//
+// A Store must be safe for concurrent use. Stores may evict entities at any
+// time, so a Get after a Set is not guaranteed to succeed.
+type Store[V any] interface {
+	// Get returns an entity.
+	Get(group, id discord.Snowflake) (V, bool)
+
+	// Set stores an entity and returns the one it replaced, if any.
+	Set(group, id discord.Snowflake, v V) (old V, replaced bool)
+
+	// Delete removes an entity and returns it, if it was stored.
+	Delete(group, id discord.Snowflake) (old V, deleted bool)
+
+	// List returns the entities of a group, in no particular order.
+	List(group discord.Snowflake) []V
+
+	// Replace swaps every entity of a group for entities, keyed by id, and
+	// returns the previous ones.
+	Replace(group discord.Snowflake, entities map[discord.Snowflake]V) (old []V)
+
+	// DeleteGroup removes every entity of a group.
+	DeleteGroup(group discord.Snowflake)
+
+	// Range calls fn for every entity until fn returns false. fn must not
+	// modify the store.
+	Range(fn func(group, id discord.Snowflake, v V) bool)
+
+	// Len returns the number of stored entities.
+	Len() int
+}
+
+// MapStore is an unbounded Store backed by maps.
+type MapStore[V any] struct {
+	mu     sync.RWMutex
+	groups map[discord.Snowflake]map[discord.Snowflake]V
+}
+
+// NewMapStore returns an empty MapStore.
+func NewMapStore[V any]() *MapStore[V] {
+	return &MapStore[V]{groups: make(map[discord.Snowflake]map[discord.Snowflake]V)}
+}
+
+// Get implements Store.
+func (s *MapStore[V]) Get(group, id discord.Snowflake) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.groups[group][id]
+	return v, ok
+}
+
+// Set implements Store.
+func (s *MapStore[V]) Set(group, id discord.Snowflake, v V) (old V, replaced bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entities := s.groups[group]
+	if entities == nil {
+		entities = make(map[discord.Snowflake]V)
+		s.groups[group] = entities
+	}
+	old, replaced = entities[id]
+	entities[id] = v
+	return old, replaced
+}
+
+// Delete implements Store.
+func (s *MapStore[V]) Delete(group, id discord.Snowflake) (old V, deleted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entities := s.groups[group]
+	old, deleted = entities[id]
+	delete(entities, id)
+	if len(entities) == 0 {
+		delete(s.groups, group)
+	}
+	return old, deleted
+}
+
+// List implements Store.
+func (s *MapStore[V]) List(group discord.Snowflake) []V {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Collect(maps.Values(s.groups[group]))
+}
+
+// Replace implements Store.
+func (s *MapStore[V]) Replace(group discord.Snowflake, entities map[discord.Snowflake]V) (old []V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old = slices.Collect(maps.Values(s.groups[group]))
+	if len(entities) == 0 {
+		delete(s.groups, group)
+	} else {
+		s.groups[group] = maps.Clone(entities)
+	}
+	return old
+}
+
+// DeleteGroup implements Store.
+func (s *MapStore[V]) DeleteGroup(group discord.Snowflake) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.groups, group)
+}
+
+// Range implements Store.
+func (s *MapStore[V]) Range(fn func(group, id discord.Snowflake, v V) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for group, entities := range s.groups {
+		for id, v := range entities {
+			if !fn(group, id, v) {
+				return
+			}
+		}
+	}
+}
+
+// Len implements Store.
+func (s *MapStore[V]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, entities := range s.groups {
+		n += len(entities)
+	}
+	return n
+}
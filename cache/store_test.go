@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func() Store[string]{
+		"Map": func() Store[string] { return NewMapStore[string]() },
+		"LRU": func() Store[string] { return NewLRUStore(LRUConfig[string]{MaxEntries: 100}) },
+		"Snapshot": func() Store[string] {
+			return NewSnapshotStore[string](filepath.Join(t.TempDir(), "snapshot.jsonl"), nil)
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore()
+			if _, replaced := s.Set("1", "a", "first"); replaced {
+				t.Error("Set() of a new entity reported replaced")
+			}
+			if old, replaced := s.Set("1", "a", "second"); !replaced || old != "first" {
+				t.Errorf("Set() = %q, %v, want first, true", old, replaced)
+			}
+			s.Set("1", "b", "third")
+			s.Set("2", "a", "fourth")
+
+			if v, ok := s.Get("1", "a"); !ok || v != "second" {
+				t.Errorf("Get() = %q, %v, want second", v, ok)
+			}
+			if got := s.List("1"); len(got) != 2 {
+				t.Errorf("List() = %v, want 2 entities", got)
+			}
+			if s.Len() != 3 {
+				t.Errorf("Len() = %d, want 3", s.Len())
+			}
+
+			old := s.Replace("1", map[discord.Snowflake]string{"c": "fifth"})
+			slices.Sort(old)
+			if !slices.Equal(old, []string{"second", "third"}) || !slices.Equal(s.List("1"), []string{"fifth"}) {
+				t.Errorf("Replace() = %v, List() = %v", old, s.List("1"))
+			}
+
+			if old, deleted := s.Delete("2", "a"); !deleted || old != "fourth" {
+				t.Errorf("Delete() = %q, %v, want fourth, true", old, deleted)
+			}
+			if _, deleted := s.Delete("2", "a"); deleted {
+				t.Error("Delete() of a missing entity reported deleted")
+			}
+
+			s.DeleteGroup("1")
+			if s.Len() != 0 {
+				t.Errorf("Len() after DeleteGroup = %d, want 0", s.Len())
+			}
+		})
+	}
+}
+
+func TestLRUStore_Eviction(t *testing.T) {
+	t.Run("Entries", func(t *testing.T) {
+		s := NewLRUStore(LRUConfig[string]{MaxEntries: 2})
+		s.Set("g", "a", "a")
+		s.Set("g", "b", "b")
+		s.Get("g", "a")
+		s.Set("g", "c", "c")
+
+		if _, ok := s.Get("g", "b"); ok {
+			t.Error("least recently used entity was not evicted")
+		}
+		if _, ok := s.Get("g", "a"); !ok {
+			t.Error("recently used entity was evicted")
+		}
+		if s.Len() != 2 {
+			t.Errorf("Len() = %d, want 2", s.Len())
+		}
+	})
+
+	t.Run("Bytes", func(t *testing.T) {
+		s := NewLRUStore(LRUConfig[string]{MaxBytes: 10, Size: func(v string) int64 { return int64(len(v)) }})
+		s.Set("g", "a", "aaaa")
+		s.Set("g", "b", "bbbb")
+		s.Set("g", "c", "cccc")
+
+		if _, ok := s.Get("g", "a"); ok {
+			t.Error("entity over the byte budget was not evicted")
+		}
+		if s.Bytes() != 8 {
+			t.Errorf("Bytes() = %d, want 8", s.Bytes())
+		}
+	})
+
+	t.Run("Default size", func(t *testing.T) {
+		s := NewLRUStore(LRUConfig[payloads.Role]{MaxBytes: 1 << 20})
+		s.Set("g", "1", payloads.Role{ID: "1", Name: "Moderators"})
+		if s.Bytes() == 0 {
+			t.Error("Bytes() = 0, want the JSON size of the role")
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		s := NewLRUStore(LRUConfig[string]{TTL: time.Hour})
+		now := time.Now()
+		s.now = func() time.Time { return now }
+
+		s.Set("g", "a", "a")
+		now = now.Add(30 * time.Minute)
+		s.Set("g", "b", "b")
+		// Reading does not extend the TTL.
+		s.Get("g", "a")
+		now = now.Add(45 * time.Minute)
+
+		if _, ok := s.Get("g", "a"); ok {
+			t.Error("expired entity returned")
+		}
+		if _, ok := s.Get("g", "b"); !ok {
+			t.Error("entity expired before its TTL")
+		}
+		if s.Len() != 1 {
+			t.Errorf("Len() = %d, want 1", s.Len())
+		}
+	})
+}
+
+func TestSnapshotStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "members.jsonl")
+
+	empty := NewSnapshotStore[payloads.GuildMember](path, nil)
+	if err := empty.Load(); err != nil {
+		t.Fatalf("Load() without a snapshot error = %v", err)
+	}
+
+	saved := NewSnapshotStore[payloads.GuildMember](path, nil)
+	nick := "Nel"
+	saved.Set(guildID, nelly, payloads.GuildMember{User: &payloads.User{ID: nelly, Username: "Nelly"}, Nick: &nick})
+	saved.Set(guildID, mason, payloads.GuildMember{User: &payloads.User{ID: mason, Username: "Mason"}})
+	if err := saved.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("snapshot directory holds %d files, want only the snapshot", len(entries))
+	}
+
+	restored := NewSnapshotStore[payloads.GuildMember](path, NewLRUStore(LRUConfig[payloads.GuildMember]{MaxEntries: 10}))
+	if err := restored.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	member, ok := restored.Get(guildID, nelly)
+	if !ok || member.User.Username != "Nelly" || member.Nick == nil || *member.Nick != "Nel" {
+		t.Errorf("restored member = %+v, %v", member, ok)
+	}
+	if restored.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", restored.Len())
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSnapshotStore[payloads.GuildMember](path, nil).Load(); err == nil {
+		t.Error("Load() of a corrupt snapshot error = nil")
+	}
+}
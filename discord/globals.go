@@ -5,6 +5,8 @@
 package discord
 
 import (
+	"encoding/json"
+	"fmt"
 	"regexp"
 	"strconv"
 	"time"
@@ -46,6 +48,30 @@ func (s Snowflake) Time() (time.Time, error) {
 	return time.Unix(timestamp/1000, (timestamp%1000)*1000000), nil
 }
 
+// UnmarshalJSON implements json.Unmarshaler.
+// It accepts both the string form Discord uses in JSON and bare integers,
+// which is how snowflakes arrive when the gateway is using ETF encoding.
+func (s *Snowflake) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		*s = Snowflake(str)
+		return nil
+	}
+
+	if _, err := strconv.ParseUint(string(data), 10, 64); err != nil {
+		return fmt.Errorf("discord: invalid snowflake %s", data)
+	}
+	*s = Snowflake(data)
+	return nil
+}
+
 // IsValid checks if the Snowflake is a valid Discord ID.
 func (s Snowflake) IsValid() bool {
 	if len(s) < 17 || len(s) > 20 {
@@ -1,6 +1,7 @@
 package discord
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -242,3 +243,56 @@ func TestSnowflake_TimestampExtraction(t *testing.T) {
 		t.Errorf("Extracted time %v is unreasonably far in the future", extractedTime)
 	}
 }
+
+func TestSnowflake_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Snowflake
+		wantErr  bool
+	}{
+		{
+			name:     "String snowflake",
+			input:    `"80351110224678912"`,
+			expected: Snowflake("80351110224678912"),
+		},
+		{
+			name:     "Integer snowflake",
+			input:    `80351110224678912`,
+			expected: Snowflake("80351110224678912"),
+		},
+		{
+			name:     "Max uint64",
+			input:    `18446744073709551615`,
+			expected: Snowflake("18446744073709551615"),
+		},
+		{
+			name:     "Null leaves value untouched",
+			input:    `null`,
+			expected: Snowflake(""),
+		},
+		{
+			name:    "Float",
+			input:   `1.5`,
+			wantErr: true,
+		},
+		{
+			name:    "Negative integer",
+			input:   `-1`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Snowflake
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("UnmarshalJSON() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
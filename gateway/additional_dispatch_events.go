@@ -1,6 +1,11 @@
 package gateway
 
-import "github.com/kolosys/discord-types/discord"
+import (
+	"encoding/json"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+)
 
 // This file contains additional dispatch event types to complete coverage
 // matching the TypeScript discord-api-types library
@@ -19,8 +24,11 @@ type GuildScheduledEventCreateDispatch struct {
 
 func (e GuildScheduledEventCreateDispatch) isReceivePayload() {}
 
+// GuildScheduledEventCreateDispatchData represents the data for a Guild Scheduled Event Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#guild-scheduled-event-create
 type GuildScheduledEventCreateDispatchData struct {
-	// GuildScheduledEvent data - defined in payloads/guild_scheduled_event.go
+	payloads.GuildScheduledEvent
 }
 
 // GuildScheduledEventUpdateDispatch represents a guild scheduled event update dispatch event.
@@ -89,8 +97,11 @@ type GuildSoundboardSoundCreateDispatch struct {
 
 func (e GuildSoundboardSoundCreateDispatch) isReceivePayload() {}
 
+// GuildSoundboardSoundCreateDispatchData represents the data for a Guild Soundboard Sound Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#guild-soundboard-sound-create
 type GuildSoundboardSoundCreateDispatchData struct {
-	// SoundboardSound data - defined in payloads/soundboard.go
+	payloads.SoundboardSound
 }
 
 // GuildSoundboardSoundUpdateDispatch represents a guild soundboard sound update dispatch event.
@@ -131,8 +142,8 @@ type GuildSoundboardSoundsUpdateDispatch struct {
 func (e GuildSoundboardSoundsUpdateDispatch) isReceivePayload() {}
 
 type GuildSoundboardSoundsUpdateDispatchData struct {
-	SoundboardSounds []interface{}     `json:"soundboard_sounds"` // SoundboardSound array
-	GuildID          discord.Snowflake `json:"guild_id"`
+	SoundboardSounds []payloads.SoundboardSound `json:"soundboard_sounds"`
+	GuildID          discord.Snowflake          `json:"guild_id"`
 }
 
 // SoundboardSoundsDispatch represents a soundboard sounds dispatch event.
@@ -146,8 +157,8 @@ type SoundboardSoundsDispatch struct {
 func (e SoundboardSoundsDispatch) isReceivePayload() {}
 
 type SoundboardSoundsDispatchData struct {
-	SoundboardSounds []interface{}     `json:"soundboard_sounds"` // SoundboardSound array
-	GuildID          discord.Snowflake `json:"guild_id"`
+	SoundboardSounds []payloads.SoundboardSound `json:"soundboard_sounds"`
+	GuildID          discord.Snowflake          `json:"guild_id"`
 }
 
 // =============================================================================
@@ -164,8 +175,11 @@ type StageInstanceCreateDispatch struct {
 
 func (e StageInstanceCreateDispatch) isReceivePayload() {}
 
+// StageInstanceCreateDispatchData represents the data for a Stage Instance Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#stage-instance-create
 type StageInstanceCreateDispatchData struct {
-	// StageInstance data - defined in payloads/stage_instance.go
+	payloads.StageInstance
 }
 
 // StageInstanceUpdateDispatch represents a stage instance update dispatch event.
@@ -206,8 +220,11 @@ type SubscriptionCreateDispatch struct {
 
 func (e SubscriptionCreateDispatch) isReceivePayload() {}
 
+// SubscriptionCreateDispatchData represents the data for a Subscription Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#subscription-create
 type SubscriptionCreateDispatchData struct {
-	// Subscription data - defined in payloads/monetization.go
+	payloads.Subscription
 }
 
 // SubscriptionUpdateDispatch represents a subscription update dispatch event.
@@ -248,10 +265,15 @@ type ThreadCreateDispatch struct {
 
 func (e ThreadCreateDispatch) isReceivePayload() {}
 
+// ThreadCreateDispatchData represents the data for a Thread Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#thread-create
 type ThreadCreateDispatchData struct {
-	// ThreadChannel data with additional fields
+	payloads.ThreadChannel
+
+	// NewlyCreated is set when the thread was just created, as opposed to
+	// being sent because the current user was added to an existing thread.
 	NewlyCreated *bool `json:"newly_created,omitempty"`
-	// ... other thread fields
 }
 
 // ThreadUpdateDispatch represents a thread update dispatch event.
@@ -264,8 +286,14 @@ type ThreadUpdateDispatch struct {
 
 func (e ThreadUpdateDispatch) isReceivePayload() {}
 
+// ThreadUpdateDispatchData represents the data for a Thread Update event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#thread-update
 type ThreadUpdateDispatchData struct {
-	// ThreadChannel data
+	payloads.ThreadChannel
+
+	// Fields are the JSON fields present in the update.
+	Fields Fields `json:"-"`
 }
 
 // ThreadDeleteDispatch represents a thread delete dispatch event.
@@ -296,10 +324,10 @@ type ThreadListSyncDispatch struct {
 func (e ThreadListSyncDispatch) isReceivePayload() {}
 
 type ThreadListSyncDispatchData struct {
-	GuildID    discord.Snowflake   `json:"guild_id"`
-	ChannelIDs []discord.Snowflake `json:"channel_ids,omitempty"`
-	Threads    []interface{}       `json:"threads"` // ThreadChannel array
-	Members    []interface{}       `json:"members"` // ThreadMember array
+	GuildID    discord.Snowflake        `json:"guild_id"`
+	ChannelIDs []discord.Snowflake      `json:"channel_ids,omitempty"`
+	Threads    []payloads.ThreadChannel `json:"threads"`
+	Members    []payloads.ThreadMember  `json:"members"`
 }
 
 // ThreadMembersUpdateDispatch represents a thread members update dispatch event.
@@ -313,11 +341,11 @@ type ThreadMembersUpdateDispatch struct {
 func (e ThreadMembersUpdateDispatch) isReceivePayload() {}
 
 type ThreadMembersUpdateDispatchData struct {
-	ID               discord.Snowflake   `json:"id"`
-	GuildID          discord.Snowflake   `json:"guild_id"`
-	MemberCount      int                 `json:"member_count"`
-	AddedMembers     []interface{}       `json:"added_members,omitempty"` // ThreadMember array
-	RemovedMemberIDs []discord.Snowflake `json:"removed_member_ids,omitempty"`
+	ID               discord.Snowflake       `json:"id"`
+	GuildID          discord.Snowflake       `json:"guild_id"`
+	MemberCount      int                     `json:"member_count"`
+	AddedMembers     []payloads.ThreadMember `json:"added_members,omitempty"`
+	RemovedMemberIDs []discord.Snowflake     `json:"removed_member_ids,omitempty"`
 }
 
 // ThreadMemberUpdateDispatch represents a thread member update dispatch event.
@@ -330,9 +358,14 @@ type ThreadMemberUpdateDispatch struct {
 
 func (e ThreadMemberUpdateDispatch) isReceivePayload() {}
 
+// ThreadMemberUpdateDispatchData represents the data for a Thread Member Update event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#thread-member-update
 type ThreadMemberUpdateDispatchData struct {
+	payloads.ThreadMember
+
+	// GuildID is the id of the guild.
 	GuildID discord.Snowflake `json:"guild_id"`
-	// ... other thread member fields
 }
 
 // =============================================================================
@@ -350,11 +383,11 @@ type TypingStartDispatch struct {
 func (e TypingStartDispatch) isReceivePayload() {}
 
 type TypingStartDispatchData struct {
-	ChannelID discord.Snowflake  `json:"channel_id"`
-	GuildID   *discord.Snowflake `json:"guild_id,omitempty"`
-	UserID    discord.Snowflake  `json:"user_id"`
-	Timestamp int64              `json:"timestamp"`
-	Member    *interface{}       `json:"member,omitempty"` // GuildMember
+	ChannelID discord.Snowflake     `json:"channel_id"`
+	GuildID   *discord.Snowflake    `json:"guild_id,omitempty"`
+	UserID    discord.Snowflake     `json:"user_id"`
+	Timestamp int64                 `json:"timestamp"`
+	Member    *payloads.GuildMember `json:"member,omitempty"`
 }
 
 // =============================================================================
@@ -371,8 +404,11 @@ type UserUpdateDispatch struct {
 
 func (e UserUpdateDispatch) isReceivePayload() {}
 
+// UserUpdateDispatchData represents the data for a User Update event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#user-update
 type UserUpdateDispatchData struct {
-	// User data - defined in payloads/user.go
+	payloads.User
 }
 
 // =============================================================================
@@ -389,13 +425,18 @@ type PresenceUpdateDispatch struct {
 
 func (e PresenceUpdateDispatch) isReceivePayload() {}
 
+// PresenceUpdateDispatchData represents the data for a Presence Update event.
+//
+// The user object is partial: only its id is always sent. Fields records
+// which fields were sent; use Merge to apply the update to a previously
+// received presence.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#presence-update
 type PresenceUpdateDispatchData struct {
-	// Presence update data
-	User         interface{}       `json:"user"` // PartialUser
-	GuildID      discord.Snowflake `json:"guild_id"`
-	Status       string            `json:"status"`
-	Activities   []interface{}     `json:"activities"`    // Activity array
-	ClientStatus interface{}       `json:"client_status"` // ClientStatus
+	GatewayPresenceUpdate
+
+	// Fields are the JSON fields present in the update.
+	Fields Fields `json:"-"`
 }
 
 // =============================================================================
@@ -412,9 +453,14 @@ type IntegrationCreateDispatch struct {
 
 func (e IntegrationCreateDispatch) isReceivePayload() {}
 
+// IntegrationCreateDispatchData represents the data for an Integration Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#integration-create
 type IntegrationCreateDispatchData struct {
+	payloads.Integration
+
+	// GuildID is the id of the guild.
 	GuildID discord.Snowflake `json:"guild_id"`
-	// ... other integration fields
 }
 
 // IntegrationUpdateDispatch represents an integration update dispatch event.
@@ -459,8 +505,16 @@ type InteractionCreateDispatch struct {
 
 func (e InteractionCreateDispatch) isReceivePayload() {}
 
+// InteractionCreateDispatchData represents the data for an Interaction Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#interaction-create
 type InteractionCreateDispatchData struct {
-	// Interaction data - defined in payloads/interactions.go
+	payloads.BaseInteraction
+
+	// Data is the interaction data payload. Its shape depends on Type; decode it
+	// into payloads.ApplicationCommandInteractionData, payloads.MessageComponentInteractionData
+	// or payloads.ModalSubmitInteractionData as appropriate.
+	Data json.RawMessage `json:"data,omitempty"`
 }
 
 // =============================================================================
@@ -478,18 +532,18 @@ type InviteCreateDispatch struct {
 func (e InviteCreateDispatch) isReceivePayload() {}
 
 type InviteCreateDispatchData struct {
-	ChannelID         discord.Snowflake  `json:"channel_id"`
-	Code              string             `json:"code"`
-	CreatedAt         int64              `json:"created_at"`
-	GuildID           *discord.Snowflake `json:"guild_id,omitempty"`
-	Inviter           *interface{}       `json:"inviter,omitempty"` // User
-	MaxAge            int                `json:"max_age"`
-	MaxUses           int                `json:"max_uses"`
-	TargetType        *int               `json:"target_type,omitempty"`
-	TargetUser        *interface{}       `json:"target_user,omitempty"`        // User
-	TargetApplication *interface{}       `json:"target_application,omitempty"` // PartialApplication
-	Temporary         bool               `json:"temporary"`
-	Uses              int                `json:"uses"`
+	ChannelID         discord.Snowflake     `json:"channel_id"`
+	Code              string                `json:"code"`
+	CreatedAt         int64                 `json:"created_at"`
+	GuildID           *discord.Snowflake    `json:"guild_id,omitempty"`
+	Inviter           *payloads.User        `json:"inviter,omitempty"`
+	MaxAge            int                   `json:"max_age"`
+	MaxUses           int                   `json:"max_uses"`
+	TargetType        *int                  `json:"target_type,omitempty"`
+	TargetUser        *payloads.User        `json:"target_user,omitempty"`
+	TargetApplication *payloads.Application `json:"target_application,omitempty"`
+	Temporary         bool                  `json:"temporary"`
+	Uses              int                   `json:"uses"`
 }
 
 // InviteDeleteDispatch represents an invite delete dispatch event.
@@ -0,0 +1,672 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// DefaultGatewayURL is the Gateway URL used when ClientConfig.URL is empty.
+//
+// Bots should prefer the URL returned by Get Gateway Bot.
+const DefaultGatewayURL = "wss://gateway.discord.gg"
+
+// maxFrameSize bounds a single WebSocket message. GUILD_CREATE frames for
+// large guilds are far bigger than typical WebSocket defaults.
+const maxFrameSize = 128 << 20
+
+var (
+	// ErrClientRunning is returned by Client.Run when the client is already running.
+	ErrClientRunning = errors.New("gateway: client is already running")
+
+	// ErrNotConnected is returned by Client.Send when there is no open connection.
+	ErrNotConnected = errors.New("gateway: client is not connected")
+
+	// errZombieConnection ends a connection whose last heartbeat was never acknowledged.
+	errZombieConnection = errors.New("gateway: heartbeat not acknowledged")
+
+	// errReconnectRequested ends a connection after an OpcodeReconnect.
+	errReconnectRequested = errors.New("gateway: reconnect requested")
+)
+
+// CloseError is returned when the Gateway closes the connection with a close code.
+type CloseError struct {
+	// Code is the close code sent by the Gateway.
+	Code CloseCodes
+
+	// Reason is the close reason sent by the Gateway.
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("gateway: connection closed with %d (%s): %s", int(e.Code), e.Code, e.Reason)
+	}
+	return fmt.Sprintf("gateway: connection closed with %d (%s)", int(e.Code), e.Code)
+}
+
+// invalidSessionError ends a connection after an OpcodeInvalidSession.
+type invalidSessionError struct {
+	resumable bool
+}
+
+func (e *invalidSessionError) Error() string {
+	return fmt.Sprintf("gateway: invalid session (resumable: %t)", e.resumable)
+}
+
+// ConnectionState represents the state of a Client's connection.
+type ConnectionState int32
+
+// Connection state constants
+const (
+	// StateDisconnected means the client has no connection.
+	StateDisconnected ConnectionState = iota
+
+	// StateConnecting means the client is dialing or waiting for Hello.
+	StateConnecting
+
+	// StateIdentifying means the client has sent Identify and is waiting for READY.
+	StateIdentifying
+
+	// StateResuming means the client has sent Resume and is waiting for RESUMED.
+	StateResuming
+
+	// StateReady means the session is established and events are flowing.
+	StateReady
+)
+
+// String returns the name of the state.
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateIdentifying:
+		return "identifying"
+	case StateResuming:
+		return "resuming"
+	case StateReady:
+		return "ready"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", int32(s))
+	}
+}
+
+// Handler receives every payload read from the Gateway, in order.
+//
+// Handlers run on the connection's read loop and should return quickly:
+// heartbeat acknowledgements are not processed while a handler is running.
+type Handler func(ctx context.Context, payload GatewayReceivePayload)
+
+// ClientConfig configures a Client.
+type ClientConfig struct {
+	// Token is the bot token, without the "Bot " prefix.
+	Token string
+
+	// Intents are the Gateway Intents to identify with.
+	Intents IntentBits
+
+	// URL is the Gateway URL to connect to. Defaults to DefaultGatewayURL.
+	URL string
+
+	// Encoding is the payload encoding. Defaults to GatewayEncodingJSON.
+	Encoding GatewayEncoding
+
+	// Compression is the transport compression, or empty for none.
+	Compression GatewayCompression
+
+	// Shard is the [shard_id, num_shards] pair to identify with.
+	Shard *[2]int
+
+	// Properties are the connection properties. Defaults to the running OS
+	// and "discord-types" as browser and device.
+	Properties *IdentifyProperties
+
+	// Presence is the initial presence.
+	Presence *PresenceUpdateData
+
+	// LargeThreshold is the large guild threshold, between 50 and 250.
+	LargeThreshold *int
+
+	// Handler receives every payload read from the Gateway.
+	Handler Handler
+
+	// IdentifyLimiter, if set, is waited on before every connection that
+	// will identify rather than resume.
+	IdentifyLimiter IdentifyLimiter
+
+	// MaxReconnectDelay caps the backoff between failed connection attempts.
+	// Defaults to two minutes.
+	MaxReconnectDelay time.Duration
+
+	// Recorder, if set, records every frame received from the Gateway.
+	Recorder *Recorder
+}
+
+// Client is a single Gateway connection (one shard).
+//
+// Run connects, identifies and keeps the session alive: it heartbeats on the
+// interval from Hello, answers heartbeat requests, and reconnects after
+// OpcodeReconnect, OpcodeInvalidSession, zombied connections and closes,
+// resuming the session whenever Discord allows it.
+//
+// See: https://discord.com/developers/docs/topics/gateway#connection-lifecycle
+type Client struct {
+	cfg ClientConfig
+
+	running atomic.Bool
+	state   atomic.Int32
+	latency atomic.Int64
+
+	mu        sync.Mutex
+	conn      *connection
+	sessionID string
+	resumeURL string
+	seq       int
+
+	// invalidSessionDelay returns how long to wait before identifying again
+	// after a non-resumable InvalidSession.
+	invalidSessionDelay func() time.Duration
+}
+
+// NewClient returns a Client for the given configuration. Call Run to connect.
+func NewClient(cfg ClientConfig) *Client {
+	if cfg.URL == "" {
+		cfg.URL = DefaultGatewayURL
+	}
+	if cfg.Encoding == "" {
+		cfg.Encoding = GatewayEncodingJSON
+	}
+	if cfg.MaxReconnectDelay <= 0 {
+		cfg.MaxReconnectDelay = 2 * time.Minute
+	}
+	if cfg.Properties == nil {
+		cfg.Properties = &IdentifyProperties{
+			OS:      runtime.GOOS,
+			Browser: "discord-types",
+			Device:  "discord-types",
+		}
+	}
+
+	return &Client{
+		cfg: cfg,
+		invalidSessionDelay: func() time.Duration {
+			// Discord asks clients to wait a random 1-5 seconds.
+			return time.Second + rand.N(4*time.Second)
+		},
+	}
+}
+
+// State returns the current connection state.
+func (c *Client) State() ConnectionState {
+	return ConnectionState(c.state.Load())
+}
+
+// Latency returns the round trip time of the last acknowledged heartbeat.
+func (c *Client) Latency() time.Duration {
+	return time.Duration(c.latency.Load())
+}
+
+// SessionID returns the current session id, or "" before READY.
+func (c *Client) SessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
+}
+
+// Sequence returns the last sequence number received, or 0 if none.
+func (c *Client) Sequence() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seq
+}
+
+// Send validates payload and writes it to the current connection, waiting
+// for the connection's send rate limit if necessary.
+func (c *Client) Send(ctx context.Context, payload GatewaySendPayload) error {
+	if err := validateSendPayload(payload, c.cfg.Intents); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return ErrNotConnected
+	}
+	return conn.write(ctx, payload)
+}
+
+// Run connects to the Gateway and keeps the session alive until ctx is
+// cancelled or the Gateway closes the connection with a fatal close code.
+//
+// Run returns ctx.Err() after closing the connection when ctx is cancelled,
+// a *CloseError whose Code.Action() is CloseActionFatal, or an error
+// wrapping ErrInvalidPayload if the configuration cannot produce a valid
+// Identify payload.
+func (c *Client) Run(ctx context.Context) error {
+	if err := c.identify().D.Validate(); err != nil {
+		return err
+	}
+	if !c.running.CompareAndSwap(false, true) {
+		return ErrClientRunning
+	}
+	defer c.running.Store(false)
+	defer c.setState(StateDisconnected)
+
+	var backoff time.Duration
+	for {
+		established, err := c.connect(ctx)
+		// The connection is gone until the next one is dialed, which may be
+		// after a long backoff.
+		c.setState(StateDisconnected)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var delay time.Duration
+		switch reconnectAction(err) {
+		case CloseActionFatal:
+			return err
+		case CloseActionReidentify:
+			c.resetSession()
+			var invalid *invalidSessionError
+			if errors.As(err, &invalid) {
+				delay = c.invalidSessionDelay()
+			}
+		}
+
+		if established {
+			backoff = 0
+		} else {
+			backoff = min(max(2*backoff, time.Second), c.cfg.MaxReconnectDelay)
+			delay = max(delay, backoff)
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// reconnectAction decides how to continue after a connection ended with err.
+func reconnectAction(err error) CloseAction {
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		return closeErr.Code.Action()
+	}
+
+	var invalid *invalidSessionError
+	if errors.As(err, &invalid) && !invalid.resumable {
+		return CloseActionReidentify
+	}
+	return CloseActionResume
+}
+
+func (c *Client) setState(s ConnectionState) {
+	c.state.Store(int32(s))
+}
+
+func (c *Client) resetSession() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionID = ""
+	c.resumeURL = ""
+	c.seq = 0
+}
+
+// gatewayURL adds the version, encoding and compression parameters to base.
+func (c *Client) gatewayURL(base string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("gateway: invalid gateway URL: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("v", GatewayVersion)
+	q.Set("encoding", string(c.cfg.Encoding))
+	if c.cfg.Compression != "" {
+		q.Set("compress", string(c.cfg.Compression))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// connect runs a single connection until it ends. established reports
+// whether Hello was received, which resets the reconnect backoff.
+func (c *Client) connect(ctx context.Context) (established bool, err error) {
+	c.setState(StateConnecting)
+
+	c.mu.Lock()
+	sessionID, resumeURL, seq := c.sessionID, c.resumeURL, c.seq
+	c.mu.Unlock()
+
+	resume := sessionID != ""
+	base := c.cfg.URL
+	if resume && resumeURL != "" {
+		base = resumeURL
+	}
+	target, err := c.gatewayURL(base)
+	if err != nil {
+		return false, err
+	}
+
+	if !resume && c.cfg.IdentifyLimiter != nil {
+		shardID := 0
+		if c.cfg.Shard != nil {
+			shardID = c.cfg.Shard[0]
+		}
+		if err := c.cfg.IdentifyLimiter.WaitIdentify(ctx, shardID); err != nil {
+			return false, err
+		}
+	}
+
+	ws, _, err := websocket.Dial(ctx, target, nil)
+	if err != nil {
+		return false, fmt.Errorf("gateway: dial: %w", err)
+	}
+	ws.SetReadLimit(maxFrameSize)
+
+	conn := &connection{ws: ws, encoding: c.cfg.Encoding, compression: c.cfg.Compression, recorder: c.cfg.Recorder}
+	if c.cfg.Shard != nil {
+		conn.shard = c.cfg.Shard[0]
+	}
+	if c.cfg.Compression != "" {
+		conn.decompressor, err = NewTransportDecompressor(c.cfg.Compression)
+		if err != nil {
+			ws.CloseNow()
+			return false, err
+		}
+		defer conn.decompressor.Close()
+	}
+
+	// The connection outlives ctx so that cancelling Run can close it
+	// cleanly; connCtx is only cancelled when the connection is abandoned.
+	connCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// A normal closure invalidates the session, which is what an
+			// intentional shutdown wants.
+			ws.Close(websocket.StatusNormalClosure, "")
+		case <-connCtx.Done():
+		}
+	}()
+	defer ws.CloseNow()
+
+	payload, _, err := conn.read(connCtx)
+	if err != nil {
+		return false, c.connectionError(connCtx, err)
+	}
+	hello, ok := payload.(Hello)
+	if !ok {
+		return false, fmt.Errorf("gateway: expected Hello, got %T", payload)
+	}
+	interval := time.Duration(hello.D.HeartbeatInterval) * time.Millisecond
+	conn.queue = NewSendQueue(interval)
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+	}()
+
+	go c.heartbeat(connCtx, cancel, conn, interval)
+
+	if c.cfg.Handler != nil {
+		c.cfg.Handler(ctx, hello)
+	}
+
+	if resume {
+		c.setState(StateResuming)
+		err = conn.write(connCtx, Resume{
+			Op: OpcodeResume,
+			D:  ResumeData{Token: c.cfg.Token, SessionID: sessionID, Seq: seq},
+		})
+	} else {
+		c.setState(StateIdentifying)
+		err = conn.write(connCtx, c.identify())
+	}
+	if err != nil {
+		return true, c.connectionError(connCtx, err)
+	}
+
+	for {
+		payload, frame, err := conn.read(connCtx)
+		if err != nil {
+			return true, c.connectionError(connCtx, err)
+		}
+
+		c.mu.Lock()
+		if frame.S != nil {
+			c.seq = *frame.S
+		}
+		seq := c.seq
+		c.mu.Unlock()
+
+		var next error
+		switch p := payload.(type) {
+		case ReadyDispatch:
+			c.mu.Lock()
+			c.sessionID = p.D.SessionID
+			c.resumeURL = p.D.ResumeGatewayURL
+			c.mu.Unlock()
+			c.setState(StateReady)
+		case ResumedDispatch:
+			c.setState(StateReady)
+		case Heartbeat:
+			if err := conn.heartbeat(connCtx, seq); err != nil {
+				return true, c.connectionError(connCtx, err)
+			}
+		case HeartbeatAck:
+			if rtt, ok := conn.ack(); ok {
+				c.latency.Store(int64(rtt))
+			}
+		case Reconnect:
+			next = errReconnectRequested
+		case InvalidSession:
+			next = &invalidSessionError{resumable: bool(p.D)}
+		}
+
+		if c.cfg.Handler != nil {
+			c.cfg.Handler(ctx, payload)
+		}
+		if next != nil {
+			return true, next
+		}
+	}
+}
+
+// connectionError explains why a read or write on the connection failed.
+func (c *Client) connectionError(connCtx context.Context, err error) error {
+	if cause := context.Cause(connCtx); cause != nil && !errors.Is(cause, context.Canceled) {
+		return cause
+	}
+
+	var closeErr websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return &CloseError{Code: CloseCodes(closeErr.Code), Reason: closeErr.Reason}
+	}
+	return err
+}
+
+// identify builds the Identify payload from the client configuration.
+func (c *Client) identify() Identify {
+	return Identify{
+		Op: OpcodeIdentify,
+		D: IdentifyData{
+			Token:          c.cfg.Token,
+			Properties:     *c.cfg.Properties,
+			LargeThreshold: c.cfg.LargeThreshold,
+			Shard:          c.cfg.Shard,
+			Presence:       c.cfg.Presence,
+			Intents:        int(c.cfg.Intents),
+		},
+	}
+}
+
+// heartbeat sends a heartbeat every interval, starting after a random
+// fraction of the first interval. If the previous heartbeat was not
+// acknowledged by the time the next one is due, the connection is considered
+// zombied and abandoned so it can be resumed.
+func (c *Client) heartbeat(ctx context.Context, cancel context.CancelCauseFunc, conn *connection, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(rand.N(interval))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if conn.awaitingAck() {
+			cancel(errZombieConnection)
+			return
+		}
+		if err := conn.heartbeat(ctx, c.Sequence()); err != nil {
+			cancel(err)
+			return
+		}
+		timer.Reset(interval)
+	}
+}
+
+// connection is the state of a single WebSocket connection.
+type connection struct {
+	ws           *websocket.Conn
+	encoding     GatewayEncoding
+	compression  GatewayCompression
+	decompressor TransportDecompressor
+	queue        *SendQueue
+	recorder     *Recorder
+	recorded     bool
+	shard        int
+
+	mu      sync.Mutex
+	sentAt  time.Time
+	pending bool
+}
+
+// read returns the next complete payload and its envelope.
+func (c *connection) read(ctx context.Context) (GatewayReceivePayload, receiveFrame, error) {
+	for {
+		_, data, err := c.ws.Read(ctx)
+		if err != nil {
+			return nil, receiveFrame{}, err
+		}
+
+		if c.recorder != nil {
+			// A failed recording must not take the connection down; write
+			// errors are kept for Recorder.Err.
+			_ = c.recorder.Record(RecordedFrame{
+				Shard:       c.shard,
+				Encoding:    c.encoding,
+				Compression: c.compression,
+				Connect:     !c.recorded,
+				Data:        data,
+			})
+			c.recorded = true
+		}
+
+		if c.decompressor != nil {
+			data, err = c.decompressor.Decompress(data)
+			if err != nil {
+				return nil, receiveFrame{}, err
+			}
+			if data == nil {
+				continue
+			}
+		}
+
+		if c.encoding == GatewayEncodingETF {
+			data, err = ETFToJSON(data)
+			if err != nil {
+				return nil, receiveFrame{}, err
+			}
+		}
+
+		return decodeReceiveFrame(data)
+	}
+}
+
+// write encodes payload with the connection's encoding and sends it once
+// the send queue allows.
+func (c *connection) write(ctx context.Context, payload GatewaySendPayload) error {
+	_, heartbeat := payload.(Heartbeat)
+	if err := c.queue.Wait(ctx, heartbeat); err != nil {
+		return err
+	}
+
+	if c.encoding == GatewayEncodingETF {
+		data, err := MarshalETF(payload)
+		if err != nil {
+			return err
+		}
+		return c.ws.Write(ctx, websocket.MessageBinary, data)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.ws.Write(ctx, websocket.MessageText, data)
+}
+
+// heartbeat sends a heartbeat carrying seq, or null if no sequence has been received.
+func (c *connection) heartbeat(ctx context.Context, seq int) error {
+	payload := Heartbeat{Op: OpcodeHeartbeat}
+	if seq != 0 {
+		payload.D = &seq
+	}
+
+	c.mu.Lock()
+	c.sentAt = time.Now()
+	c.pending = true
+	c.mu.Unlock()
+
+	return c.write(ctx, payload)
+}
+
+// ack records a HeartbeatAck and returns the round trip time of the heartbeat it acknowledges.
+func (c *connection) ack() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.pending {
+		return 0, false
+	}
+	c.pending = false
+	return time.Since(c.sentAt), true
+}
+
+func (c *connection) awaitingAck() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending
+}
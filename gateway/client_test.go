@@ -0,0 +1,535 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// fakeGateway is an in-process stand-in for the Discord Gateway. Every
+// accepted connection is handed to the test through conns.
+type fakeGateway struct {
+	t      *testing.T
+	server *httptest.Server
+	conns  chan *fakeConn
+	done   chan struct{}
+}
+
+// fakeConn is the server side of one client connection.
+type fakeConn struct {
+	t     *testing.T
+	ws    *websocket.Conn
+	path  string
+	query url.Values
+
+	// frames receives every message the client sends. Reading continuously
+	// lets close handshakes complete without the test's involvement.
+	frames chan []byte
+
+	// zw compresses outgoing frames when the client asked for zlib-stream.
+	zw     *zlib.Writer
+	stream bytes.Buffer
+}
+
+func newFakeGateway(t *testing.T) *fakeGateway {
+	t.Helper()
+
+	g := &fakeGateway{
+		t:     t,
+		conns: make(chan *fakeConn, 8),
+		done:  make(chan struct{}),
+	}
+	g.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("Accept() error = %v", err)
+			return
+		}
+		ws.SetReadLimit(-1)
+		c := &fakeConn{t: t, ws: ws, path: r.URL.Path, query: r.URL.Query(), frames: make(chan []byte, 64)}
+		if c.query.Get("compress") == string(GatewayCompressionZlibStream) {
+			c.zw = zlib.NewWriter(&c.stream)
+		}
+		g.conns <- c
+
+		defer close(c.frames)
+		for {
+			_, data, err := ws.Read(context.Background())
+			if err != nil {
+				return
+			}
+			select {
+			case c.frames <- data:
+			case <-g.done:
+				return
+			}
+		}
+	}))
+	t.Cleanup(func() {
+		close(g.done)
+		g.server.Close()
+	})
+	return g
+}
+
+// url returns the ws:// URL of the server with the given path.
+func (g *fakeGateway) url(path string) string {
+	return "ws" + strings.TrimPrefix(g.server.URL, "http") + path
+}
+
+// accept waits for the next client connection.
+func (g *fakeGateway) accept() *fakeConn {
+	g.t.Helper()
+
+	select {
+	case c := <-g.conns:
+		return c
+	case <-time.After(5 * time.Second):
+		g.t.Fatal("timed out waiting for a connection")
+		return nil
+	}
+}
+
+func (c *fakeConn) send(frame string) {
+	c.t.Helper()
+
+	typ, data := websocket.MessageText, []byte(frame)
+	if c.zw != nil {
+		c.zw.Write(data)
+		c.zw.Flush()
+		typ, data = websocket.MessageBinary, bytes.Clone(c.stream.Bytes())
+		c.stream.Reset()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.ws.Write(ctx, typ, data); err != nil {
+		c.t.Fatalf("Write() error = %v", err)
+	}
+}
+
+// hello sends Hello with the given heartbeat interval in milliseconds.
+func (c *fakeConn) hello(interval int) {
+	c.t.Helper()
+	c.send(`{"op":10,"d":{"heartbeat_interval":` + itoa(interval) + `}}`)
+}
+
+// expect reads frames until one with the given opcode arrives, skipping
+// heartbeats unless op is OpcodeHeartbeat.
+func (c *fakeConn) expect(op GatewayOpcode) json.RawMessage {
+	c.t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		var data []byte
+		select {
+		case frame, ok := <-c.frames:
+			if !ok {
+				c.t.Fatalf("waiting for opcode %d: connection closed", op)
+			}
+			data = frame
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for opcode %d", op)
+		}
+
+		var frame struct {
+			Op GatewayOpcode   `json:"op"`
+			D  json.RawMessage `json:"d"`
+		}
+		if err := json.Unmarshal(data, &frame); err != nil {
+			c.t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if frame.Op == op {
+			return frame.D
+		}
+		if frame.Op != OpcodeHeartbeat {
+			c.t.Fatalf("got opcode %d, want %d", frame.Op, op)
+		}
+	}
+}
+
+func itoa(n int) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
+
+// recorder collects the payloads delivered to a Handler.
+type recorder struct {
+	notify chan GatewayReceivePayload
+}
+
+func newRecorder() *recorder {
+	return &recorder{notify: make(chan GatewayReceivePayload, 64)}
+}
+
+func (r *recorder) handle(_ context.Context, payload GatewayReceivePayload) {
+	r.notify <- payload
+}
+
+// waitFor returns the next delivered payload matching match.
+func (r *recorder) waitFor(t *testing.T, match func(GatewayReceivePayload) bool) GatewayReceivePayload {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case p := <-r.notify:
+			if match(p) {
+				return p
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for payload")
+			return nil
+		}
+	}
+}
+
+func isType[T GatewayReceivePayload](p GatewayReceivePayload) bool {
+	_, ok := p.(T)
+	return ok
+}
+
+// runClient starts c.Run in the background. stop cancels it and returns
+// Run's error; done is closed once Run has returned.
+func runClient(t *testing.T, c *Client) (stop func() error, done <-chan struct{}) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	var err error
+	go func() {
+		err = c.Run(ctx)
+		close(finished)
+	}()
+
+	stop = func() error {
+		cancel()
+		select {
+		case <-finished:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Run() did not return after cancellation")
+		}
+		return err
+	}
+	t.Cleanup(func() { stop() })
+	return stop, finished
+}
+
+const readyFrame = `{"op":0,"s":1,"t":"READY","d":{"v":10,"user":{"id":"1","username":"bot"},"guilds":[],"session_id":"session","resume_gateway_url":"RESUME_URL","application":{"id":"2","flags":0}}}`
+
+// identifyAndReady completes the initial handshake on conn.
+func identifyAndReady(t *testing.T, g *fakeGateway, conn *fakeConn) {
+	t.Helper()
+
+	conn.hello(45000)
+	conn.expect(OpcodeIdentify)
+	conn.send(strings.Replace(readyFrame, "RESUME_URL", g.url("/resume"), 1))
+}
+
+func TestClient_IdentifyAndDispatch(t *testing.T) {
+	g := newFakeGateway(t)
+	rec := newRecorder()
+	shard := [2]int{1, 2}
+	c := NewClient(ClientConfig{
+		Token:       "token",
+		Intents:     IntentGuilds | IntentGuildMessages,
+		URL:         g.url("/"),
+		Compression: GatewayCompressionZlibStream,
+		Shard:       &shard,
+		Handler:     rec.handle,
+	})
+	stop, _ := runClient(t, c)
+
+	conn := g.accept()
+	if got := conn.query.Get("v"); got != GatewayVersion {
+		t.Errorf("query v = %q, want %q", got, GatewayVersion)
+	}
+	if got := conn.query.Get("encoding"); got != "json" {
+		t.Errorf("query encoding = %q, want %q", got, "json")
+	}
+	if got := conn.query.Get("compress"); got != "zlib-stream" {
+		t.Errorf("query compress = %q, want %q", got, "zlib-stream")
+	}
+
+	conn.hello(45000)
+
+	var identify IdentifyData
+	if err := json.Unmarshal(conn.expect(OpcodeIdentify), &identify); err != nil {
+		t.Fatal(err)
+	}
+	if identify.Token != "token" {
+		t.Errorf("Identify token = %q, want %q", identify.Token, "token")
+	}
+	if identify.Intents != int(IntentGuilds|IntentGuildMessages) {
+		t.Errorf("Identify intents = %d, want %d", identify.Intents, IntentGuilds|IntentGuildMessages)
+	}
+	if identify.Shard == nil || *identify.Shard != shard {
+		t.Errorf("Identify shard = %v, want %v", identify.Shard, shard)
+	}
+	if identify.Properties.Browser != "discord-types" {
+		t.Errorf("Identify browser = %q, want %q", identify.Properties.Browser, "discord-types")
+	}
+
+	conn.send(strings.Replace(readyFrame, "RESUME_URL", g.url("/resume"), 1))
+	rec.waitFor(t, isType[ReadyDispatch])
+
+	conn.send(`{"op":0,"s":2,"t":"MESSAGE_CREATE","d":{"id":"10","channel_id":"20","content":"hi","author":{"id":"1","username":"bot"}}}`)
+	msg := rec.waitFor(t, isType[MessageCreateDispatch]).(MessageCreateDispatch)
+	if msg.D.Content != "hi" {
+		t.Errorf("MessageCreate content = %q, want %q", msg.D.Content, "hi")
+	}
+
+	if got := c.State(); got != StateReady {
+		t.Errorf("State() = %v, want %v", got, StateReady)
+	}
+	if got := c.SessionID(); got != "session" {
+		t.Errorf("SessionID() = %q, want %q", got, "session")
+	}
+	if got := c.Sequence(); got != 2 {
+		t.Errorf("Sequence() = %d, want 2", got)
+	}
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if got := c.State(); got != StateDisconnected {
+		t.Errorf("State() after Run = %v, want %v", got, StateDisconnected)
+	}
+}
+
+func TestClient_HeartbeatRequestAndAck(t *testing.T) {
+	g := newFakeGateway(t)
+	c := NewClient(ClientConfig{Token: "token", URL: g.url("/")})
+	runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+
+	// A heartbeat request must be answered immediately with the last sequence.
+	conn.send(`{"op":1,"d":null}`)
+	var seq *int
+	if err := json.Unmarshal(conn.expect(OpcodeHeartbeat), &seq); err != nil {
+		t.Fatal(err)
+	}
+	if seq == nil || *seq != 1 {
+		t.Errorf("Heartbeat d = %v, want 1", seq)
+	}
+
+	conn.send(`{"op":11}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Latency() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Latency() = 0 after HeartbeatAck")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_ReconnectResumes(t *testing.T) {
+	g := newFakeGateway(t)
+	rec := newRecorder()
+	c := NewClient(ClientConfig{Token: "token", URL: g.url("/"), Handler: rec.handle})
+	runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+	conn.send(`{"op":0,"s":5,"t":"TYPING_START","d":{"channel_id":"1","user_id":"2","timestamp":1}}`)
+	rec.waitFor(t, isType[TypingStartDispatch])
+	conn.send(`{"op":7,"d":null}`)
+
+	resumed := g.accept()
+	if resumed.path != "/resume" {
+		t.Errorf("resume path = %q, want %q", resumed.path, "/resume")
+	}
+	resumed.hello(45000)
+
+	var resume ResumeData
+	if err := json.Unmarshal(resumed.expect(OpcodeResume), &resume); err != nil {
+		t.Fatal(err)
+	}
+	want := ResumeData{Token: "token", SessionID: "session", Seq: 5}
+	if resume != want {
+		t.Errorf("Resume = %+v, want %+v", resume, want)
+	}
+
+	resumed.send(`{"op":0,"s":6,"t":"RESUMED","d":{}}`)
+	rec.waitFor(t, isType[ResumedDispatch])
+	if got := c.State(); got != StateReady {
+		t.Errorf("State() = %v, want %v", got, StateReady)
+	}
+}
+
+func TestClient_InvalidSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		d      string
+		wantOp GatewayOpcode
+	}{
+		{"Resumable", "true", OpcodeResume},
+		{"Not resumable", "false", OpcodeIdentify},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newFakeGateway(t)
+			c := NewClient(ClientConfig{Token: "token", URL: g.url("/")})
+			c.invalidSessionDelay = func() time.Duration { return 0 }
+			runClient(t, c)
+
+			conn := g.accept()
+			identifyAndReady(t, g, conn)
+			conn.send(`{"op":9,"d":` + tt.d + `}`)
+
+			next := g.accept()
+			next.hello(45000)
+			next.expect(tt.wantOp)
+		})
+	}
+}
+
+func TestClient_StateBetweenConnections(t *testing.T) {
+	g := newFakeGateway(t)
+	c := NewClient(ClientConfig{Token: "token", URL: g.url("/")})
+	c.invalidSessionDelay = func() time.Duration { return time.Hour }
+	runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+	waitReady(t, c)
+	conn.send(`{"op":9,"d":false}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.State() == StateReady {
+		if time.Now().After(deadline) {
+			t.Fatal("State() still ready after the session was invalidated")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := c.State(); got != StateDisconnected {
+		t.Errorf("State() while waiting to reconnect = %v, want %v", got, StateDisconnected)
+	}
+}
+
+func TestClient_ZombieConnectionResumes(t *testing.T) {
+	g := newFakeGateway(t)
+	c := NewClient(ClientConfig{Token: "token", URL: g.url("/")})
+	runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+	// Switch to a short interval on a fresh connection that never acks.
+	conn.send(`{"op":7,"d":null}`)
+
+	zombie := g.accept()
+	zombie.hello(20)
+	zombie.expect(OpcodeResume)
+
+	next := g.accept()
+	next.hello(45000)
+	next.expect(OpcodeResume)
+}
+
+func TestClient_FatalCloseCode(t *testing.T) {
+	g := newFakeGateway(t)
+	c := NewClient(ClientConfig{Token: "token", URL: g.url("/")})
+	stop, done := runClient(t, c)
+
+	conn := g.accept()
+	conn.hello(45000)
+	conn.expect(OpcodeIdentify)
+	conn.ws.Close(websocket.StatusCode(CloseCodeAuthenticationFailed), "Authentication failed.")
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after a fatal close code")
+	}
+
+	var closeErr *CloseError
+	if err := stop(); !errors.As(err, &closeErr) {
+		t.Fatalf("Run() error = %v, want *CloseError", err)
+	}
+	if closeErr.Code != CloseCodeAuthenticationFailed {
+		t.Errorf("CloseError.Code = %d, want %d", closeErr.Code, CloseCodeAuthenticationFailed)
+	}
+}
+
+func TestClient_ResumableCloseCode(t *testing.T) {
+	g := newFakeGateway(t)
+	c := NewClient(ClientConfig{Token: "token", URL: g.url("/")})
+	runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+	conn.ws.Close(websocket.StatusCode(CloseCodeRateLimited), "")
+
+	next := g.accept()
+	next.hello(45000)
+	next.expect(OpcodeResume)
+}
+
+func TestClient_SendNotConnected(t *testing.T) {
+	c := NewClient(ClientConfig{Token: "token"})
+	err := c.Send(context.Background(), Heartbeat{Op: OpcodeHeartbeat})
+	if !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Send() error = %v, want %v", err, ErrNotConnected)
+	}
+}
+
+func TestCloseCodes_Action(t *testing.T) {
+	tests := []struct {
+		code     CloseCodes
+		expected CloseAction
+	}{
+		{CloseCodeUnknownError, CloseActionResume},
+		{CloseCodeUnknownOpcode, CloseActionResume},
+		{CloseCodeDecodeError, CloseActionResume},
+		{CloseCodeNotAuthenticated, CloseActionReidentify},
+		{CloseCodeAuthenticationFailed, CloseActionFatal},
+		{CloseCodeAlreadyAuthenticated, CloseActionResume},
+		{CloseCodeInvalidSeq, CloseActionReidentify},
+		{CloseCodeRateLimited, CloseActionResume},
+		{CloseCodeSessionTimedOut, CloseActionReidentify},
+		{CloseCodeInvalidShard, CloseActionFatal},
+		{CloseCodeShardingRequired, CloseActionFatal},
+		{CloseCodeInvalidAPIVersion, CloseActionFatal},
+		{CloseCodeInvalidIntents, CloseActionFatal},
+		{CloseCodeDisallowedIntents, CloseActionFatal},
+		{CloseCodes(1001), CloseActionResume},
+		{CloseCodes(1006), CloseActionResume},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := tt.code.Action(); got != tt.expected {
+				t.Errorf("Action() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClient_Validation(t *testing.T) {
+	c := NewClient(ClientConfig{URL: "ws://127.0.0.1:1"})
+	if err := c.Run(context.Background()); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Run() without token error = %v, want %v", err, ErrInvalidPayload)
+	}
+
+	c = NewClient(ClientConfig{Token: "token", Intents: IntentGuilds})
+	err := c.Send(context.Background(), RequestGuildMembers{
+		Op: OpcodeRequestGuildMembers,
+		D:  RequestGuildMembersData{GuildID: "80351110224678912"},
+	})
+	if !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Send() error = %v, want %v", err, ErrInvalidPayload)
+	}
+}
@@ -4,6 +4,8 @@
 // and other WebSocket-related functionality.
 package gateway
 
+import "fmt"
+
 // This package is independent of the main discord package to avoid circular imports
 
 // GatewayVersion represents the Gateway version.
@@ -42,6 +44,9 @@ type GatewayCompression string
 const (
 	// GatewayCompressionZlibStream represents zlib-stream compression.
 	GatewayCompressionZlibStream GatewayCompression = "zlib-stream"
+
+	// GatewayCompressionZstdStream represents zstd-stream compression.
+	GatewayCompressionZstdStream GatewayCompression = "zstd-stream"
 )
 
 // GatewayOpcode represents Gateway opcodes.
@@ -116,6 +121,87 @@ const (
 	CloseCodeDisallowedIntents
 )
 
+// String returns the name Discord documents for the close code.
+func (c CloseCodes) String() string {
+	switch c {
+	case CloseCodeUnknownError:
+		return "unknown error"
+	case CloseCodeUnknownOpcode:
+		return "unknown opcode"
+	case CloseCodeDecodeError:
+		return "decode error"
+	case CloseCodeNotAuthenticated:
+		return "not authenticated"
+	case CloseCodeAuthenticationFailed:
+		return "authentication failed"
+	case CloseCodeAlreadyAuthenticated:
+		return "already authenticated"
+	case CloseCodeInvalidSeq:
+		return "invalid seq"
+	case CloseCodeRateLimited:
+		return "rate limited"
+	case CloseCodeSessionTimedOut:
+		return "session timed out"
+	case CloseCodeInvalidShard:
+		return "invalid shard"
+	case CloseCodeShardingRequired:
+		return "sharding required"
+	case CloseCodeInvalidAPIVersion:
+		return "invalid API version"
+	case CloseCodeInvalidIntents:
+		return "invalid intent(s)"
+	case CloseCodeDisallowedIntents:
+		return "disallowed intent(s)"
+	default:
+		return fmt.Sprintf("close code %d", int(c))
+	}
+}
+
+// CloseAction is what a client should do after the Gateway closed its connection.
+type CloseAction int
+
+// Close action constants
+const (
+	// CloseActionResume means the client should reconnect and resume the session.
+	CloseActionResume CloseAction = iota
+
+	// CloseActionReidentify means the session is gone; the client should
+	// reconnect and identify again.
+	CloseActionReidentify
+
+	// CloseActionFatal means reconnecting cannot succeed without changing the
+	// client's configuration.
+	CloseActionFatal
+)
+
+// String returns the name of the action.
+func (a CloseAction) String() string {
+	switch a {
+	case CloseActionResume:
+		return "resume"
+	case CloseActionReidentify:
+		return "reidentify"
+	case CloseActionFatal:
+		return "fatal"
+	default:
+		return fmt.Sprintf("CloseAction(%d)", int(a))
+	}
+}
+
+// Action classifies the close code. Codes outside the 4000 range, such as
+// WebSocket-level closes and dropped connections, are resumable.
+func (c CloseCodes) Action() CloseAction {
+	switch c {
+	case CloseCodeNotAuthenticated, CloseCodeInvalidSeq, CloseCodeSessionTimedOut:
+		return CloseActionReidentify
+	case CloseCodeAuthenticationFailed, CloseCodeInvalidShard, CloseCodeShardingRequired,
+		CloseCodeInvalidAPIVersion, CloseCodeInvalidIntents, CloseCodeDisallowedIntents:
+		return CloseActionFatal
+	default:
+		return CloseActionResume
+	}
+}
+
 // IntentBits represents Gateway intent bits.
 //
 // See: https://discord.com/developers/docs/topics/gateway#list-of-intents
@@ -313,7 +399,8 @@ type Heartbeat struct {
 	D  *int          `json:"d"` // Sequence number
 }
 
-func (p Heartbeat) isSendPayload() {}
+func (p Heartbeat) isSendPayload()    {}
+func (p Heartbeat) isReceivePayload() {}
 
 // HeartbeatAck represents a Heartbeat ACK payload.
 type HeartbeatAck struct {
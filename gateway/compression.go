@@ -0,0 +1,338 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zlibFlushSuffix terminates every complete message in a zlib-stream
+// connection (the tail of a Z_SYNC_FLUSH).
+var zlibFlushSuffix = []byte{0x00, 0x00, 0xff, 0xff}
+
+// zlibWindowSize is the size of the deflate back-reference window.
+const zlibWindowSize = 32 << 10
+
+// ErrDecompressorClosed is returned when a TransportDecompressor is used after Close.
+var ErrDecompressorClosed = errors.New("gateway: decompressor closed")
+
+// TransportDecompressor turns the binary WebSocket messages of a compressed
+// Gateway connection back into complete JSON (or ETF) frames.
+//
+// A TransportDecompressor holds the shared compression context for exactly
+// one connection; create a new one for every connection, including resumes.
+type TransportDecompressor interface {
+	// Decompress consumes one WebSocket message. It returns the decompressed
+	// frame once a complete frame has been received, or nil if more messages
+	// are needed first.
+	Decompress(msg []byte) ([]byte, error)
+
+	// Stats returns the byte counters for the connection so far. It is safe
+	// to call concurrently with Decompress.
+	Stats() CompressionStats
+
+	// Close releases the resources held by the decompressor. It must be
+	// called when the connection ends.
+	Close() error
+}
+
+// CompressionStats holds transport compression metrics for a connection.
+type CompressionStats struct {
+	// CompressedBytes is the number of bytes received from the WebSocket.
+	CompressedBytes uint64
+
+	// DecompressedBytes is the number of bytes of frames produced.
+	DecompressedBytes uint64
+
+	// Frames is the number of complete frames produced.
+	Frames uint64
+}
+
+// Ratio returns DecompressedBytes / CompressedBytes, or 0 if nothing has
+// been received yet.
+func (s CompressionStats) Ratio() float64 {
+	if s.CompressedBytes == 0 {
+		return 0
+	}
+	return float64(s.DecompressedBytes) / float64(s.CompressedBytes)
+}
+
+// compressionCounters implements the Stats half of TransportDecompressor.
+type compressionCounters struct {
+	compressed   atomic.Uint64
+	decompressed atomic.Uint64
+	frames       atomic.Uint64
+}
+
+func (c *compressionCounters) Stats() CompressionStats {
+	return CompressionStats{
+		CompressedBytes:   c.compressed.Load(),
+		DecompressedBytes: c.decompressed.Load(),
+		Frames:            c.frames.Load(),
+	}
+}
+
+func (c *compressionCounters) frame(n int) {
+	c.decompressed.Add(uint64(n))
+	c.frames.Add(1)
+}
+
+// NewTransportDecompressor returns the decompressor for the given transport
+// compression. Close it when the connection ends.
+func NewTransportDecompressor(compression GatewayCompression) (TransportDecompressor, error) {
+	switch compression {
+	case GatewayCompressionZlibStream:
+		return NewZlibStreamDecompressor(), nil
+	case GatewayCompressionZstdStream:
+		return NewZstdStreamDecompressor()
+	default:
+		return nil, fmt.Errorf("gateway: unsupported transport compression %q", compression)
+	}
+}
+
+// ZlibStreamDecompressor decompresses a zlib-stream Gateway connection.
+//
+// Discord compresses the whole connection as one zlib stream and flushes it
+// after every frame, so a frame may span several WebSocket messages and is
+// only complete once the data ends with the 00 00 ff ff flush suffix.
+//
+// See: https://discord.com/developers/docs/topics/gateway#zlibstream
+type ZlibStreamDecompressor struct {
+	compressionCounters
+
+	mu         sync.Mutex
+	pending    []byte
+	inflater   io.ReadCloser
+	source     bytes.Reader
+	window     []byte
+	headerSeen bool
+	closed     bool
+}
+
+// NewZlibStreamDecompressor returns a decompressor for a new zlib-stream connection.
+func NewZlibStreamDecompressor() *ZlibStreamDecompressor {
+	return &ZlibStreamDecompressor{}
+}
+
+// Decompress implements TransportDecompressor.
+func (z *ZlibStreamDecompressor) Decompress(msg []byte) ([]byte, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.closed {
+		return nil, ErrDecompressorClosed
+	}
+	z.compressed.Add(uint64(len(msg)))
+
+	z.pending = append(z.pending, msg...)
+	if !bytes.HasSuffix(z.pending, zlibFlushSuffix) {
+		return nil, nil
+	}
+
+	data := z.pending
+	z.pending = z.pending[:0]
+
+	if !z.headerSeen {
+		if len(data) < 2 || data[0]&0x0f != 8 || (uint16(data[0])<<8|uint16(data[1]))%31 != 0 {
+			return nil, errors.New("gateway: invalid zlib-stream header")
+		}
+		if data[1]&0x20 != 0 {
+			return nil, errors.New("gateway: zlib-stream preset dictionaries are not supported")
+		}
+		data = data[2:]
+		z.headerSeen = true
+	}
+
+	// The inflater cannot be fed incrementally, so each flushed chunk is
+	// inflated by resetting it onto the chunk with the previous output as its
+	// dictionary. A sync flush always ends on a byte boundary, so the window
+	// is the only state that carries over between chunks.
+	z.source.Reset(data)
+	if z.inflater == nil {
+		z.inflater = flate.NewReaderDict(&z.source, z.window)
+	} else if err := z.inflater.(flate.Resetter).Reset(&z.source, z.window); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(z.inflater); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("gateway: zlib-stream: %w", err)
+	}
+	if z.source.Len() != 0 {
+		return nil, errors.New("gateway: zlib-stream: unexpected end of stream")
+	}
+
+	frame := out.Bytes()
+	z.window = append(z.window, frame...)
+	if len(z.window) > zlibWindowSize {
+		z.window = append(z.window[:0], z.window[len(z.window)-zlibWindowSize:]...)
+	}
+
+	z.frame(len(frame))
+	return frame, nil
+}
+
+// Close implements TransportDecompressor.
+func (z *ZlibStreamDecompressor) Close() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	z.closed = true
+	z.pending = nil
+	z.window = nil
+	if z.inflater != nil {
+		return z.inflater.Close()
+	}
+	return nil
+}
+
+// ZstdStreamDecompressor decompresses a zstd-stream Gateway connection.
+//
+// Discord compresses the whole connection as one zstd frame and flushes it
+// after every Gateway frame, so each WebSocket message decompresses to
+// exactly one complete frame.
+type ZstdStreamDecompressor struct {
+	compressionCounters
+
+	mu      sync.Mutex
+	input   chan []byte
+	results chan zstdResult
+	stopped chan struct{}
+	decoder *zstd.Decoder
+	failed  bool
+	closed  bool
+}
+
+type zstdResult struct {
+	frame []byte
+	err   error
+}
+
+// NewZstdStreamDecompressor returns a decompressor for a new zstd-stream connection.
+//
+// The decompressor decodes on a goroutine of its own, which runs until
+// Close is called. Unlike a ZlibStreamDecompressor, it must be closed when
+// the connection ends, or the goroutine and the decoder leak.
+func NewZstdStreamDecompressor() (*ZstdStreamDecompressor, error) {
+	z := &ZstdStreamDecompressor{
+		input:   make(chan []byte),
+		results: make(chan zstdResult),
+		stopped: make(chan struct{}),
+	}
+
+	// A single-threaded decoder decodes synchronously and never reads ahead,
+	// which zstdMessageReader relies on to find the end of each frame.
+	src := &zstdMessageReader{z: z}
+	decoder, err := zstd.NewReader(src, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	z.decoder = decoder
+
+	go z.run(src)
+	return z, nil
+}
+
+// run drives the streaming decoder. It must run on its own goroutine because
+// the decoder blocks reading its source until the next message arrives.
+func (z *ZstdStreamDecompressor) run(src *zstdMessageReader) {
+	defer close(z.stopped)
+
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := z.decoder.Read(buf)
+		src.out.Write(buf[:n])
+		if err != nil {
+			if !src.done {
+				z.results <- zstdResult{err: fmt.Errorf("gateway: zstd-stream: %w", err)}
+			}
+			return
+		}
+	}
+}
+
+// zstdMessageReader feeds WebSocket messages to the zstd decoder. The
+// decoder reads exactly the bytes it needs, so when it asks for more after a
+// message is exhausted every block of that message has been decoded and the
+// frame in out is complete.
+type zstdMessageReader struct {
+	z    *ZstdStreamDecompressor
+	msg  []byte
+	out  bytes.Buffer
+	busy bool
+	done bool
+}
+
+func (r *zstdMessageReader) Read(p []byte) (int, error) {
+	for len(r.msg) == 0 {
+		if r.busy {
+			var frame []byte
+			if r.out.Len() > 0 {
+				frame = bytes.Clone(r.out.Bytes())
+				r.out.Reset()
+			}
+			r.busy = false
+			r.z.results <- zstdResult{frame: frame}
+		}
+
+		msg, ok := <-r.z.input
+		if !ok {
+			r.done = true
+			return 0, io.EOF
+		}
+		r.msg = msg
+		r.busy = true
+	}
+
+	n := copy(p, r.msg)
+	r.msg = r.msg[n:]
+	return n, nil
+}
+
+// Decompress implements TransportDecompressor.
+func (z *ZstdStreamDecompressor) Decompress(msg []byte) ([]byte, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.closed || z.failed {
+		return nil, ErrDecompressorClosed
+	}
+	if len(msg) == 0 {
+		return nil, nil
+	}
+	z.compressed.Add(uint64(len(msg)))
+
+	z.input <- msg
+	res := <-z.results
+	if res.err != nil {
+		// The decoder has stopped; the connection cannot be recovered.
+		z.failed = true
+		return nil, res.err
+	}
+
+	if res.frame != nil {
+		z.frame(len(res.frame))
+	}
+	return res.frame, nil
+}
+
+// Close implements TransportDecompressor.
+func (z *ZstdStreamDecompressor) Close() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.closed {
+		return nil
+	}
+	z.closed = true
+	close(z.input)
+	<-z.stopped
+	z.decoder.Close()
+	return nil
+}
@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionFrames returns the recorded frames in a stable order, followed
+// by a frame larger than the deflate window and a repeat of the first frame
+// so back-references across frames and window trimming are exercised.
+func compressionFrames(t *testing.T) [][]byte {
+	t.Helper()
+
+	recorded := loadFrames(t)
+	names := make([]string, 0, len(recorded))
+	for name := range recorded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	frames := make([][]byte, 0, len(names)+2)
+	for _, name := range names {
+		frames = append(frames, recorded[name])
+	}
+	large := `{"op":0,"s":99,"t":"MESSAGE_CREATE","d":{"content":"` + strings.Repeat("lorem ipsum ", 5000) + `"}}`
+	frames = append(frames, []byte(large), frames[0])
+	return frames
+}
+
+func TestZlibStreamDecompressor(t *testing.T) {
+	frames := compressionFrames(t)
+
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	z := NewZlibStreamDecompressor()
+	defer z.Close()
+
+	var compressed uint64
+	for i, frame := range frames {
+		zw.Write(frame)
+		zw.Flush()
+		msg := bytes.Clone(stream.Bytes())
+		stream.Reset()
+		compressed += uint64(len(msg))
+
+		// Split every other frame across several WebSocket messages.
+		parts := [][]byte{msg}
+		if i%2 == 1 && len(msg) > 8 {
+			parts = [][]byte{msg[:3], msg[3 : len(msg)-2], msg[len(msg)-2:]}
+		}
+
+		var got []byte
+		for j, part := range parts {
+			out, err := z.Decompress(part)
+			if err != nil {
+				t.Fatalf("frame %d: Decompress() error = %v", i, err)
+			}
+			if j < len(parts)-1 && out != nil {
+				t.Fatalf("frame %d: Decompress() returned a frame before the flush suffix", i)
+			}
+			got = out
+		}
+		if !bytes.Equal(got, frame) {
+			t.Fatalf("frame %d: Decompress() = %.80q, want %.80q", i, got, frame)
+		}
+	}
+
+	stats := z.Stats()
+	if stats.Frames != uint64(len(frames)) || stats.CompressedBytes != compressed {
+		t.Errorf("Stats() = %+v, want %d frames and %d compressed bytes", stats, len(frames), compressed)
+	}
+	if stats.Ratio() <= 1 {
+		t.Errorf("Stats().Ratio() = %v, want > 1", stats.Ratio())
+	}
+}
+
+func TestZlibStreamDecompressor_InvalidHeader(t *testing.T) {
+	z := NewZlibStreamDecompressor()
+	if _, err := z.Decompress([]byte{0x12, 0x34, 0x00, 0x00, 0xff, 0xff}); err == nil {
+		t.Error("Decompress() error = nil, want invalid header error")
+	}
+}
+
+func TestZstdStreamDecompressor(t *testing.T) {
+	frames := compressionFrames(t)
+
+	var stream bytes.Buffer
+	zw, err := zstd.NewWriter(&stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zw.Close()
+
+	z, err := NewZstdStreamDecompressor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer z.Close()
+
+	var compressed uint64
+	for i, frame := range frames {
+		zw.Write(frame)
+		zw.Flush()
+		msg := bytes.Clone(stream.Bytes())
+		stream.Reset()
+		compressed += uint64(len(msg))
+
+		got, err := z.Decompress(msg)
+		if err != nil {
+			t.Fatalf("frame %d: Decompress() error = %v", i, err)
+		}
+		if !bytes.Equal(got, frame) {
+			t.Fatalf("frame %d: Decompress() = %.80q, want %.80q", i, got, frame)
+		}
+	}
+
+	stats := z.Stats()
+	if stats.Frames != uint64(len(frames)) || stats.CompressedBytes != compressed {
+		t.Errorf("Stats() = %+v, want %d frames and %d compressed bytes", stats, len(frames), compressed)
+	}
+}
+
+func TestZstdStreamDecompressor_Corrupt(t *testing.T) {
+	z, err := NewZstdStreamDecompressor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer z.Close()
+
+	if _, err := z.Decompress([]byte("definitely not zstd")); err == nil {
+		t.Fatal("Decompress() error = nil, want error")
+	}
+	if _, err := z.Decompress([]byte{0}); !errors.Is(err, ErrDecompressorClosed) {
+		t.Errorf("Decompress() after failure error = %v, want %v", err, ErrDecompressorClosed)
+	}
+}
+
+func TestNewTransportDecompressor(t *testing.T) {
+	tests := []struct {
+		compression GatewayCompression
+		wantErr     bool
+	}{
+		{GatewayCompressionZlibStream, false},
+		{GatewayCompressionZstdStream, false},
+		{GatewayCompression("gzip"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.compression), func(t *testing.T) {
+			d, err := NewTransportDecompressor(tt.compression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTransportDecompressor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d != nil {
+				d.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownOpcode is returned when a frame carries an opcode the Gateway never sends to clients.
+var ErrUnknownOpcode = errors.New("gateway: unknown receive opcode")
+
+// ErrMissingEventName is returned when a dispatch frame has no event name.
+var ErrMissingEventName = errors.New("gateway: dispatch frame without event name")
+
+// receiveFrame is the envelope shared by every payload received from the Gateway.
+type receiveFrame struct {
+	Op GatewayOpcode   `json:"op"`
+	D  json.RawMessage `json:"d"`
+	S  *int            `json:"s"`
+	T  *string         `json:"t"`
+}
+
+// UnknownDispatch represents a dispatch event this package has no concrete type for.
+//
+// Discord ships new and undocumented events regularly. Instead of failing,
+// DecodeReceivePayload returns them as UnknownDispatch so callers can still
+// track the sequence number and inspect the raw data.
+type UnknownDispatch struct {
+	Op GatewayOpcode   `json:"op"`
+	T  string          `json:"t"`
+	S  int             `json:"s"`
+	D  json.RawMessage `json:"d"`
+}
+
+func (e UnknownDispatch) isReceivePayload() {}
+
+// dispatchDecoder decodes a complete dispatch frame into its concrete payload type.
+type dispatchDecoder func(data []byte) (GatewayReceivePayload, error)
+
+// dispatchDecoders maps every known dispatch event to the decoder for its payload type.
+//
+// Registering a new *Dispatch type here is all that is needed to make it decodable.
+var dispatchDecoders = map[DispatchEvents]dispatchDecoder{
+	EventApplicationCommandPermissionsUpdate: decodeAs[ApplicationCommandPermissionsUpdateDispatch],
+	EventAutoModerationActionExecution:       decodeAs[AutoModerationActionExecutionDispatch],
+	EventAutoModerationRuleCreate:            decodeAs[AutoModerationRuleCreateDispatch],
+	EventAutoModerationRuleDelete:            decodeAs[AutoModerationRuleDeleteDispatch],
+	EventAutoModerationRuleUpdate:            decodeAs[AutoModerationRuleUpdateDispatch],
+	EventChannelCreate:                       decodeAs[ChannelCreateDispatch],
+	EventChannelDelete:                       decodeAs[ChannelDeleteDispatch],
+	EventChannelPinsUpdate:                   decodeAs[ChannelPinsUpdateDispatch],
+	EventChannelUpdate:                       decodeAs[ChannelUpdateDispatch],
+	EventEntitlementCreate:                   decodeAs[EntitlementCreateDispatch],
+	EventEntitlementDelete:                   decodeAs[EntitlementDeleteDispatch],
+	EventEntitlementUpdate:                   decodeAs[EntitlementUpdateDispatch],
+	EventGuildAuditLogEntryCreate:            decodeAs[GuildAuditLogEntryCreateDispatch],
+	EventGuildBanAdd:                         decodeAs[GuildBanAddDispatch],
+	EventGuildBanRemove:                      decodeAs[GuildBanRemoveDispatch],
+	EventGuildCreate:                         decodeAs[GuildCreateDispatch],
+	EventGuildDelete:                         decodeAs[GuildDeleteDispatch],
+	EventGuildEmojisUpdate:                   decodeAs[GuildEmojisUpdateDispatch],
+	EventGuildIntegrationsUpdate:             decodeAs[GuildIntegrationsUpdateDispatch],
+	EventGuildMemberAdd:                      decodeAs[GuildMemberAddDispatch],
+	EventGuildMemberRemove:                   decodeAs[GuildMemberRemoveDispatch],
+	EventGuildMembersChunk:                   decodeAs[GuildMembersChunkDispatch],
+	EventGuildMemberUpdate:                   decodeAs[GuildMemberUpdateDispatch],
+	EventGuildRoleCreate:                     decodeAs[GuildRoleCreateDispatch],
+	EventGuildRoleDelete:                     decodeAs[GuildRoleDeleteDispatch],
+	EventGuildRoleUpdate:                     decodeAs[GuildRoleUpdateDispatch],
+	EventGuildScheduledEventCreate:           decodeAs[GuildScheduledEventCreateDispatch],
+	EventGuildScheduledEventDelete:           decodeAs[GuildScheduledEventDeleteDispatch],
+	EventGuildScheduledEventUpdate:           decodeAs[GuildScheduledEventUpdateDispatch],
+	EventGuildScheduledEventUserAdd:          decodeAs[GuildScheduledEventUserAddDispatch],
+	EventGuildScheduledEventUserRemove:       decodeAs[GuildScheduledEventUserRemoveDispatch],
+	EventGuildSoundboardSoundCreate:          decodeAs[GuildSoundboardSoundCreateDispatch],
+	EventGuildSoundboardSoundDelete:          decodeAs[GuildSoundboardSoundDeleteDispatch],
+	EventGuildSoundboardSoundsUpdate:         decodeAs[GuildSoundboardSoundsUpdateDispatch],
+	EventGuildSoundboardSoundUpdate:          decodeAs[GuildSoundboardSoundUpdateDispatch],
+	EventSoundboardSounds:                    decodeAs[SoundboardSoundsDispatch],
+	EventGuildStickersUpdate:                 decodeAs[GuildStickersUpdateDispatch],
+	EventGuildUpdate:                         decodeAs[GuildUpdateDispatch],
+	EventIntegrationCreate:                   decodeAs[IntegrationCreateDispatch],
+	EventIntegrationDelete:                   decodeAs[IntegrationDeleteDispatch],
+	EventIntegrationUpdate:                   decodeAs[IntegrationUpdateDispatch],
+	EventInteractionCreate:                   decodeAs[InteractionCreateDispatch],
+	EventInviteCreate:                        decodeAs[InviteCreateDispatch],
+	EventInviteDelete:                        decodeAs[InviteDeleteDispatch],
+	EventMessageCreate:                       decodeAs[MessageCreateDispatch],
+	EventMessageDelete:                       decodeAs[MessageDeleteDispatch],
+	EventMessageDeleteBulk:                   decodeAs[MessageDeleteBulkDispatch],
+	EventMessagePollVoteAdd:                  decodeAs[MessagePollVoteAddDispatch],
+	EventMessagePollVoteRemove:               decodeAs[MessagePollVoteRemoveDispatch],
+	EventMessageReactionAdd:                  decodeAs[MessageReactionAddDispatch],
+	EventMessageReactionRemove:               decodeAs[MessageReactionRemoveDispatch],
+	EventMessageReactionRemoveAll:            decodeAs[MessageReactionRemoveAllDispatch],
+	EventMessageReactionRemoveEmoji:          decodeAs[MessageReactionRemoveEmojiDispatch],
+	EventMessageUpdate:                       decodeAs[MessageUpdateDispatch],
+	EventPresenceUpdate:                      decodeAs[PresenceUpdateDispatch],
+	EventReady:                               decodeAs[ReadyDispatch],
+	EventResumed:                             decodeAs[ResumedDispatch],
+	EventStageInstanceCreate:                 decodeAs[StageInstanceCreateDispatch],
+	EventStageInstanceDelete:                 decodeAs[StageInstanceDeleteDispatch],
+	EventStageInstanceUpdate:                 decodeAs[StageInstanceUpdateDispatch],
+	EventSubscriptionCreate:                  decodeAs[SubscriptionCreateDispatch],
+	EventSubscriptionDelete:                  decodeAs[SubscriptionDeleteDispatch],
+	EventSubscriptionUpdate:                  decodeAs[SubscriptionUpdateDispatch],
+	EventThreadCreate:                        decodeAs[ThreadCreateDispatch],
+	EventThreadDelete:                        decodeAs[ThreadDeleteDispatch],
+	EventThreadListSync:                      decodeAs[ThreadListSyncDispatch],
+	EventThreadMembersUpdate:                 decodeAs[ThreadMembersUpdateDispatch],
+	EventThreadMemberUpdate:                  decodeAs[ThreadMemberUpdateDispatch],
+	EventThreadUpdate:                        decodeAs[ThreadUpdateDispatch],
+	EventTypingStart:                         decodeAs[TypingStartDispatch],
+	EventUserUpdate:                          decodeAs[UserUpdateDispatch],
+	EventVoiceChannelEffectSend:              decodeAs[VoiceChannelEffectSendDispatch],
+	EventVoiceServerUpdate:                   decodeAs[VoiceServerUpdateDispatch],
+	EventVoiceStateUpdate:                    decodeAs[VoiceStateUpdateDispatch],
+	EventWebhooksUpdate:                      decodeAs[WebhooksUpdateDispatch],
+}
+
+// decodeAs decodes data into the payload type T.
+func decodeAs[T GatewayReceivePayload](data []byte) (GatewayReceivePayload, error) {
+	var payload T
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeReceivePayload decodes a raw JSON Gateway frame into its concrete payload type.
+//
+// Dispatch frames are decoded into the matching *Dispatch type (for example
+// ReadyDispatch or MessageCreateDispatch) based on their event name. Events
+// without a registered type are returned as UnknownDispatch. Hello,
+// Heartbeat, HeartbeatAck, InvalidSession and Reconnect frames are returned
+// as their respective types.
+func DecodeReceivePayload(data []byte) (GatewayReceivePayload, error) {
+	payload, _, err := decodeReceiveFrame(data)
+	return payload, err
+}
+
+// decodeReceiveFrame is DecodeReceivePayload, additionally returning the
+// frame envelope so callers can read the sequence number without another pass.
+func decodeReceiveFrame(data []byte) (GatewayReceivePayload, receiveFrame, error) {
+	var frame receiveFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return nil, frame, fmt.Errorf("gateway: decode frame: %w", err)
+	}
+
+	var (
+		payload GatewayReceivePayload
+		err     error
+	)
+
+	switch frame.Op {
+	case OpcodeDispatch:
+		if frame.T == nil || *frame.T == "" {
+			return nil, frame, ErrMissingEventName
+		}
+		decode, ok := dispatchDecoders[DispatchEvents(*frame.T)]
+		if !ok {
+			decode = decodeAs[UnknownDispatch]
+		}
+		payload, err = decode(data)
+	case OpcodeHeartbeat:
+		payload, err = decodeAs[Heartbeat](data)
+	case OpcodeReconnect:
+		payload, err = decodeAs[Reconnect](data)
+	case OpcodeInvalidSession:
+		payload, err = decodeAs[InvalidSession](data)
+	case OpcodeHello:
+		payload, err = decodeAs[Hello](data)
+	case OpcodeHeartbeatAck:
+		payload, err = decodeAs[HeartbeatAck](data)
+	default:
+		return nil, frame, fmt.Errorf("%w: %d", ErrUnknownOpcode, frame.Op)
+	}
+
+	if err != nil {
+		if frame.T != nil {
+			return nil, frame, fmt.Errorf("gateway: decode %s: %w", *frame.T, err)
+		}
+		return nil, frame, fmt.Errorf("gateway: decode opcode %d: %w", frame.Op, err)
+	}
+	return payload, frame, nil
+}
+
+// IsKnownDispatchEvent reports whether DecodeReceivePayload has a concrete type for event.
+func IsKnownDispatchEvent(event DispatchEvents) bool {
+	_, ok := dispatchDecoders[event]
+	return ok
+}
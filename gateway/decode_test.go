@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestDecodeReceivePayload(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		check func(t *testing.T, payload GatewayReceivePayload)
+	}{
+		{
+			name:  "Hello",
+			frame: `{"op":10,"d":{"heartbeat_interval":41250},"s":null,"t":null}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				hello, ok := payload.(Hello)
+				if !ok {
+					t.Fatalf("got %T, want Hello", payload)
+				}
+				if hello.D.HeartbeatInterval != 41250 {
+					t.Errorf("HeartbeatInterval = %d, want 41250", hello.D.HeartbeatInterval)
+				}
+			},
+		},
+		{
+			name:  "Heartbeat request",
+			frame: `{"op":1,"d":null}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				if _, ok := payload.(Heartbeat); !ok {
+					t.Fatalf("got %T, want Heartbeat", payload)
+				}
+			},
+		},
+		{
+			name:  "HeartbeatAck",
+			frame: `{"op":11}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				if _, ok := payload.(HeartbeatAck); !ok {
+					t.Fatalf("got %T, want HeartbeatAck", payload)
+				}
+			},
+		},
+		{
+			name:  "InvalidSession resumable",
+			frame: `{"op":9,"d":true}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				invalid, ok := payload.(InvalidSession)
+				if !ok {
+					t.Fatalf("got %T, want InvalidSession", payload)
+				}
+				if !invalid.D {
+					t.Error("InvalidSession.D = false, want true")
+				}
+			},
+		},
+		{
+			name:  "Reconnect",
+			frame: `{"op":7,"d":null}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				if _, ok := payload.(Reconnect); !ok {
+					t.Fatalf("got %T, want Reconnect", payload)
+				}
+			},
+		},
+		{
+			name: "Ready",
+			frame: `{"op":0,"s":1,"t":"READY","d":{"v":10,"user":{"id":"80351110224678912","username":"Nelly","discriminator":"0","global_name":null,"avatar":null},` +
+				`"guilds":[{"id":"41771983423143937","unavailable":true}],"session_id":"abc","resume_gateway_url":"wss://gateway-us-east1-b.discord.gg",` +
+				`"shard":[0,1],"application":{"id":"80351110224678912","flags":0}}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				ready, ok := payload.(ReadyDispatch)
+				if !ok {
+					t.Fatalf("got %T, want ReadyDispatch", payload)
+				}
+				if ready.S != 1 || ready.T != string(EventReady) {
+					t.Errorf("S, T = %d, %q, want 1, %q", ready.S, ready.T, EventReady)
+				}
+				if ready.D.SessionID != "abc" || len(ready.D.Guilds) != 1 || !ready.D.Guilds[0].Unavailable {
+					t.Errorf("unexpected Ready data: %+v", ready.D)
+				}
+			},
+		},
+		{
+			name: "MessageCreate",
+			frame: `{"op":0,"s":42,"t":"MESSAGE_CREATE","d":{"id":"334385199974967042","channel_id":"290926798999357250",` +
+				`"author":{"id":"53908099506183680","username":"Mason","discriminator":"0","global_name":null,"avatar":null},` +
+				`"content":"hello","timestamp":"2017-07-11T17:27:07.299000+00:00","edited_timestamp":null,"tts":false,` +
+				`"mention_everyone":false,"mentions":[],"mention_roles":[],"attachments":[],"embeds":[],"pinned":false,"type":0}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				msg, ok := payload.(MessageCreateDispatch)
+				if !ok {
+					t.Fatalf("got %T, want MessageCreateDispatch", payload)
+				}
+				if msg.D.Content != "hello" || msg.D.Author.Username != "Mason" {
+					t.Errorf("unexpected message data: %+v", msg.D)
+				}
+			},
+		},
+		{
+			name: "GuildMembersChunk",
+			frame: `{"op":0,"s":7,"t":"GUILD_MEMBERS_CHUNK","d":{"guild_id":"41771983423143937","members":[],` +
+				`"chunk_index":0,"chunk_count":2,"nonce":"req-1"}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				chunk, ok := payload.(GuildMembersChunkDispatch)
+				if !ok {
+					t.Fatalf("got %T, want GuildMembersChunkDispatch", payload)
+				}
+				if chunk.D.ChunkCount != 2 || chunk.D.Nonce == nil || *chunk.D.Nonce != "req-1" {
+					t.Errorf("unexpected chunk data: %+v", chunk.D)
+				}
+			},
+		},
+		{
+			name:  "Unknown dispatch",
+			frame: `{"op":0,"s":9,"t":"SOME_FUTURE_EVENT","d":{"foo":"bar"}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				unknown, ok := payload.(UnknownDispatch)
+				if !ok {
+					t.Fatalf("got %T, want UnknownDispatch", payload)
+				}
+				if unknown.T != "SOME_FUTURE_EVENT" || unknown.S != 9 || string(unknown.D) != `{"foo":"bar"}` {
+					t.Errorf("unexpected unknown dispatch: %+v", unknown)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := DecodeReceivePayload([]byte(tt.frame))
+			if err != nil {
+				t.Fatalf("DecodeReceivePayload() error = %v", err)
+			}
+			tt.check(t, payload)
+		})
+	}
+}
+
+func TestDecodeReceivePayload_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   string
+		wantErr error
+	}{
+		{"Malformed JSON", `{"op":`, nil},
+		{"Send-only opcode", `{"op":2,"d":{}}`, ErrUnknownOpcode},
+		{"Dispatch without name", `{"op":0,"s":1,"d":{}}`, ErrMissingEventName},
+		{"Wrong data shape", `{"op":10,"d":{"heartbeat_interval":"soon"}}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeReceivePayload([]byte(tt.frame))
+			if err == nil {
+				t.Fatal("DecodeReceivePayload() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeReceivePayload() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// declaredDispatchEvents parses common.go and returns the names of every
+// DispatchEvents constant, so the tests notice newly added events.
+func declaredDispatchEvents(t *testing.T) map[string]DispatchEvents {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "common.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse common.go: %v", err)
+	}
+
+	events := make(map[string]DispatchEvents)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			ident, ok := value.Type.(*ast.Ident)
+			if !ok || ident.Name != "DispatchEvents" {
+				continue
+			}
+			lit := value.Values[0].(*ast.BasicLit)
+			events[value.Names[0].Name] = DispatchEvents(lit.Value[1 : len(lit.Value)-1])
+		}
+	}
+	if len(events) == 0 {
+		t.Fatal("no DispatchEvents constants found in common.go")
+	}
+	return events
+}
+
+func TestDispatchDecoders_CoverAllEvents(t *testing.T) {
+	for name, event := range declaredDispatchEvents(t) {
+		if !IsKnownDispatchEvent(event) {
+			t.Errorf("%s (%s) has no registered decoder", name, event)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package gateway
 
-import "github.com/kolosys/discord-types/discord"
+import (
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+)
 
 // This file contains comprehensive dispatch event types and data structures
 // matching the TypeScript discord-api-types library
@@ -19,9 +22,11 @@ type AutoModerationRuleCreateDispatch struct {
 
 func (e AutoModerationRuleCreateDispatch) isReceivePayload() {}
 
+// AutoModerationRuleCreateDispatchData represents the data for an Auto Moderation Rule Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#auto-moderation-rule-create
 type AutoModerationRuleCreateDispatchData struct {
-	// This would contain the full auto moderation rule data
-	// Defined in payloads/auto_moderation.go
+	payloads.AutoModerationRule
 }
 
 // AutoModerationRuleUpdateDispatch represents an auto moderation rule update dispatch event.
@@ -34,9 +39,7 @@ type AutoModerationRuleUpdateDispatch struct {
 
 func (e AutoModerationRuleUpdateDispatch) isReceivePayload() {}
 
-type AutoModerationRuleUpdateDispatchData struct {
-	// Auto moderation rule data
-}
+type AutoModerationRuleUpdateDispatchData = AutoModerationRuleCreateDispatchData
 
 // AutoModerationRuleDeleteDispatch represents an auto moderation rule delete dispatch event.
 type AutoModerationRuleDeleteDispatch struct {
@@ -48,9 +51,7 @@ type AutoModerationRuleDeleteDispatch struct {
 
 func (e AutoModerationRuleDeleteDispatch) isReceivePayload() {}
 
-type AutoModerationRuleDeleteDispatchData struct {
-	// Auto moderation rule data
-}
+type AutoModerationRuleDeleteDispatchData = AutoModerationRuleCreateDispatchData
 
 // AutoModerationActionExecutionDispatch represents an auto moderation action execution dispatch event.
 type AutoModerationActionExecutionDispatch struct {
@@ -63,17 +64,17 @@ type AutoModerationActionExecutionDispatch struct {
 func (e AutoModerationActionExecutionDispatch) isReceivePayload() {}
 
 type AutoModerationActionExecutionDispatchData struct {
-	GuildID              discord.Snowflake  `json:"guild_id"`
-	Action               interface{}        `json:"action"` // AutoModerationAction
-	RuleID               discord.Snowflake  `json:"rule_id"`
-	RuleTriggerType      int                `json:"rule_trigger_type"`
-	UserID               discord.Snowflake  `json:"user_id"`
-	ChannelID            *discord.Snowflake `json:"channel_id,omitempty"`
-	MessageID            *discord.Snowflake `json:"message_id,omitempty"`
-	AlertSystemMessageID *discord.Snowflake `json:"alert_system_message_id,omitempty"`
-	Content              string             `json:"content"`
-	MatchedKeyword       *string            `json:"matched_keyword"`
-	MatchedContent       *string            `json:"matched_content"`
+	GuildID              discord.Snowflake                      `json:"guild_id"`
+	Action               payloads.AutoModerationAction          `json:"action"`
+	RuleID               discord.Snowflake                      `json:"rule_id"`
+	RuleTriggerType      payloads.AutoModerationRuleTriggerType `json:"rule_trigger_type"`
+	UserID               discord.Snowflake                      `json:"user_id"`
+	ChannelID            *discord.Snowflake                     `json:"channel_id,omitempty"`
+	MessageID            *discord.Snowflake                     `json:"message_id,omitempty"`
+	AlertSystemMessageID *discord.Snowflake                     `json:"alert_system_message_id,omitempty"`
+	Content              string                                 `json:"content"`
+	MatchedKeyword       *string                                `json:"matched_keyword"`
+	MatchedContent       *string                                `json:"matched_content"`
 }
 
 // =============================================================================
@@ -91,10 +92,10 @@ type ApplicationCommandPermissionsUpdateDispatch struct {
 func (e ApplicationCommandPermissionsUpdateDispatch) isReceivePayload() {}
 
 type ApplicationCommandPermissionsUpdateDispatchData struct {
-	ID            discord.Snowflake `json:"id"`
-	ApplicationID discord.Snowflake `json:"application_id"`
-	GuildID       discord.Snowflake `json:"guild_id"`
-	Permissions   []interface{}     `json:"permissions"` // ApplicationCommandPermission array
+	ID            discord.Snowflake                       `json:"id"`
+	ApplicationID discord.Snowflake                       `json:"application_id"`
+	GuildID       discord.Snowflake                       `json:"guild_id"`
+	Permissions   []payloads.ApplicationCommandPermission `json:"permissions"`
 }
 
 // =============================================================================
@@ -111,11 +112,63 @@ type ChannelCreateDispatch struct {
 
 func (e ChannelCreateDispatch) isReceivePayload() {}
 
+// ChannelCreateDispatchData represents the data for a Channel Create event.
+//
+// Channel events carry any non-thread guild channel, so the fields specific to
+// text, voice and forum channels are all optional here; check Type to know
+// which of them apply.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#channel-create
 type ChannelCreateDispatchData struct {
-	// Channel data with guild_id required
-	// Type would exclude thread channel types
+	payloads.GuildChannel
+
+	// GuildID is the id of the guild; always present for channel events.
 	GuildID discord.Snowflake `json:"guild_id"`
-	// ... other channel fields
+
+	// Position is the sorting position of the channel.
+	Position int `json:"position"`
+
+	// Topic is the channel topic.
+	Topic *string `json:"topic,omitempty"`
+
+	// LastMessageID is the id of the last message sent in this channel.
+	LastMessageID *discord.Snowflake `json:"last_message_id,omitempty"`
+
+	// LastPinTimestamp is when the last pinned message was pinned.
+	LastPinTimestamp *string `json:"last_pin_timestamp,omitempty"`
+
+	// RateLimitPerUser is the amount of seconds a user has to wait before sending another message.
+	RateLimitPerUser *int `json:"rate_limit_per_user,omitempty"`
+
+	// Bitrate is the bitrate (in bits) of the voice channel.
+	Bitrate *int `json:"bitrate,omitempty"`
+
+	// UserLimit is the user limit of the voice channel.
+	UserLimit *int `json:"user_limit,omitempty"`
+
+	// RTCRegion is the voice region id for the voice channel, automatic when null.
+	RTCRegion *string `json:"rtc_region,omitempty"`
+
+	// VideoQualityMode is the camera video quality mode of the voice channel.
+	VideoQualityMode *payloads.VideoQualityMode `json:"video_quality_mode,omitempty"`
+
+	// DefaultAutoArchiveDuration is the default duration for newly created threads.
+	DefaultAutoArchiveDuration *payloads.ThreadAutoArchiveDuration `json:"default_auto_archive_duration,omitempty"`
+
+	// DefaultThreadRateLimitPerUser is the initial rate_limit_per_user set on newly created threads.
+	DefaultThreadRateLimitPerUser *int `json:"default_thread_rate_limit_per_user,omitempty"`
+
+	// AvailableTags are the tags that can be used in a forum or media channel.
+	AvailableTags []payloads.ForumTag `json:"available_tags,omitempty"`
+
+	// DefaultReactionEmoji is the emoji to show in the add reaction button on a forum thread.
+	DefaultReactionEmoji *payloads.DefaultReaction `json:"default_reaction_emoji,omitempty"`
+
+	// DefaultSortOrder is the default sort order type used to order forum posts.
+	DefaultSortOrder *payloads.SortOrderType `json:"default_sort_order,omitempty"`
+
+	// DefaultForumLayout is the default forum layout view used to display posts.
+	DefaultForumLayout *payloads.ForumLayoutType `json:"default_forum_layout,omitempty"`
 }
 
 // ChannelUpdateDispatch represents a channel update dispatch event.
@@ -172,8 +225,11 @@ type EntitlementCreateDispatch struct {
 
 func (e EntitlementCreateDispatch) isReceivePayload() {}
 
+// EntitlementCreateDispatchData represents the data for an Entitlement Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#entitlement-create
 type EntitlementCreateDispatchData struct {
-	// Entitlement data - defined in payloads/monetization.go
+	payloads.Entitlement
 }
 
 // EntitlementUpdateDispatch represents an entitlement update dispatch event.
@@ -214,9 +270,14 @@ type GuildAuditLogEntryCreateDispatch struct {
 
 func (e GuildAuditLogEntryCreateDispatch) isReceivePayload() {}
 
+// GuildAuditLogEntryCreateDispatchData represents the data for a Guild Audit Log Entry Create event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#guild-audit-log-entry-create
 type GuildAuditLogEntryCreateDispatchData struct {
+	payloads.AuditLogEntry
+
+	// GuildID is the id of the guild.
 	GuildID discord.Snowflake `json:"guild_id"`
-	// ... other audit log entry fields
 }
 
 // =============================================================================
@@ -235,7 +296,7 @@ func (e GuildBanAddDispatch) isReceivePayload() {}
 
 type GuildBanAddDispatchData struct {
 	GuildID discord.Snowflake `json:"guild_id"`
-	User    interface{}       `json:"user"` // User
+	User    payloads.User     `json:"user"`
 }
 
 // GuildBanRemoveDispatch represents a guild ban remove dispatch event.
@@ -283,8 +344,11 @@ type GuildUpdateDispatch struct {
 
 func (e GuildUpdateDispatch) isReceivePayload() {}
 
+// GuildUpdateDispatchData represents the data for a Guild Update event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#guild-update
 type GuildUpdateDispatchData struct {
-	// Full guild data
+	payloads.Guild
 }
 
 // =============================================================================
@@ -303,7 +367,7 @@ func (e GuildEmojisUpdateDispatch) isReceivePayload() {}
 
 type GuildEmojisUpdateDispatchData struct {
 	GuildID discord.Snowflake `json:"guild_id"`
-	Emojis  []interface{}     `json:"emojis"` // Emoji array
+	Emojis  []payloads.Emoji  `json:"emojis"`
 }
 
 // =============================================================================
@@ -321,8 +385,8 @@ type GuildStickersUpdateDispatch struct {
 func (e GuildStickersUpdateDispatch) isReceivePayload() {}
 
 type GuildStickersUpdateDispatchData struct {
-	GuildID  discord.Snowflake `json:"guild_id"`
-	Stickers []interface{}     `json:"stickers"` // Sticker array
+	GuildID  discord.Snowflake  `json:"guild_id"`
+	Stickers []payloads.Sticker `json:"stickers"`
 }
 
 // =============================================================================
@@ -357,9 +421,14 @@ type GuildMemberAddDispatch struct {
 
 func (e GuildMemberAddDispatch) isReceivePayload() {}
 
+// GuildMemberAddDispatchData represents the data for a Guild Member Add event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#guild-member-add
 type GuildMemberAddDispatchData struct {
+	payloads.GuildMember
+
+	// GuildID is the id of the guild.
 	GuildID discord.Snowflake `json:"guild_id"`
-	// ... other guild member fields
 }
 
 // GuildMemberRemoveDispatch represents a guild member remove dispatch event.
@@ -374,7 +443,7 @@ func (e GuildMemberRemoveDispatch) isReceivePayload() {}
 
 type GuildMemberRemoveDispatchData struct {
 	GuildID discord.Snowflake `json:"guild_id"`
-	User    interface{}       `json:"user"` // User
+	User    payloads.User     `json:"user"`
 }
 
 // GuildMemberUpdateDispatch represents a guild member update dispatch event.
@@ -387,9 +456,20 @@ type GuildMemberUpdateDispatch struct {
 
 func (e GuildMemberUpdateDispatch) isReceivePayload() {}
 
+// GuildMemberUpdateDispatchData represents the data for a Guild Member Update event.
+//
+// Discord always sends the user and roles; the remaining member fields are
+// sent when they are known.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#guild-member-update
 type GuildMemberUpdateDispatchData struct {
+	payloads.GuildMember
+
+	// GuildID is the id of the guild.
 	GuildID discord.Snowflake `json:"guild_id"`
-	// ... other updated guild member fields
+
+	// Fields are the JSON fields present in the update.
+	Fields Fields `json:"-"`
 }
 
 // GuildMembersChunkDispatch represents a guild members chunk dispatch event.
@@ -403,13 +483,13 @@ type GuildMembersChunkDispatch struct {
 func (e GuildMembersChunkDispatch) isReceivePayload() {}
 
 type GuildMembersChunkDispatchData struct {
-	GuildID    discord.Snowflake `json:"guild_id"`
-	Members    []interface{}     `json:"members"` // GuildMember array
-	ChunkIndex int               `json:"chunk_index"`
-	ChunkCount int               `json:"chunk_count"`
-	NotFound   []interface{}     `json:"not_found,omitempty"`
-	Presences  []interface{}     `json:"presences,omitempty"` // PresenceUpdate array
-	Nonce      *string           `json:"nonce,omitempty"`
+	GuildID    discord.Snowflake       `json:"guild_id"`
+	Members    []payloads.GuildMember  `json:"members"`
+	ChunkIndex int                     `json:"chunk_index"`
+	ChunkCount int                     `json:"chunk_count"`
+	NotFound   []discord.Snowflake     `json:"not_found,omitempty"`
+	Presences  []GatewayPresenceUpdate `json:"presences,omitempty"`
+	Nonce      *string                 `json:"nonce,omitempty"`
 }
 
 // =============================================================================
@@ -428,7 +508,7 @@ func (e GuildRoleCreateDispatch) isReceivePayload() {}
 
 type GuildRoleCreateDispatchData struct {
 	GuildID discord.Snowflake `json:"guild_id"`
-	Role    interface{}       `json:"role"` // Role
+	Role    payloads.Role     `json:"role"`
 }
 
 // GuildRoleUpdateDispatch represents a guild role update dispatch event.
@@ -472,12 +552,18 @@ type MessageUpdateDispatch struct {
 
 func (e MessageUpdateDispatch) isReceivePayload() {}
 
+// MessageUpdateDispatchData represents the data for a Message Update event.
+//
+// Message updates share the message object of Message Create, but may only
+// carry the fields that changed. Fields records which were sent; use Merge
+// to apply the update to a previously received message.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#message-update
 type MessageUpdateDispatchData struct {
-	// Partial message data with gateway extra fields
-	GuildID  *discord.Snowflake `json:"guild_id,omitempty"`
-	Member   *interface{}       `json:"member,omitempty"` // GuildMemberNoUser
-	Mentions []interface{}      `json:"mentions"`         // UserWithMember array
-	// ... other message fields
+	MessageCreateDispatchData
+
+	// Fields are the JSON fields present in the update.
+	Fields Fields `json:"-"`
 }
 
 // MessageDeleteDispatch represents a message delete dispatch event.
@@ -561,16 +647,16 @@ type MessageReactionAddDispatch struct {
 func (e MessageReactionAddDispatch) isReceivePayload() {}
 
 type MessageReactionAddDispatchData struct {
-	UserID          discord.Snowflake  `json:"user_id"`
-	ChannelID       discord.Snowflake  `json:"channel_id"`
-	MessageID       discord.Snowflake  `json:"message_id"`
-	GuildID         *discord.Snowflake `json:"guild_id,omitempty"`
-	Member          *interface{}       `json:"member,omitempty"` // GuildMember
-	Emoji           interface{}        `json:"emoji"`            // PartialEmoji
-	MessageAuthorID *discord.Snowflake `json:"message_author_id,omitempty"`
-	Burst           bool               `json:"burst"`
-	BurstColors     []string           `json:"burst_colors,omitempty"`
-	Type            int                `json:"type"` // ReactionType
+	UserID          discord.Snowflake     `json:"user_id"`
+	ChannelID       discord.Snowflake     `json:"channel_id"`
+	MessageID       discord.Snowflake     `json:"message_id"`
+	GuildID         *discord.Snowflake    `json:"guild_id,omitempty"`
+	Member          *payloads.GuildMember `json:"member,omitempty"`
+	Emoji           payloads.PartialEmoji `json:"emoji"`
+	MessageAuthorID *discord.Snowflake    `json:"message_author_id,omitempty"`
+	Burst           bool                  `json:"burst"`
+	BurstColors     []string              `json:"burst_colors,omitempty"`
+	Type            payloads.ReactionType `json:"type"`
 }
 
 // MessageReactionRemoveDispatch represents a message reaction remove dispatch event.
@@ -584,13 +670,13 @@ type MessageReactionRemoveDispatch struct {
 func (e MessageReactionRemoveDispatch) isReceivePayload() {}
 
 type MessageReactionRemoveDispatchData struct {
-	UserID    discord.Snowflake  `json:"user_id"`
-	ChannelID discord.Snowflake  `json:"channel_id"`
-	MessageID discord.Snowflake  `json:"message_id"`
-	GuildID   *discord.Snowflake `json:"guild_id,omitempty"`
-	Emoji     interface{}        `json:"emoji"` // PartialEmoji
-	Burst     bool               `json:"burst"`
-	Type      int                `json:"type"` // ReactionType
+	UserID    discord.Snowflake     `json:"user_id"`
+	ChannelID discord.Snowflake     `json:"channel_id"`
+	MessageID discord.Snowflake     `json:"message_id"`
+	GuildID   *discord.Snowflake    `json:"guild_id,omitempty"`
+	Emoji     payloads.PartialEmoji `json:"emoji"`
+	Burst     bool                  `json:"burst"`
+	Type      payloads.ReactionType `json:"type"`
 }
 
 // MessageReactionRemoveAllDispatch represents a message reaction remove all dispatch event.
@@ -620,10 +706,10 @@ type MessageReactionRemoveEmojiDispatch struct {
 func (e MessageReactionRemoveEmojiDispatch) isReceivePayload() {}
 
 type MessageReactionRemoveEmojiDispatchData struct {
-	ChannelID discord.Snowflake  `json:"channel_id"`
-	MessageID discord.Snowflake  `json:"message_id"`
-	GuildID   *discord.Snowflake `json:"guild_id,omitempty"`
-	Emoji     interface{}        `json:"emoji"` // PartialEmoji
+	ChannelID discord.Snowflake     `json:"channel_id"`
+	MessageID discord.Snowflake     `json:"message_id"`
+	GuildID   *discord.Snowflake    `json:"guild_id,omitempty"`
+	Emoji     payloads.PartialEmoji `json:"emoji"`
 }
 
 // =============================================================================
@@ -656,10 +742,11 @@ type VoiceStateUpdateDispatch struct {
 
 func (e VoiceStateUpdateDispatch) isReceivePayload() {}
 
+// VoiceStateUpdateDispatchData2 represents the data for a Voice State Update event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#voice-state-update
 type VoiceStateUpdateDispatchData2 struct {
-	// Voice state data with member included
-	Member *interface{} `json:"member,omitempty"` // VoiceStateMember
-	// ... other voice state fields
+	payloads.VoiceState
 }
 
 // VoiceChannelEffectSendDispatch represents a voice channel effect send dispatch event.
@@ -673,14 +760,14 @@ type VoiceChannelEffectSendDispatch struct {
 func (e VoiceChannelEffectSendDispatch) isReceivePayload() {}
 
 type VoiceChannelEffectSendDispatchData struct {
-	ChannelID     discord.Snowflake `json:"channel_id"`
-	GuildID       discord.Snowflake `json:"guild_id"`
-	UserID        discord.Snowflake `json:"user_id"`
-	Emoji         *interface{}      `json:"emoji,omitempty"` // PartialEmoji
-	AnimationType *int              `json:"animation_type,omitempty"`
-	AnimationID   *int              `json:"animation_id,omitempty"`
-	SoundID       interface{}       `json:"sound_id,omitempty"` // Snowflake or number
-	SoundVolume   *float64          `json:"sound_volume,omitempty"`
+	ChannelID     discord.Snowflake      `json:"channel_id"`
+	GuildID       discord.Snowflake      `json:"guild_id"`
+	UserID        discord.Snowflake      `json:"user_id"`
+	Emoji         *payloads.PartialEmoji `json:"emoji,omitempty"`
+	AnimationType *int                   `json:"animation_type,omitempty"`
+	AnimationID   *int                   `json:"animation_id,omitempty"`
+	SoundID       interface{}            `json:"sound_id,omitempty"` // Snowflake or number
+	SoundVolume   *float64               `json:"sound_volume,omitempty"`
 }
 
 // Add more dispatch events as needed...
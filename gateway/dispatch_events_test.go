@@ -0,0 +1,121 @@
+package gateway
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// countFields returns the number of fields in t, looking through embedded
+// structs so that an embedded placeholder with no fields does not count.
+func countFields(t reflect.Type) int {
+	n := 0
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			n += countFields(field.Type)
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func TestDispatchData_HasFields(t *testing.T) {
+	for name, event := range declaredDispatchEvents(t) {
+		frame := fmt.Sprintf(`{"op":0,"s":1,"t":%q,"d":{}}`, event)
+		payload, err := DecodeReceivePayload([]byte(frame))
+		if err != nil {
+			t.Errorf("%s: DecodeReceivePayload() error = %v", name, err)
+			continue
+		}
+
+		data, ok := reflect.TypeOf(payload).FieldByName("D")
+		if !ok {
+			t.Errorf("%s: %T has no D field", name, payload)
+			continue
+		}
+		if data.Type.Kind() == reflect.Struct && countFields(data.Type) == 0 {
+			t.Errorf("%s: %s has no fields", name, data.Type)
+		}
+	}
+}
+
+func TestDispatchData_Decode(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		check func(t *testing.T, payload GatewayReceivePayload)
+	}{
+		{
+			name: "GuildMemberAdd",
+			frame: `{"op":0,"s":3,"t":"GUILD_MEMBER_ADD","d":{"guild_id":"41771983423143937",` +
+				`"user":{"id":"80351110224678912","username":"Nelly","discriminator":"0","global_name":null,"avatar":null},` +
+				`"roles":["41771983423143936"],"joined_at":"2015-04-26T06:26:56.936000+00:00","deaf":false,"mute":false,"flags":0}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				d := payload.(GuildMemberAddDispatch).D
+				if d.GuildID != "41771983423143937" || d.User == nil || d.User.Username != "Nelly" || len(d.Roles) != 1 {
+					t.Errorf("unexpected member data: %+v", d)
+				}
+			},
+		},
+		{
+			name: "ThreadCreate",
+			frame: `{"op":0,"s":4,"t":"THREAD_CREATE","d":{"id":"41771983423143937","guild_id":"41771983423143936",` +
+				`"parent_id":"41771983423143935","name":"help","type":11,"newly_created":true,` +
+				`"thread_metadata":{"archived":false,"auto_archive_duration":1440,"archive_timestamp":"2021-04-12T23:40:39.855793+00:00","locked":false}}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				d := payload.(ThreadCreateDispatch).D
+				if d.Name != "help" || d.NewlyCreated == nil || !*d.NewlyCreated || d.ThreadMetadata.AutoArchiveDuration != 1440 {
+					t.Errorf("unexpected thread data: %+v", d)
+				}
+			},
+		},
+		{
+			name: "ChannelCreate",
+			frame: `{"op":0,"s":5,"t":"CHANNEL_CREATE","d":{"id":"41771983423143937","guild_id":"41771983423143936",` +
+				`"name":"general","type":0,"position":6,"permission_overwrites":[],"rate_limit_per_user":2,"nsfw":true,` +
+				`"topic":"24/7 chat about how to gank Mike #2","last_message_id":"155117677105512449","parent_id":"399942396007890945"}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				d := payload.(ChannelCreateDispatch).D
+				if d.GuildID != "41771983423143936" || d.Name != "general" || d.Position != 6 || d.Topic == nil {
+					t.Errorf("unexpected channel data: %+v", d)
+				}
+			},
+		},
+		{
+			name: "AutoModerationRuleCreate",
+			frame: `{"op":0,"s":6,"t":"AUTO_MODERATION_RULE_CREATE","d":{"id":"969707018069872670","guild_id":"613425648685547541",` +
+				`"name":"Keyword Filter 1","creator_id":"423457898095789043","trigger_type":1,"event_type":1,` +
+				`"actions":[{"type":1,"metadata":{}}],"trigger_metadata":{"keyword_filter":["cat*"]},"enabled":true,"exempt_roles":[],"exempt_channels":[]}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				d := payload.(AutoModerationRuleCreateDispatch).D
+				if d.Name != "Keyword Filter 1" || len(d.Actions) != 1 || !d.Enabled {
+					t.Errorf("unexpected rule data: %+v", d)
+				}
+			},
+		},
+		{
+			name: "InteractionCreate",
+			frame: `{"op":0,"s":8,"t":"INTERACTION_CREATE","d":{"id":"846462639134605312","application_id":"775799577604522054",` +
+				`"type":2,"token":"A_UNIQUE_TOKEN","version":1,"app_permissions":"442368","locale":"en-US","entitlements":[],` +
+				`"authorizing_integration_owners":{},"attachment_size_limit":8388608,"data":{"id":"771825006014889984","name":"blep","type":1}}}`,
+			check: func(t *testing.T, payload GatewayReceivePayload) {
+				d := payload.(InteractionCreateDispatch).D
+				if d.Token != "A_UNIQUE_TOKEN" || string(d.Data) != `{"id":"771825006014889984","name":"blep","type":1}` {
+					t.Errorf("unexpected interaction data: %+v", d)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := DecodeReceivePayload([]byte(tt.frame))
+			if err != nil {
+				t.Fatalf("DecodeReceivePayload() error = %v", err)
+			}
+			tt.check(t, payload)
+		})
+	}
+}
@@ -0,0 +1,645 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ETF (Erlang External Term Format) support.
+//
+// Rather than maintaining a second set of struct tags, ETF payloads are
+// transcoded to and from JSON so the same Go types work for both encodings.
+// Binaries map to strings, the atoms nil, true and false map to null and
+// booleans, and integers of any size (including the bigints Discord uses for
+// snowflakes) map to JSON numbers, which discord.Snowflake accepts.
+//
+// See: https://discord.com/developers/docs/topics/gateway#etfjson
+
+// ETF term tags.
+const (
+	etfVersion       = 131
+	etfCompressed    = 80
+	etfNewFloat      = 70
+	etfSmallInteger  = 97
+	etfInteger       = 98
+	etfFloat         = 99
+	etfAtom          = 100
+	etfSmallTuple    = 104
+	etfLargeTuple    = 105
+	etfNil           = 106
+	etfString        = 107
+	etfList          = 108
+	etfBinary        = 109
+	etfSmallBig      = 110
+	etfLargeBig      = 111
+	etfMap           = 116
+	etfSmallAtom     = 115
+	etfAtomUTF8      = 118
+	etfSmallAtomUTF8 = 119
+)
+
+const (
+	// etfMaxDepth bounds nesting so hostile input cannot exhaust the stack.
+	etfMaxDepth = 512
+
+	// etfMaxInflatedSize bounds the size of a compressed term once inflated.
+	etfMaxInflatedSize = 64 << 20
+)
+
+// ErrInvalidETF is returned when data is not a well-formed ETF term, or holds
+// a term that has no JSON equivalent.
+var ErrInvalidETF = errors.New("gateway: invalid ETF data")
+
+// MarshalETF returns the ETF encoding of v, using the same JSON struct tags
+// that encoding/json uses.
+func MarshalETF(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return JSONToETF(data)
+}
+
+// UnmarshalETF parses ETF-encoded data and stores the result in the value
+// pointed to by v, using the same JSON struct tags that encoding/json uses.
+func UnmarshalETF(data []byte, v any) error {
+	js, err := ETFToJSON(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, v)
+}
+
+// DecodeReceivePayloadETF is the ETF counterpart of DecodeReceivePayload.
+func DecodeReceivePayloadETF(data []byte) (GatewayReceivePayload, error) {
+	js, err := ETFToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeReceivePayload(js)
+}
+
+// ETFToJSON transcodes a single ETF term, including its version byte, to JSON.
+func ETFToJSON(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != etfVersion {
+		return nil, fmt.Errorf("%w: missing version byte", ErrInvalidETF)
+	}
+
+	d := &etfDecoder{data: data[1:]}
+	if len(d.data) > 0 && d.data[0] == etfCompressed {
+		if err := d.inflate(); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := d.term(&buf, 0); err != nil {
+		return nil, err
+	}
+	if len(d.data) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidETF, len(d.data))
+	}
+	return buf.Bytes(), nil
+}
+
+// etfDecoder reads ETF terms from data, consuming it as it goes.
+type etfDecoder struct {
+	data []byte
+}
+
+func (d *etfDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data) < n {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidETF)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *etfDecoder) uint8() (int, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return int(b[0]), nil
+}
+
+func (d *etfDecoder) uint16() (int, error) {
+	b, err := d.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(b)), nil
+}
+
+func (d *etfDecoder) uint32() (int, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint32(b)), nil
+}
+
+// inflate replaces a compressed term with its uncompressed contents.
+func (d *etfDecoder) inflate() error {
+	d.data = d.data[1:]
+	size, err := d.uint32()
+	if err != nil {
+		return err
+	}
+	if size > etfMaxInflatedSize {
+		return fmt.Errorf("%w: compressed term too large", ErrInvalidETF)
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(d.data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidETF, err)
+	}
+	defer zr.Close()
+
+	out := make([]byte, size)
+	if _, err := io.ReadFull(zr, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidETF, err)
+	}
+	d.data = out
+	return nil
+}
+
+// term transcodes the next term to JSON.
+func (d *etfDecoder) term(buf *bytes.Buffer, depth int) error {
+	if depth > etfMaxDepth {
+		return fmt.Errorf("%w: nesting too deep", ErrInvalidETF)
+	}
+
+	tag, err := d.uint8()
+	if err != nil {
+		return err
+	}
+
+	switch tag {
+	case etfSmallInteger:
+		n, err := d.uint8()
+		if err != nil {
+			return err
+		}
+		buf.WriteString(strconv.Itoa(n))
+
+	case etfInteger:
+		b, err := d.next(4)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(strconv.FormatInt(int64(int32(binary.BigEndian.Uint32(b))), 10))
+
+	case etfNewFloat:
+		b, err := d.next(8)
+		if err != nil {
+			return err
+		}
+		return writeJSONFloat(buf, math.Float64frombits(binary.BigEndian.Uint64(b)))
+
+	case etfFloat:
+		b, err := d.next(31)
+		if err != nil {
+			return err
+		}
+		f, err := strconv.ParseFloat(strings.TrimRight(string(b), "\x00"), 64)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidETF, err)
+		}
+		return writeJSONFloat(buf, f)
+
+	case etfSmallBig, etfLargeBig:
+		s, err := d.bigint(tag)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+
+	case etfAtom, etfSmallAtom, etfAtomUTF8, etfSmallAtomUTF8:
+		atom, err := d.atom(tag)
+		if err != nil {
+			return err
+		}
+		switch atom {
+		case "nil":
+			buf.WriteString("null")
+		case "true", "false":
+			buf.WriteString(atom)
+		default:
+			writeJSONString(buf, atom)
+		}
+
+	case etfBinary:
+		n, err := d.uint32()
+		if err != nil {
+			return err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return err
+		}
+		writeJSONString(buf, string(b))
+
+	case etfString:
+		// Erlang sends lists of bytes this way; erlpack and Discord treat
+		// them as strings.
+		n, err := d.uint16()
+		if err != nil {
+			return err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return err
+		}
+		writeJSONString(buf, string(b))
+
+	case etfNil:
+		buf.WriteString("[]")
+
+	case etfList:
+		n, err := d.uint32()
+		if err != nil {
+			return err
+		}
+		if err := d.array(buf, n, depth); err != nil {
+			return err
+		}
+		// Proper lists end with NIL_EXT; anything else is an improper list.
+		tail, err := d.uint8()
+		if err != nil {
+			return err
+		}
+		if tail != etfNil {
+			return fmt.Errorf("%w: improper list", ErrInvalidETF)
+		}
+
+	case etfSmallTuple:
+		n, err := d.uint8()
+		if err != nil {
+			return err
+		}
+		return d.array(buf, n, depth)
+
+	case etfLargeTuple:
+		n, err := d.uint32()
+		if err != nil {
+			return err
+		}
+		return d.array(buf, n, depth)
+
+	case etfMap:
+		n, err := d.uint32()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := d.key()
+			if err != nil {
+				return err
+			}
+			writeJSONString(buf, key)
+			buf.WriteByte(':')
+			if err := d.term(buf, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	default:
+		return fmt.Errorf("%w: unsupported tag %d", ErrInvalidETF, tag)
+	}
+	return nil
+}
+
+func (d *etfDecoder) array(buf *bytes.Buffer, n, depth int) error {
+	buf.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := d.term(buf, depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// key reads a map key. JSON only has string keys, so binaries, strings,
+// atoms and integers are accepted and converted to their string form.
+func (d *etfDecoder) key() (string, error) {
+	if len(d.data) == 0 {
+		return "", fmt.Errorf("%w: unexpected end of data", ErrInvalidETF)
+	}
+
+	switch tag := d.data[0]; tag {
+	case etfBinary, etfString:
+		d.data = d.data[1:]
+		var n int
+		var err error
+		if tag == etfBinary {
+			n, err = d.uint32()
+		} else {
+			n, err = d.uint16()
+		}
+		if err != nil {
+			return "", err
+		}
+		b, err := d.next(n)
+		return string(b), err
+
+	case etfAtom, etfSmallAtom, etfAtomUTF8, etfSmallAtomUTF8:
+		d.data = d.data[1:]
+		return d.atom(int(tag))
+
+	case etfSmallInteger, etfInteger, etfSmallBig, etfLargeBig:
+		var buf bytes.Buffer
+		if err := d.term(&buf, 0); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+
+	default:
+		return "", fmt.Errorf("%w: unsupported map key tag %d", ErrInvalidETF, tag)
+	}
+}
+
+func (d *etfDecoder) atom(tag int) (string, error) {
+	var n int
+	var err error
+	if tag == etfSmallAtom || tag == etfSmallAtomUTF8 {
+		n, err = d.uint8()
+	} else {
+		n, err = d.uint16()
+	}
+	if err != nil {
+		return "", err
+	}
+	b, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+	if tag == etfAtom || tag == etfSmallAtom {
+		// Latin-1 atoms.
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		return string(r), nil
+	}
+	return string(b), nil
+}
+
+// bigint reads a SMALL_BIG_EXT or LARGE_BIG_EXT and returns its decimal form.
+func (d *etfDecoder) bigint(tag int) (string, error) {
+	var n int
+	var err error
+	if tag == etfSmallBig {
+		n, err = d.uint8()
+	} else {
+		n, err = d.uint32()
+	}
+	if err != nil {
+		return "", err
+	}
+	sign, err := d.uint8()
+	if err != nil {
+		return "", err
+	}
+	digits, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+
+	// Digits are little-endian; fast path for anything that fits in a uint64,
+	// which covers every snowflake.
+	if n <= 8 {
+		var v uint64
+		for i := n - 1; i >= 0; i-- {
+			v = v<<8 | uint64(digits[i])
+		}
+		s := strconv.FormatUint(v, 10)
+		if sign != 0 && v != 0 {
+			s = "-" + s
+		}
+		return s, nil
+	}
+
+	be := make([]byte, n)
+	for i, b := range digits {
+		be[n-1-i] = b
+	}
+	v := new(big.Int).SetBytes(be)
+	if sign != 0 {
+		v.Neg(v)
+	}
+	return v.String(), nil
+}
+
+func writeJSONFloat(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("%w: non-finite float", ErrInvalidETF)
+	}
+	buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	return nil
+}
+
+// writeJSONString writes s as a JSON string, taking a fast path for plain
+// ASCII which is what the vast majority of gateway strings are.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' {
+			b, _ := json.Marshal(s)
+			buf.Write(b)
+			return
+		}
+	}
+	buf.WriteByte('"')
+	buf.WriteString(s)
+	buf.WriteByte('"')
+}
+
+// JSONToETF transcodes a JSON document to a single ETF term, including the
+// version byte. Objects become maps with binary keys, strings become
+// binaries, null becomes the nil atom, and integers too large for
+// INTEGER_EXT are written as bigints.
+func JSONToETF(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	e := &etfEncoder{buf: []byte{etfVersion}}
+	if err := e.value(dec); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("gateway: trailing data after JSON value")
+	}
+	return e.buf, nil
+}
+
+// etfEncoder writes ETF terms for values read from a json.Decoder token
+// stream, which preserves object key order and avoids building a map.
+type etfEncoder struct {
+	buf []byte
+}
+
+func (e *etfEncoder) value(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return e.token(dec, tok)
+}
+
+func (e *etfEncoder) token(dec *json.Decoder, tok json.Token) error {
+	switch v := tok.(type) {
+	case nil:
+		e.atom("nil")
+	case bool:
+		if v {
+			e.atom("true")
+		} else {
+			e.atom("false")
+		}
+	case string:
+		e.binary(v)
+	case json.Number:
+		return e.number(v)
+	case json.Delim:
+		switch v {
+		case '[':
+			return e.list(dec)
+		case '{':
+			return e.object(dec)
+		}
+		return fmt.Errorf("gateway: unexpected JSON delimiter %q", v)
+	default:
+		return fmt.Errorf("gateway: unexpected JSON token %T", tok)
+	}
+	return nil
+}
+
+func (e *etfEncoder) list(dec *json.Decoder) error {
+	start := len(e.buf)
+	e.buf = append(e.buf, etfList, 0, 0, 0, 0)
+
+	n := 0
+	for dec.More() {
+		if err := e.value(dec); err != nil {
+			return err
+		}
+		n++
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	if n == 0 {
+		e.buf = append(e.buf[:start], etfNil)
+		return nil
+	}
+	binary.BigEndian.PutUint32(e.buf[start+1:], uint32(n))
+	e.buf = append(e.buf, etfNil)
+	return nil
+}
+
+func (e *etfEncoder) object(dec *json.Decoder) error {
+	start := len(e.buf)
+	e.buf = append(e.buf, etfMap, 0, 0, 0, 0)
+
+	n := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("gateway: unexpected JSON object key %v", tok)
+		}
+		e.binary(key)
+		if err := e.value(dec); err != nil {
+			return err
+		}
+		n++
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint32(e.buf[start+1:], uint32(n))
+	return nil
+}
+
+func (e *etfEncoder) atom(name string) {
+	e.buf = append(e.buf, etfSmallAtomUTF8, byte(len(name)))
+	e.buf = append(e.buf, name...)
+}
+
+func (e *etfEncoder) binary(s string) {
+	e.buf = append(e.buf, etfBinary)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *etfEncoder) number(n json.Number) error {
+	s := n.String()
+	if strings.ContainsAny(s, ".eE") {
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		e.buf = append(e.buf, etfNewFloat)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(f))
+		return nil
+	}
+
+	if i, err := n.Int64(); err == nil {
+		switch {
+		case i >= 0 && i <= math.MaxUint8:
+			e.buf = append(e.buf, etfSmallInteger, byte(i))
+			return nil
+		case i >= math.MinInt32 && i <= math.MaxInt32:
+			e.buf = append(e.buf, etfInteger)
+			e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(int32(i)))
+			return nil
+		}
+	}
+
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return fmt.Errorf("gateway: invalid JSON number %q", s)
+	}
+	sign := byte(0)
+	if v.Sign() < 0 {
+		sign = 1
+		v.Abs(v)
+	}
+	be := v.Bytes()
+	if len(be) <= math.MaxUint8 {
+		e.buf = append(e.buf, etfSmallBig, byte(len(be)), sign)
+	} else {
+		e.buf = append(e.buf, etfLargeBig)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(len(be)))
+		e.buf = append(e.buf, sign)
+	}
+	for i := len(be) - 1; i >= 0; i-- {
+		e.buf = append(e.buf, be[i])
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// loadFrames returns the recorded gateway frames in testdata/frames keyed by
+// file name.
+func loadFrames(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "frames", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no frames found in testdata/frames")
+	}
+
+	frames := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames[filepath.Base(path)] = bytes.TrimSpace(data)
+	}
+	return frames
+}
+
+var snowflakePattern = regexp.MustCompile(`^[0-9]{17,20}$`)
+
+// discordETF encodes a JSON frame the way Discord does when using ETF: map
+// keys are atoms, and snowflakes are sent as integers rather than strings,
+// whatever the type of the field that holds them.
+func discordETF(t *testing.T, frame []byte) []byte {
+	t.Helper()
+
+	dec := json.NewDecoder(bytes.NewReader(frame))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &etfEncoder{buf: []byte{etfVersion}}
+	var encode func(v any)
+	encode = func(v any) {
+		switch v := v.(type) {
+		case nil:
+			e.atom("nil")
+		case bool:
+			e.atom(strconv.FormatBool(v))
+		case string:
+			if snowflakePattern.MatchString(v) {
+				encode(json.Number(v))
+				return
+			}
+			e.binary(v)
+		case json.Number:
+			if err := e.number(v); err != nil {
+				t.Fatal(err)
+			}
+		case []any:
+			if len(v) == 0 {
+				e.buf = append(e.buf, etfNil)
+				return
+			}
+			e.buf = append(e.buf, etfList)
+			e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(len(v)))
+			for _, item := range v {
+				encode(item)
+			}
+			e.buf = append(e.buf, etfNil)
+		case map[string]any:
+			e.buf = append(e.buf, etfMap)
+			e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(len(v)))
+			for _, key := range slices.Sorted(maps.Keys(v)) {
+				e.atom(key)
+				encode(v[key])
+			}
+		default:
+			t.Fatalf("unexpected JSON value %T", v)
+		}
+	}
+	encode(v)
+	return e.buf
+}
+
+func TestETF_DecodeAgreesWithJSON(t *testing.T) {
+	for name, frame := range loadFrames(t) {
+		t.Run(name, func(t *testing.T) {
+			fromJSON, err := DecodeReceivePayload(frame)
+			if err != nil {
+				t.Fatalf("DecodeReceivePayload() error = %v", err)
+			}
+
+			fromETF, err := DecodeReceivePayloadETF(discordETF(t, frame))
+			if err != nil {
+				t.Fatalf("DecodeReceivePayloadETF() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(fromJSON, fromETF) {
+				t.Errorf("ETF decoding differs from JSON\njson: %+v\netf:  %+v", fromJSON, fromETF)
+			}
+		})
+	}
+}
+
+func TestETFToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		term     []byte
+		expected string
+	}{
+		{"Nil atom", []byte{131, 119, 3, 'n', 'i', 'l'}, `null`},
+		{"True atom", []byte{131, 115, 4, 't', 'r', 'u', 'e'}, `true`},
+		{"False atom", []byte{131, 100, 0, 5, 'f', 'a', 'l', 's', 'e'}, `false`},
+		{"Other atom", []byte{131, 119, 6, 'o', 'n', 'l', 'i', 'n', 'e'}, `"online"`},
+		{"Small integer", []byte{131, 97, 200}, `200`},
+		{"Negative integer", []byte{131, 98, 0xff, 0xff, 0xff, 0xfe}, `-2`},
+		{
+			// 80351110224678912 as a little-endian SMALL_BIG_EXT.
+			"Snowflake bigint",
+			[]byte{131, 110, 8, 0, 0x00, 0x10, 0x40, 0xb6, 0xe8, 0x76, 0x1d, 0x01},
+			`80351110224678912`,
+		},
+		{"Negative bigint", []byte{131, 110, 1, 1, 5}, `-5`},
+		{
+			"Large bigint",
+			append([]byte{131, 111, 0, 0, 0, 9, 0}, 0, 0, 0, 0, 0, 0, 0, 0, 1),
+			`18446744073709551616`,
+		},
+		{"Float", []byte{131, 70, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, `1.5`},
+		{"Binary", []byte{131, 109, 0, 0, 0, 2, 'h', 'i'}, `"hi"`},
+		{"Binary with quote", []byte{131, 109, 0, 0, 0, 3, 'a', '"', 'b'}, `"a\"b"`},
+		{"String", []byte{131, 107, 0, 3, 'a', 'b', 'c'}, `"abc"`},
+		{"Empty list", []byte{131, 106}, `[]`},
+		{"List", []byte{131, 108, 0, 0, 0, 2, 97, 1, 97, 2, 106}, `[1,2]`},
+		{"Tuple", []byte{131, 104, 2, 97, 0, 97, 1}, `[0,1]`},
+		{
+			"Map with binary and atom keys",
+			[]byte{131, 116, 0, 0, 0, 2,
+				109, 0, 0, 0, 2, 'o', 'p', 97, 11,
+				119, 1, 'd', 119, 3, 'n', 'i', 'l'},
+			`{"op":11,"d":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ETFToJSON(tt.term)
+			if err != nil {
+				t.Fatalf("ETFToJSON() error = %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("ETFToJSON() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestETFToJSON_Compressed(t *testing.T) {
+	term := []byte{116, 0, 0, 0, 1, 109, 0, 0, 0, 2, 'o', 'p', 97, 11}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(term)
+	zw.Close()
+
+	data := []byte{131, 80}
+	data = binary.BigEndian.AppendUint32(data, uint32(len(term)))
+	data = append(data, compressed.Bytes()...)
+
+	got, err := ETFToJSON(data)
+	if err != nil {
+		t.Fatalf("ETFToJSON() error = %v", err)
+	}
+	if string(got) != `{"op":11}` {
+		t.Errorf("ETFToJSON() = %s, want %s", got, `{"op":11}`)
+	}
+}
+
+func TestETFToJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		term []byte
+	}{
+		{"Empty", nil},
+		{"Missing version", []byte{97, 1}},
+		{"Truncated binary", []byte{131, 109, 0, 0, 0, 5, 'a'}},
+		{"Unsupported tag", []byte{131, 88}},
+		{"Improper list", []byte{131, 108, 0, 0, 0, 1, 97, 1, 97, 2}},
+		{"Trailing bytes", []byte{131, 97, 1, 97}},
+		{"Non-string map key", []byte{131, 116, 0, 0, 0, 1, 106, 97, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ETFToJSON(tt.term); !errors.Is(err, ErrInvalidETF) {
+				t.Errorf("ETFToJSON() error = %v, want %v", err, ErrInvalidETF)
+			}
+		})
+	}
+}
+
+func TestETF_RoundTrip(t *testing.T) {
+	shard := [2]int{1, 4}
+	threshold := 250
+	identify := Identify{
+		Op: OpcodeIdentify,
+		D: IdentifyData{
+			Token: "token",
+			Properties: IdentifyProperties{
+				OS:      "linux",
+				Browser: "discord-types",
+				Device:  "discord-types",
+			},
+			LargeThreshold: &threshold,
+			Shard:          &shard,
+			Intents:        int(IntentGuilds | IntentGuildMessages),
+		},
+	}
+
+	data, err := MarshalETF(identify)
+	if err != nil {
+		t.Fatalf("MarshalETF() error = %v", err)
+	}
+
+	var got Identify
+	if err := UnmarshalETF(data, &got); err != nil {
+		t.Fatalf("UnmarshalETF() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, identify) {
+		t.Errorf("round trip = %+v, want %+v", got, identify)
+	}
+}
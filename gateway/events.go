@@ -47,6 +47,24 @@ type ReadyDispatch struct {
 
 func (e ReadyDispatch) isReceivePayload() {}
 
+// ResumedDispatchData represents the data for a Resumed event.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#resumed
+type ResumedDispatchData struct {
+	// Trace is debugging information about the servers involved in the session.
+	Trace []string `json:"_trace,omitempty"`
+}
+
+// ResumedDispatch represents a Resumed dispatch event.
+type ResumedDispatch struct {
+	Op GatewayOpcode       `json:"op"`
+	T  string              `json:"t"`
+	S  int                 `json:"s"`
+	D  ResumedDispatchData `json:"d"`
+}
+
+func (e ResumedDispatch) isReceivePayload() {}
+
 // MessageCreateDispatchData represents the data for a Message Create event.
 //
 // See: https://discord.com/developers/docs/topics/gateway-events#message-create
@@ -181,19 +199,19 @@ type GuildCreateDispatchData struct {
 
 	// VoiceStates are states of members currently in voice channels; lacks the guild_id key.
 	// This field is only sent within the GUILD_CREATE event.
-	VoiceStates []VoiceStateUpdateDispatchData `json:"voice_states"`
+	VoiceStates []payloads.VoiceState `json:"voice_states"`
 
 	// Members are users in the guild.
 	// This field is only sent within the GUILD_CREATE event.
 	Members []payloads.GuildMember `json:"members"`
 
-	// Channels are channels in the guild.
+	// Channels are channels in the guild; their guild_id is not set.
 	// This field is only sent within the GUILD_CREATE event.
-	Channels []payloads.BasePartialChannel `json:"channels"`
+	Channels []ChannelCreateDispatchData `json:"channels"`
 
-	// Threads are threads in the guild.
+	// Threads are all active threads in the guild that the current user has permission to view.
 	// This field is only sent within the GUILD_CREATE event.
-	Threads []payloads.BasePartialChannel `json:"threads"`
+	Threads []payloads.ThreadChannel `json:"threads"`
 
 	// Presences are presences of the members in the guild, will only include non-offline members if the size is greater than large_threshold.
 	// This field is only sent within the GUILD_CREATE event.
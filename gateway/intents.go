@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"slices"
+	"strings"
+)
+
+// PrivilegedIntents are the intents that must be enabled for the application
+// in the Developer Portal (and, for verified bots, approved by Discord).
+// Identifying with one that is not enabled closes the connection with
+// CloseCodeDisallowedIntents.
+//
+// See: https://discord.com/developers/docs/topics/gateway#privileged-intents
+const PrivilegedIntents = IntentGuildMembers | IntentGuildPresences | IntentMessageContent
+
+// intentNames are the names Discord documents for each intent bit, in bit order.
+var intentNames = []struct {
+	intent IntentBits
+	name   string
+}{
+	{IntentGuilds, "GUILDS"},
+	{IntentGuildMembers, "GUILD_MEMBERS"},
+	{IntentGuildModeration, "GUILD_MODERATION"},
+	{IntentGuildExpressions, "GUILD_EXPRESSIONS"},
+	{IntentGuildIntegrations, "GUILD_INTEGRATIONS"},
+	{IntentGuildWebhooks, "GUILD_WEBHOOKS"},
+	{IntentGuildInvites, "GUILD_INVITES"},
+	{IntentGuildVoiceStates, "GUILD_VOICE_STATES"},
+	{IntentGuildPresences, "GUILD_PRESENCES"},
+	{IntentGuildMessages, "GUILD_MESSAGES"},
+	{IntentGuildMessageReactions, "GUILD_MESSAGE_REACTIONS"},
+	{IntentGuildMessageTyping, "GUILD_MESSAGE_TYPING"},
+	{IntentDirectMessages, "DIRECT_MESSAGES"},
+	{IntentDirectMessageReactions, "DIRECT_MESSAGE_REACTIONS"},
+	{IntentDirectMessageTyping, "DIRECT_MESSAGE_TYPING"},
+	{IntentMessageContent, "MESSAGE_CONTENT"},
+	{IntentGuildScheduledEvents, "GUILD_SCHEDULED_EVENTS"},
+	{IntentAutoModerationConfiguration, "AUTO_MODERATION_CONFIGURATION"},
+	{IntentAutoModerationExecution, "AUTO_MODERATION_EXECUTION"},
+	{IntentGuildMessagePolls, "GUILD_MESSAGE_POLLS"},
+	{IntentDirectMessagePolls, "DIRECT_MESSAGE_POLLS"},
+}
+
+// allIntents is every intent bit Discord defines.
+var allIntents = func() IntentBits {
+	var all IntentBits
+	for _, n := range intentNames {
+		all |= n.intent
+	}
+	return all
+}()
+
+// eventIntents maps every dispatch event to the intents that deliver it.
+// Events delivered in both guilds and direct messages list both intents;
+// either one is enough for its context. Events mapped to 0 are sent
+// regardless of intents.
+//
+// See: https://discord.com/developers/docs/topics/gateway#list-of-intents
+var eventIntents = map[DispatchEvents]IntentBits{
+	EventApplicationCommandPermissionsUpdate: 0,
+	EventAutoModerationActionExecution:       IntentAutoModerationExecution,
+	EventAutoModerationRuleCreate:            IntentAutoModerationConfiguration,
+	EventAutoModerationRuleDelete:            IntentAutoModerationConfiguration,
+	EventAutoModerationRuleUpdate:            IntentAutoModerationConfiguration,
+	EventChannelCreate:                       IntentGuilds,
+	EventChannelDelete:                       IntentGuilds,
+	EventChannelPinsUpdate:                   IntentGuilds | IntentDirectMessages,
+	EventChannelUpdate:                       IntentGuilds,
+	EventEntitlementCreate:                   0,
+	EventEntitlementDelete:                   0,
+	EventEntitlementUpdate:                   0,
+	EventGuildAuditLogEntryCreate:            IntentGuildModeration,
+	EventGuildBanAdd:                         IntentGuildModeration,
+	EventGuildBanRemove:                      IntentGuildModeration,
+	EventGuildCreate:                         IntentGuilds,
+	EventGuildDelete:                         IntentGuilds,
+	EventGuildEmojisUpdate:                   IntentGuildExpressions,
+	EventGuildIntegrationsUpdate:             IntentGuildIntegrations,
+	EventGuildMemberAdd:                      IntentGuildMembers,
+	EventGuildMemberRemove:                   IntentGuildMembers,
+	EventGuildMembersChunk:                   0,
+	EventGuildMemberUpdate:                   IntentGuildMembers,
+	EventGuildRoleCreate:                     IntentGuilds,
+	EventGuildRoleDelete:                     IntentGuilds,
+	EventGuildRoleUpdate:                     IntentGuilds,
+	EventGuildScheduledEventCreate:           IntentGuildScheduledEvents,
+	EventGuildScheduledEventDelete:           IntentGuildScheduledEvents,
+	EventGuildScheduledEventUpdate:           IntentGuildScheduledEvents,
+	EventGuildScheduledEventUserAdd:          IntentGuildScheduledEvents,
+	EventGuildScheduledEventUserRemove:       IntentGuildScheduledEvents,
+	EventGuildSoundboardSoundCreate:          IntentGuildExpressions,
+	EventGuildSoundboardSoundDelete:          IntentGuildExpressions,
+	EventGuildSoundboardSoundsUpdate:         IntentGuildExpressions,
+	EventGuildSoundboardSoundUpdate:          IntentGuildExpressions,
+	EventSoundboardSounds:                    0,
+	EventGuildStickersUpdate:                 IntentGuildExpressions,
+	EventGuildUpdate:                         IntentGuilds,
+	EventIntegrationCreate:                   IntentGuildIntegrations,
+	EventIntegrationDelete:                   IntentGuildIntegrations,
+	EventIntegrationUpdate:                   IntentGuildIntegrations,
+	EventInteractionCreate:                   0,
+	EventInviteCreate:                        IntentGuildInvites,
+	EventInviteDelete:                        IntentGuildInvites,
+	EventMessageCreate:                       IntentGuildMessages | IntentDirectMessages,
+	EventMessageDelete:                       IntentGuildMessages | IntentDirectMessages,
+	EventMessageDeleteBulk:                   IntentGuildMessages,
+	EventMessagePollVoteAdd:                  IntentGuildMessagePolls | IntentDirectMessagePolls,
+	EventMessagePollVoteRemove:               IntentGuildMessagePolls | IntentDirectMessagePolls,
+	EventMessageReactionAdd:                  IntentGuildMessageReactions | IntentDirectMessageReactions,
+	EventMessageReactionRemove:               IntentGuildMessageReactions | IntentDirectMessageReactions,
+	EventMessageReactionRemoveAll:            IntentGuildMessageReactions | IntentDirectMessageReactions,
+	EventMessageReactionRemoveEmoji:          IntentGuildMessageReactions | IntentDirectMessageReactions,
+	EventMessageUpdate:                       IntentGuildMessages | IntentDirectMessages,
+	EventPresenceUpdate:                      IntentGuildPresences,
+	EventReady:                               0,
+	EventResumed:                             0,
+	EventStageInstanceCreate:                 IntentGuilds,
+	EventStageInstanceDelete:                 IntentGuilds,
+	EventStageInstanceUpdate:                 IntentGuilds,
+	EventSubscriptionCreate:                  0,
+	EventSubscriptionDelete:                  0,
+	EventSubscriptionUpdate:                  0,
+	EventThreadCreate:                        IntentGuilds,
+	EventThreadDelete:                        IntentGuilds,
+	EventThreadListSync:                      IntentGuilds,
+	EventThreadMembersUpdate:                 IntentGuilds,
+	EventThreadMemberUpdate:                  IntentGuilds,
+	EventThreadUpdate:                        IntentGuilds,
+	EventTypingStart:                         IntentGuildMessageTyping | IntentDirectMessageTyping,
+	EventUserUpdate:                          0,
+	EventVoiceChannelEffectSend:              IntentGuildVoiceStates,
+	EventVoiceServerUpdate:                   0,
+	EventVoiceStateUpdate:                    IntentGuildVoiceStates,
+	EventWebhooksUpdate:                      IntentGuildWebhooks,
+}
+
+// String returns the intents as Discord names joined by "|", for example
+// "GUILDS|GUILD_MESSAGES". Unknown bits are omitted.
+func (i IntentBits) String() string {
+	var names []string
+	for _, n := range intentNames {
+		if i&n.intent != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "0"
+	}
+	return strings.Join(names, "|")
+}
+
+// Has reports whether every bit of intents is set in i.
+func (i IntentBits) Has(intents IntentBits) bool {
+	return i&intents == intents
+}
+
+// Privileged returns the privileged intents set in i.
+func (i IntentBits) Privileged() IntentBits {
+	return i & PrivilegedIntents
+}
+
+// EventIntents returns the intents that deliver event. When it returns more
+// than one intent, any one of them delivers the event in its context (for
+// example IntentGuildMessages for guild messages and IntentDirectMessages for
+// direct messages). It returns 0 for events that are sent regardless of
+// intents, and ok is false for events this package does not know about.
+func EventIntents(event DispatchEvents) (intents IntentBits, ok bool) {
+	intents, ok = eventIntents[event]
+	return intents, ok
+}
+
+// IntentsForEvents returns the intents needed to receive every given event
+// from both guilds and direct messages. Mask out the direct message intents
+// if only guild events are needed.
+func IntentsForEvents(events ...DispatchEvents) IntentBits {
+	var intents IntentBits
+	for _, event := range events {
+		intents |= eventIntents[event]
+	}
+	return intents
+}
+
+// EventsForIntents returns the events that identifying with intents will
+// deliver, including the events that are sent regardless of intents, in
+// alphabetical order.
+func EventsForIntents(intents IntentBits) []DispatchEvents {
+	var events []DispatchEvents
+	for event, required := range eventIntents {
+		if required == 0 || intents&required != 0 {
+			events = append(events, event)
+		}
+	}
+	slices.Sort(events)
+	return events
+}
+
+// MessageContentFields are the message fields Discord sends empty when
+// IntentMessageContent is not enabled.
+//
+// See: https://discord.com/developers/docs/events/gateway#message-content-intent
+var MessageContentFields = []string{"content", "embeds", "attachments", "components", "poll"}
+
+// ExplainMessageContent describes which message fields will be empty when
+// identifying with intents, or returns "" if IntentMessageContent is set.
+func ExplainMessageContent(intents IntentBits) string {
+	if intents.Has(IntentMessageContent) {
+		return ""
+	}
+	return "Without the MESSAGE_CONTENT intent the " + strings.Join(MessageContentFields, ", ") +
+		" fields of messages are empty, except in direct messages, in messages that mention the bot," +
+		" in messages the bot sent, and in messages received through interactions."
+}
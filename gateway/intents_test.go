@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEventIntents_CoversDeclaredEvents(t *testing.T) {
+	for _, event := range declaredDispatchEvents(t) {
+		if _, ok := EventIntents(event); !ok {
+			t.Errorf("EventIntents(%s) is not mapped", event)
+		}
+	}
+}
+
+func TestIntentsForEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []DispatchEvents
+		expected IntentBits
+	}{
+		{"No events", nil, 0},
+		{"Intent free events", []DispatchEvents{EventReady, EventInteractionCreate}, 0},
+		{"Guild member add", []DispatchEvents{EventGuildMemberAdd}, IntentGuildMembers},
+		{"Message create", []DispatchEvents{EventMessageCreate}, IntentGuildMessages | IntentDirectMessages},
+		{
+			"Several events",
+			[]DispatchEvents{EventGuildCreate, EventPresenceUpdate, EventAutoModerationActionExecution},
+			IntentGuilds | IntentGuildPresences | IntentAutoModerationExecution,
+		},
+		{"Unknown event", []DispatchEvents{"SOMETHING_NEW"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IntentsForEvents(tt.events...); got != tt.expected {
+				t.Errorf("IntentsForEvents() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEventsForIntents(t *testing.T) {
+	events := EventsForIntents(IntentDirectMessages)
+	if !slices.IsSorted(events) {
+		t.Error("EventsForIntents() is not sorted")
+	}
+
+	for _, want := range []DispatchEvents{EventReady, EventInteractionCreate, EventMessageCreate, EventChannelPinsUpdate} {
+		if !slices.Contains(events, want) {
+			t.Errorf("EventsForIntents(DIRECT_MESSAGES) does not contain %s", want)
+		}
+	}
+	for _, unwanted := range []DispatchEvents{EventGuildCreate, EventMessageDeleteBulk, EventPresenceUpdate} {
+		if slices.Contains(events, unwanted) {
+			t.Errorf("EventsForIntents(DIRECT_MESSAGES) contains %s", unwanted)
+		}
+	}
+
+	// Every event is delivered by the union of all intents.
+	if got, want := len(EventsForIntents(allIntents)), len(eventIntents); got != want {
+		t.Errorf("len(EventsForIntents(all)) = %d, want %d", got, want)
+	}
+}
+
+func TestIntentBits_Privileged(t *testing.T) {
+	tests := []struct {
+		intents  IntentBits
+		expected IntentBits
+	}{
+		{IntentGuilds | IntentGuildMessages, 0},
+		{IntentGuilds | IntentGuildMembers, IntentGuildMembers},
+		{IntentGuildPresences | IntentMessageContent | IntentGuildMessages, IntentGuildPresences | IntentMessageContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.intents.String(), func(t *testing.T) {
+			if got := tt.intents.Privileged(); got != tt.expected {
+				t.Errorf("Privileged() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIntentBits_String(t *testing.T) {
+	tests := []struct {
+		intents  IntentBits
+		expected string
+	}{
+		{0, "0"},
+		{IntentGuilds, "GUILDS"},
+		{IntentGuilds | IntentGuildMessages | IntentMessageContent, "GUILDS|GUILD_MESSAGES|MESSAGE_CONTENT"},
+		{IntentGuildBans, "GUILD_MODERATION"},
+		{IntentDirectMessagePolls, "DIRECT_MESSAGE_POLLS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := tt.intents.String(); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExplainMessageContent(t *testing.T) {
+	if got := ExplainMessageContent(IntentGuildMessages | IntentMessageContent); got != "" {
+		t.Errorf("ExplainMessageContent() with MESSAGE_CONTENT = %q, want empty", got)
+	}
+
+	got := ExplainMessageContent(IntentGuildMessages)
+	for _, field := range MessageContentFields {
+		if !strings.Contains(got, field) {
+			t.Errorf("ExplainMessageContent() = %q, does not mention %q", got, field)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+)
+
+// ErrIncompleteChunks is returned by MemberRequest.Wait when the deadline
+// passes before every Guild Members Chunk has arrived.
+var ErrIncompleteChunks = errors.New("gateway: guild members chunks incomplete")
+
+// MemberChunkAssembler gathers the Guild Members Chunk events answering
+// Request Guild Members payloads into complete member lists, matching them
+// by nonce.
+//
+// Feed it every received payload by calling Handle, for example from a
+// ClientConfig.Handler.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#guild-members-chunk
+type MemberChunkAssembler struct {
+	mu      sync.Mutex
+	pending map[string]*MemberRequest
+}
+
+// NewMemberChunkAssembler returns an empty assembler.
+func NewMemberChunkAssembler() *MemberChunkAssembler {
+	return &MemberChunkAssembler{pending: make(map[string]*MemberRequest)}
+}
+
+// MemberRequest is a Request Guild Members payload waiting for its chunks.
+type MemberRequest struct {
+	// Nonce is the nonce generated for the request.
+	Nonce string
+
+	assembler *MemberChunkAssembler
+	guildID   discord.Snowflake
+	done      chan struct{}
+
+	mu     sync.Mutex
+	chunks map[int]GuildMembersChunkDispatchData
+	count  int
+}
+
+// MemberChunkResult is the assembled response to a Request Guild Members payload.
+type MemberChunkResult struct {
+	// GuildID is the guild the members belong to.
+	GuildID discord.Snowflake
+
+	// Members are the members of every received chunk, in chunk order.
+	Members []payloads.GuildMember
+
+	// NotFound are the requested user ids that were not found.
+	NotFound []discord.Snowflake
+
+	// Presences are the presences of the members, if they were requested.
+	Presences []GatewayPresenceUpdate
+
+	// ChunkCount is the total number of chunks, or 0 if none arrived.
+	ChunkCount int
+
+	// Missing are the indexes of the chunks that did not arrive.
+	Missing []int
+}
+
+// Complete reports whether every chunk arrived.
+func (r *MemberChunkResult) Complete() bool {
+	return r.ChunkCount > 0 && len(r.Missing) == 0
+}
+
+// Request registers data with the assembler under a newly generated nonce,
+// replacing any nonce already set, and returns the payload to send together
+// with the request to wait on.
+func (a *MemberChunkAssembler) Request(data RequestGuildMembersData) (RequestGuildMembers, *MemberRequest, error) {
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return RequestGuildMembers{}, nil, err
+	}
+	nonce := hex.EncodeToString(raw[:])
+	data.Nonce = &nonce
+
+	if err := data.Validate(); err != nil {
+		return RequestGuildMembers{}, nil, err
+	}
+
+	req := &MemberRequest{
+		Nonce:     nonce,
+		assembler: a,
+		guildID:   data.GuildID,
+		done:      make(chan struct{}),
+		chunks:    make(map[int]GuildMembersChunkDispatchData),
+	}
+
+	a.mu.Lock()
+	a.pending[nonce] = req
+	a.mu.Unlock()
+
+	return RequestGuildMembers{Op: OpcodeRequestGuildMembers, D: data}, req, nil
+}
+
+// Fetch requests members over c and waits for every chunk, or until ctx is
+// done. The assembler must be receiving c's payloads.
+func (a *MemberChunkAssembler) Fetch(ctx context.Context, c *Client, data RequestGuildMembersData) (*MemberChunkResult, error) {
+	payload, req, err := a.Request(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Send(ctx, payload); err != nil {
+		a.forget(req.Nonce)
+		return nil, err
+	}
+	return req.Wait(ctx)
+}
+
+// Handle consumes Guild Members Chunk events for pending requests and
+// ignores every other payload. Its signature matches Handler.
+func (a *MemberChunkAssembler) Handle(_ context.Context, payload GatewayReceivePayload) {
+	chunk, ok := payload.(GuildMembersChunkDispatch)
+	if ok {
+		a.Add(chunk.D)
+	}
+}
+
+// Add records a chunk and reports whether it belonged to a pending request.
+func (a *MemberChunkAssembler) Add(chunk GuildMembersChunkDispatchData) bool {
+	if chunk.Nonce == nil {
+		return false
+	}
+
+	a.mu.Lock()
+	req := a.pending[*chunk.Nonce]
+	a.mu.Unlock()
+	if req == nil {
+		return false
+	}
+
+	if req.add(chunk) {
+		a.forget(req.Nonce)
+		close(req.done)
+	}
+	return true
+}
+
+// Pending returns the number of requests still waiting for chunks.
+func (a *MemberChunkAssembler) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.pending)
+}
+
+func (a *MemberChunkAssembler) forget(nonce string) {
+	a.mu.Lock()
+	delete(a.pending, nonce)
+	a.mu.Unlock()
+}
+
+// add records chunk and reports whether it completed the request. Chunks
+// with an index outside their count are ignored, so that they cannot fill
+// the slot of a missing chunk.
+func (r *MemberRequest) add(chunk GuildMembersChunkDispatchData) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.count > 0 && len(r.chunks) == r.count {
+		return false
+	}
+	if chunk.ChunkIndex < 0 || chunk.ChunkIndex >= chunk.ChunkCount {
+		return false
+	}
+	r.count = chunk.ChunkCount
+	r.chunks[chunk.ChunkIndex] = chunk
+	return len(r.chunks) == r.count
+}
+
+// Wait blocks until every chunk has arrived or ctx is done. If ctx is done
+// first, Wait stops waiting for the request and returns the chunks received
+// so far together with an error wrapping ErrIncompleteChunks and ctx.Err().
+func (r *MemberRequest) Wait(ctx context.Context) (*MemberChunkResult, error) {
+	select {
+	case <-r.done:
+		return r.result(), nil
+	case <-ctx.Done():
+	}
+
+	r.assembler.forget(r.Nonce)
+	result := r.result()
+	if result.Complete() {
+		return result, nil
+	}
+	return result, fmt.Errorf("%w: received %d of %d chunks: %w",
+		ErrIncompleteChunks, result.ChunkCount-len(result.Missing), result.ChunkCount, ctx.Err())
+}
+
+// result assembles the chunks received so far.
+func (r *MemberRequest) result() *MemberChunkResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := &MemberChunkResult{GuildID: r.guildID, ChunkCount: r.count}
+	indexes := make([]int, 0, len(r.chunks))
+	for index := range r.chunks {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+
+	for _, index := range indexes {
+		chunk := r.chunks[index]
+		result.Members = append(result.Members, chunk.Members...)
+		result.NotFound = append(result.NotFound, chunk.NotFound...)
+		result.Presences = append(result.Presences, chunk.Presences...)
+	}
+	for index := range r.count {
+		if _, ok := r.chunks[index]; !ok {
+			result.Missing = append(result.Missing, index)
+		}
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// chunkFrame returns a Guild Members Chunk frame with one member per user id.
+func chunkFrame(nonce string, index, count int, userIDs []string, notFound []string) []byte {
+	members := make([]any, 0, len(userIDs))
+	presences := make([]any, 0, len(userIDs))
+	for _, id := range userIDs {
+		members = append(members, map[string]any{"user": map[string]any{"id": id, "username": "u" + id}, "roles": []string{}})
+		presences = append(presences, map[string]any{"user": map[string]any{"id": id}, "status": "online"})
+	}
+	data, _ := json.Marshal(map[string]any{
+		"op": 0, "s": index + 1, "t": "GUILD_MEMBERS_CHUNK",
+		"d": map[string]any{
+			"guild_id": "80351110224678912", "chunk_index": index, "chunk_count": count, "nonce": nonce,
+			"members": members, "presences": presences, "not_found": notFound,
+		},
+	})
+	return data
+}
+
+func feedChunk(t *testing.T, a *MemberChunkAssembler, frame []byte) {
+	t.Helper()
+
+	payload, err := DecodeReceivePayload(frame)
+	if err != nil {
+		t.Fatalf("DecodeReceivePayload() error = %v", err)
+	}
+	a.Handle(context.Background(), payload)
+}
+
+func memberIDs(result *MemberChunkResult) []discord.Snowflake {
+	ids := make([]discord.Snowflake, 0, len(result.Members))
+	for _, m := range result.Members {
+		ids = append(ids, m.User.ID)
+	}
+	return ids
+}
+
+func TestMemberChunkAssembler_Complete(t *testing.T) {
+	a := NewMemberChunkAssembler()
+	payload, req, err := a.Request(RequestGuildMembersData{GuildID: "80351110224678912", Presences: ptr(true)})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if payload.Op != OpcodeRequestGuildMembers || payload.D.Nonce == nil || *payload.D.Nonce != req.Nonce {
+		t.Fatalf("Request() payload = %+v, want op 8 with nonce %q", payload, req.Nonce)
+	}
+	if len(req.Nonce) > maxNonceLength {
+		t.Errorf("nonce is %d bytes, want at most %d", len(req.Nonce), maxNonceLength)
+	}
+
+	// Chunks for other requests are ignored.
+	feedChunk(t, a, chunkFrame("someone-else", 0, 1, []string{"9"}, nil))
+
+	// Chunks may arrive out of order.
+	feedChunk(t, a, chunkFrame(req.Nonce, 2, 3, []string{"5"}, []string{"6"}))
+	feedChunk(t, a, chunkFrame(req.Nonce, 0, 3, []string{"1", "2"}, nil))
+	feedChunk(t, a, chunkFrame(req.Nonce, 1, 3, []string{"3", "4"}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result, err := req.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	if !result.Complete() || result.ChunkCount != 3 {
+		t.Errorf("result complete = %v with %d chunks, want complete with 3", result.Complete(), result.ChunkCount)
+	}
+	if got, want := memberIDs(result), []discord.Snowflake{"1", "2", "3", "4", "5"}; !slices.Equal(got, want) {
+		t.Errorf("Members = %v, want %v", got, want)
+	}
+	if got, want := result.NotFound, []discord.Snowflake{"6"}; !slices.Equal(got, want) {
+		t.Errorf("NotFound = %v, want %v", got, want)
+	}
+	if len(result.Presences) != 5 {
+		t.Errorf("len(Presences) = %d, want 5", len(result.Presences))
+	}
+	if a.Pending() != 0 {
+		t.Errorf("Pending() = %d, want 0", a.Pending())
+	}
+}
+
+func TestMemberChunkAssembler_Deadline(t *testing.T) {
+	a := NewMemberChunkAssembler()
+	_, req, err := a.Request(RequestGuildMembersData{GuildID: "80351110224678912", Query: "a", Limit: 100})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	feedChunk(t, a, chunkFrame(req.Nonce, 0, 4, []string{"1"}, nil))
+	feedChunk(t, a, chunkFrame(req.Nonce, 2, 4, []string{"3"}, nil))
+
+	// Chunks with an index outside the count are ignored.
+	feedChunk(t, a, chunkFrame(req.Nonce, 4, 4, []string{"7"}, nil))
+	feedChunk(t, a, chunkFrame(req.Nonce, -1, 4, []string{"8"}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, err := req.Wait(ctx)
+	if !errors.Is(err, ErrIncompleteChunks) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() error = %v, want %v and %v", err, ErrIncompleteChunks, context.DeadlineExceeded)
+	}
+	if got, want := result.Missing, []int{1, 3}; !slices.Equal(got, want) {
+		t.Errorf("Missing = %v, want %v", got, want)
+	}
+	if got, want := memberIDs(result), []discord.Snowflake{"1", "3"}; !slices.Equal(got, want) {
+		t.Errorf("Members = %v, want %v", got, want)
+	}
+	if a.Pending() != 0 {
+		t.Errorf("Pending() after deadline = %d, want 0", a.Pending())
+	}
+}
+
+func TestMemberChunkAssembler_Invalid(t *testing.T) {
+	a := NewMemberChunkAssembler()
+	_, _, err := a.Request(RequestGuildMembersData{
+		GuildID: "80351110224678912",
+		UserIDs: []discord.Snowflake{"41771983423143937"},
+		Query:   "a",
+	})
+	if !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Request() error = %v, want %v", err, ErrInvalidPayload)
+	}
+	if a.Pending() != 0 {
+		t.Errorf("Pending() = %d, want 0", a.Pending())
+	}
+}
+
+func TestMemberChunkAssembler_Fetch(t *testing.T) {
+	g := newFakeGateway(t)
+	a := NewMemberChunkAssembler()
+	c := NewClient(ClientConfig{
+		Token:   "token",
+		Intents: IntentGuilds | IntentGuildMembers,
+		URL:     g.url("/"),
+		Handler: a.Handle,
+	})
+	runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+	waitReady(t, c)
+
+	type fetched struct {
+		result *MemberChunkResult
+		err    error
+	}
+	done := make(chan fetched, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := a.Fetch(ctx, c, RequestGuildMembersData{GuildID: "80351110224678912"})
+		done <- fetched{result, err}
+	}()
+
+	var request RequestGuildMembersData
+	if err := json.Unmarshal(conn.expect(OpcodeRequestGuildMembers), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Nonce == nil {
+		t.Fatal("Request Guild Members sent without a nonce")
+	}
+	for i := range 2 {
+		conn.send(string(chunkFrame(*request.Nonce, i, 2, []string{fmt.Sprint(i + 1)}, nil)))
+	}
+
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("Fetch() error = %v", got.err)
+	}
+	if ids := memberIDs(got.result); !slices.Equal(ids, []discord.Snowflake{"1", "2"}) {
+		t.Errorf("Members = %v, want [1 2]", ids)
+	}
+}
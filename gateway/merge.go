@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/kolosys/discord-types/payloads"
+)
+
+// Fields is the set of top-level JSON fields present in a partial update.
+// A field that is present with a null value is in the set; a field that
+// Discord left out is not.
+type Fields map[string]bool
+
+// Has reports whether the field was present in the update.
+func (f Fields) Has(name string) bool {
+	return f[name]
+}
+
+// Names returns the present fields in sorted order.
+func (f Fields) Names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// fieldsOf returns the top-level fields of a JSON object.
+func fieldsOf(data []byte) (Fields, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	fields := make(Fields, len(object))
+	for name := range object {
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// UnmarshalJSON decodes the update and records its fields.
+func (d *MessageUpdateDispatchData) UnmarshalJSON(data []byte) error {
+	fields, err := fieldsOf(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &d.MessageCreateDispatchData); err != nil {
+		return err
+	}
+	d.Fields = fields
+	return nil
+}
+
+// Merge applies the fields present in the update to dst, which holds the
+// message as previously received. Fields absent from the update keep
+// their value in dst.
+func (d MessageUpdateDispatchData) Merge(dst *payloads.Message) error {
+	return mergeFields(dst, d.MessageCreateDispatchData, d.Fields)
+}
+
+// UnmarshalJSON decodes the update and records its fields.
+func (d *GuildMemberUpdateDispatchData) UnmarshalJSON(data []byte) error {
+	// plain has the fields of GuildMemberUpdateDispatchData but not this
+	// method, so decoding into it does not recurse.
+	type plain GuildMemberUpdateDispatchData
+	fields, err := fieldsOf(data)
+	if err != nil {
+		return err
+	}
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*d = GuildMemberUpdateDispatchData(decoded)
+	d.Fields = fields
+	return nil
+}
+
+// Merge applies the fields present in the update to dst, which holds the
+// member as previously received. Fields absent from the update keep their
+// value in dst.
+func (d GuildMemberUpdateDispatchData) Merge(dst *payloads.GuildMember) error {
+	return mergeFields(dst, d.GuildMember, d.Fields)
+}
+
+// UnmarshalJSON decodes the update and records its fields.
+func (d *PresenceUpdateDispatchData) UnmarshalJSON(data []byte) error {
+	fields, err := fieldsOf(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &d.GatewayPresenceUpdate); err != nil {
+		return err
+	}
+	d.Fields = fields
+	return nil
+}
+
+// Merge applies the fields present in the update to dst, which holds the
+// presence as previously received. Fields absent from the update keep
+// their value in dst. The partial user is merged field by field, so a
+// presence update without a username keeps the known one.
+func (d PresenceUpdateDispatchData) Merge(dst *GatewayPresenceUpdate) error {
+	return mergeFields(dst, d.GatewayPresenceUpdate, d.Fields, "user")
+}
+
+// UnmarshalJSON decodes the update and records its fields.
+func (d *ThreadUpdateDispatchData) UnmarshalJSON(data []byte) error {
+	fields, err := fieldsOf(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &d.ThreadChannel); err != nil {
+		return err
+	}
+	d.Fields = fields
+	return nil
+}
+
+// Merge applies the fields present in the update to dst, which holds the
+// thread as previously received. Fields absent from the update keep their
+// value in dst.
+func (d ThreadUpdateDispatchData) Merge(dst *payloads.ThreadChannel) error {
+	return mergeFields(dst, d.ThreadChannel, d.Fields)
+}
+
+// mergeFields overlays the fields of update named in fields onto dst.
+//
+// The update and dst share their JSON representation, so the merge works
+// on it: a present field takes the update's value, or null if the update
+// encodes it as omitted. Fields named in deep are objects merged key by key.
+// The result is decoded into a fresh value, so dst never shares slices or
+// pointers with the update.
+func mergeFields[T any](dst *T, update any, fields Fields, deep ...string) error {
+	src, err := objectOf(update)
+	if err != nil {
+		return fmt.Errorf("gateway: merge: %w", err)
+	}
+	merged, err := objectOf(*dst)
+	if err != nil {
+		return fmt.Errorf("gateway: merge: %w", err)
+	}
+
+	for name := range fields {
+		value, ok := src[name]
+		if !ok {
+			value = json.RawMessage("null")
+		}
+		if slices.Contains(deep, name) && ok {
+			if value, err = mergeObjects(merged[name], value); err != nil {
+				return fmt.Errorf("gateway: merge %s: %w", name, err)
+			}
+		}
+		merged[name] = value
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("gateway: merge: %w", err)
+	}
+	var result T
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("gateway: merge: %w", err)
+	}
+	*dst = result
+	return nil
+}
+
+// mergeObjects overlays the keys of the JSON object src onto dst.
+func mergeObjects(dst, src json.RawMessage) (json.RawMessage, error) {
+	var object map[string]json.RawMessage
+	if len(dst) > 0 {
+		if err := json.Unmarshal(dst, &object); err != nil {
+			return nil, err
+		}
+	}
+	var overlay map[string]json.RawMessage
+	if err := json.Unmarshal(src, &overlay); err != nil {
+		return nil, err
+	}
+	if object == nil || overlay == nil {
+		return src, nil
+	}
+	for key, value := range overlay {
+		object[key] = value
+	}
+	return json.Marshal(object)
+}
+
+func objectOf(v any) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,195 @@
+package gateway
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+)
+
+// decodeUpdate decodes a dispatch frame and fails the test on error.
+func decodeUpdate[T GatewayReceivePayload](t *testing.T, frame string) T {
+	t.Helper()
+	payload, err := DecodeReceivePayload([]byte(frame))
+	if err != nil {
+		t.Fatalf("DecodeReceivePayload() error = %v", err)
+	}
+	p, ok := payload.(T)
+	if !ok {
+		t.Fatalf("DecodeReceivePayload() = %T", payload)
+	}
+	return p
+}
+
+func TestFields(t *testing.T) {
+	update := decodeUpdate[MessageUpdateDispatch](t, `{"op":0,"s":7,"t":"MESSAGE_UPDATE","d":{`+
+		`"id":"334385199974967042","channel_id":"290926798999357250","content":"edited","edited_timestamp":null}}`)
+
+	want := []string{"channel_id", "content", "edited_timestamp", "id"}
+	if got := update.D.Fields.Names(); !slices.Equal(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if !update.D.Fields.Has("edited_timestamp") {
+		t.Error("Has() of a null field = false, want true")
+	}
+	if update.D.Fields.Has("author") {
+		t.Error("Has() of an absent field = true, want false")
+	}
+}
+
+func TestMessageUpdate_Merge(t *testing.T) {
+	title := "Release notes"
+	sent := time.Date(2017, 7, 11, 17, 27, 7, 299000000, time.UTC)
+	cached := func() payloads.Message {
+		return payloads.Message{
+			ID:        "334385199974967042",
+			ChannelID: "290926798999357250",
+			Author:    payloads.User{ID: "53908099506183680", Username: "Mason"},
+			Content:   "Supa Hot",
+			Timestamp: sent,
+			Embeds:    []payloads.Embed{{Title: &title}},
+			Pinned:    true,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		frame string
+		check func(t *testing.T, m payloads.Message)
+	}{
+		{
+			name: "Content edit",
+			frame: `{"op":0,"s":7,"t":"MESSAGE_UPDATE","d":{"id":"334385199974967042","channel_id":"290926798999357250",` +
+				`"guild_id":"41771983423143937","content":"Supa Hot Fire","edited_timestamp":"2017-07-11T17:30:00.000000+00:00"}}`,
+			check: func(t *testing.T, m payloads.Message) {
+				if m.Content != "Supa Hot Fire" || m.EditedTimestamp == nil {
+					t.Errorf("content = %q, edited = %v", m.Content, m.EditedTimestamp)
+				}
+				if m.Author.Username != "Mason" || len(m.Embeds) != 1 || !m.Pinned || !m.Timestamp.Equal(sent) {
+					t.Errorf("absent fields were not kept: %+v", m)
+				}
+			},
+		},
+		{
+			name: "Embed unfurl",
+			frame: `{"op":0,"s":8,"t":"MESSAGE_UPDATE","d":{"id":"334385199974967042","channel_id":"290926798999357250",` +
+				`"embeds":[{"type":"link","url":"https://discord.com","title":"Discord"}]}}`,
+			check: func(t *testing.T, m payloads.Message) {
+				if len(m.Embeds) != 1 || m.Embeds[0].Title == nil || *m.Embeds[0].Title != "Discord" {
+					t.Errorf("embeds = %+v, want the unfurled embed", m.Embeds)
+				}
+				if m.Content != "Supa Hot" || m.Author.ID != "53908099506183680" {
+					t.Errorf("absent fields were not kept: %+v", m)
+				}
+			},
+		},
+		{
+			name: "Embeds removed",
+			frame: `{"op":0,"s":9,"t":"MESSAGE_UPDATE","d":{"id":"334385199974967042","channel_id":"290926798999357250",` +
+				`"embeds":[],"flags":4}}`,
+			check: func(t *testing.T, m payloads.Message) {
+				if len(m.Embeds) != 0 {
+					t.Errorf("embeds = %+v, want none", m.Embeds)
+				}
+				if m.Flags == nil || *m.Flags != 4 {
+					t.Errorf("flags = %v, want 4", m.Flags)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := decodeUpdate[MessageUpdateDispatch](t, tt.frame)
+			m := cached()
+			if err := update.D.Merge(&m); err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			tt.check(t, m)
+		})
+	}
+}
+
+func TestGuildMemberUpdate_Merge(t *testing.T) {
+	nick := "Nel"
+	deaf := true
+	member := payloads.GuildMember{
+		User:     &payloads.User{ID: "80351110224678912", Username: "Nelly"},
+		Nick:     &nick,
+		Roles:    []discord.Snowflake{"41771983423143936"},
+		JoinedAt: time.Date(2015, 4, 26, 6, 26, 56, 936000000, time.UTC),
+		Deaf:     &deaf,
+	}
+
+	update := decodeUpdate[GuildMemberUpdateDispatch](t, `{"op":0,"s":5,"t":"GUILD_MEMBER_UPDATE","d":{`+
+		`"guild_id":"41771983423143937","user":{"id":"80351110224678912","username":"Nelly"},`+
+		`"roles":["41771983423143936","41771983423143938"],"nick":null,"joined_at":"2015-04-26T06:26:56.936000+00:00"}}`)
+	if err := update.D.Merge(&member); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	if member.Nick != nil {
+		t.Errorf("nick = %q, want the cleared nickname", *member.Nick)
+	}
+	if len(member.Roles) != 2 {
+		t.Errorf("roles = %v, want 2", member.Roles)
+	}
+	if member.Deaf == nil || !*member.Deaf {
+		t.Error("absent deaf field was not kept")
+	}
+}
+
+func TestPresenceUpdate_Merge(t *testing.T) {
+	username, avatar := "Nelly", "a_1f2e3d"
+	presence := GatewayPresenceUpdate{
+		User:       PartialUser{ID: "80351110224678912", Username: &username, Avatar: &avatar},
+		GuildID:    "41771983423143937",
+		Status:     "online",
+		Activities: []Activity{{Name: "Rocket League", Type: 0}},
+	}
+
+	update := decodeUpdate[PresenceUpdateDispatch](t, `{"op":0,"s":6,"t":"PRESENCE_UPDATE","d":{`+
+		`"user":{"id":"80351110224678912","avatar":"b_4c5d6e"},"guild_id":"41771983423143937",`+
+		`"status":"idle","activities":[],"client_status":{"desktop":"idle"}}}`)
+	if err := update.D.Merge(&presence); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	if presence.Status != "idle" || len(presence.Activities) != 0 {
+		t.Errorf("presence = %+v, want idle without activities", presence)
+	}
+	if presence.User.Username == nil || *presence.User.Username != "Nelly" {
+		t.Error("username absent from the partial user was not kept")
+	}
+	if presence.User.Avatar == nil || *presence.User.Avatar != "b_4c5d6e" {
+		t.Errorf("avatar = %v, want b_4c5d6e", presence.User.Avatar)
+	}
+}
+
+func TestThreadUpdate_Merge(t *testing.T) {
+	messages := 12
+	thread := payloads.ThreadChannel{
+		GuildChannel:   payloads.GuildChannel{Name: "patch-notes"},
+		MessageCount:   &messages,
+		ThreadMetadata: payloads.ThreadMetadata{AutoArchiveDuration: 1440},
+	}
+
+	update := decodeUpdate[ThreadUpdateDispatch](t, `{"op":0,"s":11,"t":"THREAD_UPDATE","d":{`+
+		`"id":"1094281386235887616","guild_id":"41771983423143937","type":11,"name":"patch-notes",`+
+		`"thread_metadata":{"archived":true,"auto_archive_duration":1440,"archive_timestamp":"2023-04-10T12:00:00.000000+00:00"}}}`)
+	if err := update.D.Merge(&thread); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	if !thread.ThreadMetadata.Archived {
+		t.Error("thread was not archived")
+	}
+	if thread.MessageCount == nil || *thread.MessageCount != 12 {
+		t.Error("absent message count was not kept")
+	}
+	if !update.D.Fields.Has("thread_metadata") || update.D.Fields.Has("message_count") {
+		t.Errorf("Fields = %v", update.D.Fields.Names())
+	}
+}
@@ -0,0 +1,299 @@
+package gateway
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// DefaultReadyTimeout is the ReadyConfig.Timeout used when none is set.
+const DefaultReadyTimeout = 15 * time.Second
+
+// ShardReadyEvent is emitted by a ReadyTracker once a shard has received
+// every guild listed in its READY, or the timeout for the rest has passed.
+type ShardReadyEvent struct {
+	// ShardID is the shard that is ready.
+	ShardID int
+
+	// Guilds are the guilds of the READY that became available.
+	Guilds []discord.Snowflake
+
+	// Unavailable are the guilds of the READY that did not arrive before
+	// the timeout or are in an outage. They are announced with
+	// GuildAvailableEvent once they arrive.
+	Unavailable []discord.Snowflake
+}
+
+// GuildAvailableEvent is emitted by a ReadyTracker when a guild the
+// current user is already in arrives: a guild listed in READY, or a guild
+// recovering from an outage.
+type GuildAvailableEvent struct {
+	// ShardID is the shard that received the guild.
+	ShardID int
+
+	// Guild is the guild from its GUILD_CREATE.
+	Guild GuildCreateDispatchData
+
+	// Recovered is whether the guild was unavailable, because of an outage
+	// or because it did not arrive before the ready timeout.
+	Recovered bool
+}
+
+// GuildJoinEvent is emitted by a ReadyTracker when the current user joins
+// a guild, that is, on a GUILD_CREATE of a guild it did not know about.
+type GuildJoinEvent struct {
+	// ShardID is the shard that received the guild.
+	ShardID int
+
+	// Guild is the guild from its GUILD_CREATE.
+	Guild GuildCreateDispatchData
+}
+
+// GuildUnavailableEvent is emitted by a ReadyTracker when a guild becomes
+// unavailable because of an outage.
+type GuildUnavailableEvent struct {
+	// ShardID is the shard that received the GUILD_DELETE.
+	ShardID int
+
+	// GuildID is the guild that became unavailable.
+	GuildID discord.Snowflake
+}
+
+// GuildLeaveEvent is emitted by a ReadyTracker when the current user
+// leaves or is removed from a guild.
+type GuildLeaveEvent struct {
+	// ShardID is the shard that received the GUILD_DELETE.
+	ShardID int
+
+	// GuildID is the guild that was left.
+	GuildID discord.Snowflake
+}
+
+func (e ShardReadyEvent) isReceivePayload()       {}
+func (e GuildAvailableEvent) isReceivePayload()   {}
+func (e GuildJoinEvent) isReceivePayload()        {}
+func (e GuildUnavailableEvent) isReceivePayload() {}
+func (e GuildLeaveEvent) isReceivePayload()       {}
+
+// ReadyConfig configures a ReadyTracker.
+type ReadyConfig struct {
+	// Timeout is how long a shard waits for the next guild of its READY
+	// before it is declared ready without it. The wait restarts with every
+	// guild that arrives, so large shards are not cut short. Defaults to
+	// DefaultReadyTimeout.
+	Timeout time.Duration
+
+	// Handler receives the events emitted by the tracker. Its signature
+	// matches Router.HandleShard, so the events can be routed like any
+	// other payload.
+	Handler ShardHandler
+}
+
+// guildState is what a ReadyTracker knows about a guild.
+type guildState int
+
+const (
+	guildPending guildState = iota
+	guildAvailable
+	guildUnavailable
+)
+
+// readyShard is the initial load of a shard after its READY.
+type readyShard struct {
+	ready       bool
+	guilds      []discord.Snowflake
+	pending     map[discord.Snowflake]bool
+	unavailable []discord.Snowflake
+	timer       *time.Timer
+	ctx         context.Context
+}
+
+// ReadyTracker follows the guilds of each shard from READY through their
+// GUILD_CREATE and GUILD_DELETE events, and turns them into events that
+// tell the initial load apart from joins, leaves and outages:
+// ShardReadyEvent, GuildAvailableEvent, GuildJoinEvent,
+// GuildUnavailableEvent and GuildLeaveEvent.
+//
+// Feed it every received payload by calling HandleShard, or Handle with a
+// context from Router.HandleShard.
+//
+// See: https://discord.com/developers/docs/topics/gateway-events#ready
+type ReadyTracker struct {
+	timeout time.Duration
+	handler ShardHandler
+
+	mu     sync.Mutex
+	shards map[int]*readyShard
+	guilds map[discord.Snowflake]guildState
+}
+
+// NewReadyTracker returns a tracker that knows no guilds yet.
+func NewReadyTracker(cfg ReadyConfig) *ReadyTracker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultReadyTimeout
+	}
+	return &ReadyTracker{
+		timeout: cfg.Timeout,
+		handler: cfg.Handler,
+		shards:  make(map[int]*readyShard),
+		guilds:  make(map[discord.Snowflake]guildState),
+	}
+}
+
+// Handle feeds payload to the tracker. The shard is taken from ctx, see
+// ShardIDFromContext, and defaults to 0. Its signature matches Handler.
+func (t *ReadyTracker) Handle(ctx context.Context, payload GatewayReceivePayload) {
+	shardID, _ := ShardIDFromContext(ctx)
+	t.HandleShard(ctx, shardID, payload)
+}
+
+// HandleShard feeds payload, received by shardID, to the tracker. Events
+// are delivered to the handler before HandleShard returns, except for
+// ShardReadyEvent after a timeout. Its signature matches ShardHandler.
+func (t *ReadyTracker) HandleShard(ctx context.Context, shardID int, payload GatewayReceivePayload) {
+	var events []GatewayReceivePayload
+
+	t.mu.Lock()
+	switch p := payload.(type) {
+	case ReadyDispatch:
+		events = t.ready(ctx, shardID, p.D)
+	case GuildCreateDispatch:
+		events = t.guildCreate(shardID, p.D)
+	case GuildDeleteDispatch:
+		events = t.guildDelete(shardID, p.D)
+	}
+	t.mu.Unlock()
+
+	t.emit(ctx, shardID, events)
+}
+
+// Ready reports whether shardID has completed its initial load.
+func (t *ReadyTracker) Ready(shardID int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	shard, ok := t.shards[shardID]
+	return ok && shard.ready
+}
+
+// Unavailable returns the guilds that are currently unavailable, in no
+// particular order.
+func (t *ReadyTracker) Unavailable() []discord.Snowflake {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var ids []discord.Snowflake
+	for id, state := range t.guilds {
+		if state == guildUnavailable {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (t *ReadyTracker) ready(ctx context.Context, shardID int, d ReadyDispatchData) []GatewayReceivePayload {
+	if old, ok := t.shards[shardID]; ok && old.timer != nil {
+		old.timer.Stop()
+	}
+
+	shard := &readyShard{pending: make(map[discord.Snowflake]bool), ctx: context.WithoutCancel(ctx)}
+	t.shards[shardID] = shard
+	for _, guild := range d.Guilds {
+		t.guilds[guild.ID] = guildPending
+		shard.pending[guild.ID] = true
+	}
+
+	if len(shard.pending) == 0 {
+		return []GatewayReceivePayload{shard.complete(shardID)}
+	}
+	shard.timer = time.AfterFunc(t.timeout, func() { t.expire(shardID, shard) })
+	return nil
+}
+
+func (t *ReadyTracker) guildCreate(shardID int, d GuildCreateDispatchData) []GatewayReceivePayload {
+	previous, known := t.guilds[d.ID]
+	t.guilds[d.ID] = guildAvailable
+	if !known {
+		return []GatewayReceivePayload{GuildJoinEvent{ShardID: shardID, Guild: d}}
+	}
+
+	events := []GatewayReceivePayload{GuildAvailableEvent{ShardID: shardID, Guild: d, Recovered: previous == guildUnavailable}}
+	if shard, ok := t.shards[shardID]; ok && shard.pending[d.ID] {
+		delete(shard.pending, d.ID)
+		shard.guilds = append(shard.guilds, d.ID)
+		if len(shard.pending) == 0 {
+			events = append(events, shard.complete(shardID))
+		} else {
+			shard.timer.Reset(t.timeout)
+		}
+	}
+	return events
+}
+
+func (t *ReadyTracker) guildDelete(shardID int, d GuildDeleteDispatchData) []GatewayReceivePayload {
+	var events []GatewayReceivePayload
+	if d.Unavailable != nil && *d.Unavailable {
+		t.guilds[d.ID] = guildUnavailable
+		events = append(events, GuildUnavailableEvent{ShardID: shardID, GuildID: d.ID})
+	} else {
+		delete(t.guilds, d.ID)
+		events = append(events, GuildLeaveEvent{ShardID: shardID, GuildID: d.ID})
+	}
+
+	// A guild of the READY that is deleted will not arrive, so the shard
+	// does not wait for it.
+	if shard, ok := t.shards[shardID]; ok && shard.pending[d.ID] {
+		delete(shard.pending, d.ID)
+		if _, ok := t.guilds[d.ID]; ok {
+			shard.unavailable = append(shard.unavailable, d.ID)
+		}
+		if len(shard.pending) == 0 {
+			events = append(events, shard.complete(shardID))
+		}
+	}
+	return events
+}
+
+// expire declares shard ready once its timeout passes, marking the guilds
+// it still waits for as unavailable.
+func (t *ReadyTracker) expire(shardID int, shard *readyShard) {
+	t.mu.Lock()
+	if t.shards[shardID] != shard || shard.ready {
+		t.mu.Unlock()
+		return
+	}
+	for id := range shard.pending {
+		t.guilds[id] = guildUnavailable
+		shard.unavailable = append(shard.unavailable, id)
+	}
+	clear(shard.pending)
+	event := shard.complete(shardID)
+	t.mu.Unlock()
+
+	t.emit(shard.ctx, shardID, []GatewayReceivePayload{event})
+}
+
+func (t *ReadyTracker) emit(ctx context.Context, shardID int, events []GatewayReceivePayload) {
+	if t.handler == nil {
+		return
+	}
+	for _, event := range events {
+		t.handler(ctx, shardID, event)
+	}
+}
+
+// complete marks the shard ready and returns its ShardReadyEvent.
+func (s *readyShard) complete(shardID int) ShardReadyEvent {
+	s.ready = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	return ShardReadyEvent{
+		ShardID:     shardID,
+		Guilds:      slices.Clone(s.guilds),
+		Unavailable: slices.Clone(s.unavailable),
+	}
+}
@@ -0,0 +1,228 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// readyEvents collects the events of a ReadyTracker as short descriptions.
+type readyEvents struct {
+	mu     sync.Mutex
+	events []string
+	ready  chan struct{}
+}
+
+func newReadyEvents() *readyEvents {
+	return &readyEvents{ready: make(chan struct{}, 8)}
+}
+
+func (e *readyEvents) handle(_ context.Context, shardID int, payload GatewayReceivePayload) {
+	var event string
+	switch p := payload.(type) {
+	case ShardReadyEvent:
+		unavailable := slices.Clone(p.Unavailable)
+		slices.Sort(unavailable)
+		event = fmt.Sprintf("ShardReady %d %v %v", p.ShardID, p.Guilds, unavailable)
+		defer func() { e.ready <- struct{}{} }()
+	case GuildAvailableEvent:
+		event = fmt.Sprintf("GuildAvailable %s recovered=%v", p.Guild.ID, p.Recovered)
+	case GuildJoinEvent:
+		event = fmt.Sprintf("GuildJoin %s", p.Guild.ID)
+	case GuildUnavailableEvent:
+		event = fmt.Sprintf("GuildUnavailable %s", p.GuildID)
+	case GuildLeaveEvent:
+		event = fmt.Sprintf("GuildLeave %s", p.GuildID)
+	default:
+		event = fmt.Sprintf("%T", payload)
+	}
+	if id, ok := GuildIDOf(payload); ok && !strings.Contains(event, id.String()) {
+		event += " wrong guild " + id.String()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, fmt.Sprintf("%d: %s", shardID, event))
+}
+
+// take returns the events collected since the last call.
+func (e *readyEvents) take() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	events := e.events
+	e.events = nil
+	return events
+}
+
+// readyWith returns a READY dispatch listing guildIDs as unavailable.
+func readyWith(guildIDs ...string) string {
+	guilds := make([]string, len(guildIDs))
+	for i, id := range guildIDs {
+		guilds[i] = fmt.Sprintf(`{"id":%q,"unavailable":true}`, id)
+	}
+	return `{"op":0,"s":1,"t":"READY","d":{"v":10,"user":{"id":"80351110224678912","username":"Nelly"},` +
+		`"guilds":[` + strings.Join(guilds, ",") + `],"session_id":"c4b2b32e5ff9c0a7e1a0d2d8a9b4f3e1"}}`
+}
+
+func guildCreateFrame(id string) string {
+	return fmt.Sprintf(`{"op":0,"s":2,"t":"GUILD_CREATE","d":{"id":%q,"name":"Discord Developers","large":false,"member_count":1}}`, id)
+}
+
+func guildDeleteFrame(id string, unavailable bool) string {
+	if unavailable {
+		return fmt.Sprintf(`{"op":0,"s":3,"t":"GUILD_DELETE","d":{"id":%q,"unavailable":true}}`, id)
+	}
+	return fmt.Sprintf(`{"op":0,"s":3,"t":"GUILD_DELETE","d":{"id":%q}}`, id)
+}
+
+func TestReadyTracker(t *testing.T) {
+	const (
+		developers = "41771983423143937"
+		api        = "81384788765712384"
+		joined     = "290926798999357250"
+	)
+
+	tests := []struct {
+		name     string
+		frames   []string
+		expected []string
+	}{
+		{
+			name:   "Initial load",
+			frames: []string{readyWith(developers, api), guildCreateFrame(developers), guildCreateFrame(api)},
+			expected: []string{
+				"2: GuildAvailable 41771983423143937 recovered=false",
+				"2: GuildAvailable 81384788765712384 recovered=false",
+				"2: ShardReady 2 [41771983423143937 81384788765712384] []",
+			},
+		},
+		{
+			name:     "No guilds",
+			frames:   []string{readyWith()},
+			expected: []string{"2: ShardReady 2 [] []"},
+		},
+		{
+			name:   "Join and leave",
+			frames: []string{readyWith(), guildCreateFrame(joined), guildDeleteFrame(joined, false)},
+			expected: []string{
+				"2: ShardReady 2 [] []",
+				"2: GuildJoin 290926798999357250",
+				"2: GuildLeave 290926798999357250",
+			},
+		},
+		{
+			name: "Outage",
+			frames: []string{
+				readyWith(developers), guildCreateFrame(developers),
+				guildDeleteFrame(developers, true), guildCreateFrame(developers),
+			},
+			expected: []string{
+				"2: GuildAvailable 41771983423143937 recovered=false",
+				"2: ShardReady 2 [41771983423143937] []",
+				"2: GuildUnavailable 41771983423143937",
+				"2: GuildAvailable 41771983423143937 recovered=true",
+			},
+		},
+		{
+			name: "Outage during load",
+			frames: []string{
+				readyWith(developers, api), guildDeleteFrame(api, true), guildCreateFrame(developers),
+			},
+			expected: []string{
+				"2: GuildUnavailable 81384788765712384",
+				"2: GuildAvailable 41771983423143937 recovered=false",
+				"2: ShardReady 2 [41771983423143937] [81384788765712384]",
+			},
+		},
+		{
+			name:   "Removed during load",
+			frames: []string{readyWith(developers, api), guildDeleteFrame(api, false), guildCreateFrame(developers)},
+			expected: []string{
+				"2: GuildLeave 81384788765712384",
+				"2: GuildAvailable 41771983423143937 recovered=false",
+				"2: ShardReady 2 [41771983423143937] []",
+			},
+		},
+		{
+			name:   "Reconnect",
+			frames: []string{readyWith(developers), guildCreateFrame(developers), readyWith(developers), guildCreateFrame(developers)},
+			expected: []string{
+				"2: GuildAvailable 41771983423143937 recovered=false",
+				"2: ShardReady 2 [41771983423143937] []",
+				"2: GuildAvailable 41771983423143937 recovered=false",
+				"2: ShardReady 2 [41771983423143937] []",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := newReadyEvents()
+			tracker := NewReadyTracker(ReadyConfig{Timeout: time.Hour, Handler: events.handle})
+			for _, frame := range tt.frames {
+				payload, err := DecodeReceivePayload([]byte(frame))
+				if err != nil {
+					t.Fatalf("DecodeReceivePayload() error = %v", err)
+				}
+				tracker.HandleShard(context.Background(), 2, payload)
+			}
+
+			if got := events.take(); !slices.Equal(got, tt.expected) {
+				t.Errorf("events =\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(tt.expected, "\n\t"))
+			}
+			if !tracker.Ready(2) {
+				t.Error("Ready() = false after the initial load")
+			}
+		})
+	}
+}
+
+func TestReadyTracker_Timeout(t *testing.T) {
+	events := newReadyEvents()
+	tracker := NewReadyTracker(ReadyConfig{Timeout: 200 * time.Millisecond, Handler: events.handle})
+
+	// The capture's READY lists two guilds, but only one of them arrives.
+	router := NewRouter(ConcurrencyInline)
+	On(router, func(ctx context.Context, p ReadyDispatch) { tracker.Handle(ctx, p) })
+	On(router, func(ctx context.Context, p GuildCreateDispatch) { tracker.Handle(ctx, p) })
+	if err := Replay(context.Background(), openCapture(t, "session.jsonl"), ReplayConfig{Handler: router.HandleShard}); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if tracker.Ready(0) {
+		t.Fatal("Ready() = true before the timeout")
+	}
+
+	select {
+	case <-events.ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ShardReadyEvent after the timeout")
+	}
+	expected := []string{
+		"0: GuildAvailable 41771983423143937 recovered=false",
+		"0: ShardReady 0 [41771983423143937] [81384788765712384]",
+	}
+	if got := events.take(); !slices.Equal(got, expected) {
+		t.Errorf("events = %q, want %q", got, expected)
+	}
+	if got := tracker.Unavailable(); !slices.Equal(got, []discord.Snowflake{"81384788765712384"}) {
+		t.Errorf("Unavailable() = %v", got)
+	}
+
+	payload, err := DecodeReceivePayload([]byte(guildCreateFrame("81384788765712384")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.HandleShard(context.Background(), 0, payload)
+	if got := events.take(); !slices.Equal(got, []string{"0: GuildAvailable 81384788765712384 recovered=true"}) {
+		t.Errorf("events after the late guild = %q", got)
+	}
+	if got := tracker.Unavailable(); len(got) != 0 {
+		t.Errorf("Unavailable() = %v, want none", got)
+	}
+}
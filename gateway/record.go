@@ -0,0 +1,209 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RecordedFrame is one line of a capture file written by Recorder.
+type RecordedFrame struct {
+	// Time is when the frame was received.
+	Time time.Time `json:"time"`
+
+	// Shard is the id of the shard that received the frame.
+	Shard int `json:"shard"`
+
+	// Encoding is the payload encoding of the connection.
+	Encoding GatewayEncoding `json:"encoding"`
+
+	// Compression is the transport compression of the connection, if any.
+	Compression GatewayCompression `json:"compression,omitempty"`
+
+	// Connect is set on the first frame of a connection, where transport
+	// compression starts over.
+	Connect bool `json:"connect,omitempty"`
+
+	// Data is the WebSocket message as received, before decompression and
+	// decoding. It is base64 encoded in the capture.
+	Data []byte `json:"data"`
+}
+
+// Recorder writes every frame received by one or more clients to a capture
+// in JSON Lines format, one RecordedFrame per line. Captures can be fed back
+// through the decoder with Replay.
+//
+// Frames are captured as the raw WebSocket messages, before transport
+// decompression and payload decoding, so frames that fail to decode are
+// captured too.
+//
+// Set it as ClientConfig.Recorder; a Manager shares it between its shards.
+// A Recorder is safe for concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+	now func() time.Time
+}
+
+// NewRecorder returns a Recorder writing to w. Writes are not buffered; wrap
+// w in a bufio.Writer for busy sessions and flush it once the clients stop.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w, now: time.Now}
+}
+
+// Record writes frame, setting its Time to the current time if zero. A frame
+// that cannot be encoded is dropped with an error; after the first failed
+// write to the underlying writer, Record drops every frame and returns that
+// error.
+func (r *Recorder) Record(frame RecordedFrame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	if frame.Time.IsZero() {
+		frame.Time = r.now()
+	}
+	line, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("gateway: record frame: %w", err)
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		r.err = fmt.Errorf("gateway: record frame: %w", err)
+	}
+	return r.err
+}
+
+// Err returns the error that stopped the recording, if any. Clients do not
+// fail when recording does, so check Err after they stop.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReplayConfig configures Replay.
+type ReplayConfig struct {
+	// Speed scales the delays between frames: 1 replays in real time, 2 twice
+	// as fast. Zero or less delivers every frame without delay.
+	Speed float64
+
+	// Events, if set, limits delivery to dispatches of these events. Other
+	// payloads, including non-dispatch opcodes, are skipped.
+	Events []DispatchEvents
+
+	// Shards, if set, limits delivery to frames received by these shards.
+	Shards []int
+
+	// Handler receives every delivered payload with the shard that received it.
+	Handler ShardHandler
+}
+
+// Replay reads a capture written by Recorder and feeds each frame through
+// the same decompression and payload decoding as a live connection,
+// delivering the payloads to cfg.Handler in capture order on the calling
+// goroutine.
+//
+// Replay returns nil at the end of the capture, ctx.Err() if ctx is done
+// first, or the first frame that fails to decompress or, once delivered, to
+// decode. Frames skipped by cfg.Events are decompressed but not decoded;
+// frames skipped by cfg.Shards are neither.
+func Replay(ctx context.Context, r io.Reader, cfg ReplayConfig) error {
+	dec := json.NewDecoder(r)
+
+	// Transport compression is a stream per connection, and a shard has one
+	// connection at a time.
+	decompressors := make(map[int]TransportDecompressor)
+	defer func() {
+		for _, d := range decompressors {
+			d.Close()
+		}
+	}()
+
+	var last time.Time
+	for line := 1; ; line++ {
+		var frame RecordedFrame
+		if err := dec.Decode(&frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("gateway: replay line %d: %w", line, err)
+		}
+
+		if cfg.Speed > 0 && !last.IsZero() {
+			if delay := time.Duration(float64(frame.Time.Sub(last)) / cfg.Speed); delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		last = frame.Time
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(cfg.Shards) > 0 && !slices.Contains(cfg.Shards, frame.Shard) {
+			continue
+		}
+
+		data := frame.Data
+		if frame.Compression != "" {
+			d := decompressors[frame.Shard]
+			if d == nil || frame.Connect {
+				if d != nil {
+					d.Close()
+				}
+				var err error
+				if d, err = NewTransportDecompressor(frame.Compression); err != nil {
+					return fmt.Errorf("gateway: replay line %d: %w", line, err)
+				}
+				decompressors[frame.Shard] = d
+			}
+			var err error
+			if data, err = d.Decompress(data); err != nil {
+				return fmt.Errorf("gateway: replay line %d: %w", line, err)
+			}
+			if data == nil {
+				continue
+			}
+		}
+
+		if frame.Encoding == GatewayEncodingETF {
+			var err error
+			if data, err = ETFToJSON(data); err != nil {
+				return fmt.Errorf("gateway: replay line %d: %w", line, err)
+			}
+		}
+
+		if len(cfg.Events) > 0 {
+			var envelope struct {
+				T *string `json:"t"`
+			}
+			if err := json.Unmarshal(data, &envelope); err != nil {
+				return fmt.Errorf("gateway: replay line %d: decode frame: %w", line, err)
+			}
+			if envelope.T == nil || !slices.Contains(cfg.Events, DispatchEvents(*envelope.T)) {
+				continue
+			}
+		}
+
+		payload, _, err := decodeReceiveFrame(data)
+		if err != nil {
+			return fmt.Errorf("gateway: replay line %d: %w", line, err)
+		}
+		if cfg.Handler != nil {
+			cfg.Handler(ctx, frame.Shard, payload)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openCapture opens a capture from testdata/captures.
+func openCapture(t *testing.T, name string) *os.File {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "captures", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// replayTypes replays capture with cfg and returns the delivered payload types.
+func replayTypes(t *testing.T, capture []byte, cfg ReplayConfig) []string {
+	t.Helper()
+
+	var types []string
+	cfg.Handler = func(_ context.Context, _ int, payload GatewayReceivePayload) {
+		types = append(types, fmt.Sprintf("%T", payload))
+	}
+	if err := Replay(context.Background(), bytes.NewReader(capture), cfg); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	return types
+}
+
+func TestReplay_Capture(t *testing.T) {
+	f := openCapture(t, "session.jsonl")
+	var capture bytes.Buffer
+	if _, err := capture.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      ReplayConfig
+		expected []string
+	}{
+		{
+			name: "Every frame",
+			expected: []string{
+				"gateway.Hello", "gateway.ReadyDispatch", "gateway.GuildCreateDispatch", "gateway.HeartbeatAck",
+				"gateway.MessageCreateDispatch", "gateway.GuildMemberAddDispatch", "gateway.MessageReactionAddDispatch",
+				"gateway.PresenceUpdateDispatch", "gateway.ThreadCreateDispatch", "gateway.GuildMemberRemoveDispatch",
+			},
+		},
+		{
+			name:     "Event filter",
+			cfg:      ReplayConfig{Events: []DispatchEvents{EventGuildMemberAdd, EventGuildMemberRemove}},
+			expected: []string{"gateway.GuildMemberAddDispatch", "gateway.GuildMemberRemoveDispatch"},
+		},
+		{
+			name: "Shard filter",
+			cfg:  ReplayConfig{Shards: []int{1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replayTypes(t, capture.Bytes(), tt.cfg); !slices.Equal(got, tt.expected) {
+				t.Errorf("Replay() delivered %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReplay_Speed(t *testing.T) {
+	// The capture spans 10.14 seconds.
+	start := time.Now()
+	if err := Replay(context.Background(), openCapture(t, "session.jsonl"), ReplayConfig{Speed: 200}); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Replay() at 200x took %v, want at least 50ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Replay(ctx, openCapture(t, "session.jsonl"), ReplayConfig{Speed: 1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Replay() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// captureLine returns the capture line of a JSON frame received by shard 0.
+func captureLine(t *testing.T, received time.Time, data string) string {
+	t.Helper()
+
+	line, err := json.Marshal(RecordedFrame{Time: received, Encoding: GatewayEncodingJSON, Data: []byte(data)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(line) + "\n"
+}
+
+func TestReplay_Errors(t *testing.T) {
+	received := time.Date(2024, 1, 15, 18, 4, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		capture  string
+		expected string
+	}{
+		{"Malformed line", captureLine(t, received, `{"op":11}`) + "not json\n", "line 2"},
+		{"Undecodable frame", captureLine(t, received, `{"op":2,"d":null}`), "line 1"},
+		{"Corrupt compression", `{"time":"2024-01-15T18:04:30Z","shard":0,"encoding":"json","compression":"zlib-stream","connect":true,"data":"bm90IHpsaWIAAP//"}`, "line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Replay(context.Background(), strings.NewReader(tt.capture), ReplayConfig{})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Replay() error = %v, want mention of %q", err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Filtered out frame", func(t *testing.T) {
+		capture := captureLine(t, received, `{"op":0,"s":1,"t":"TYPING_START","d":{"channel_id":[]}}`) +
+			captureLine(t, received.Add(time.Second), `{"op":11}`)
+		cfg := ReplayConfig{Events: []DispatchEvents{EventGuildMemberAdd}}
+		if err := Replay(context.Background(), strings.NewReader(capture), cfg); err != nil {
+			t.Errorf("Replay() error = %v, want frames skipped by the event filter left undecoded", err)
+		}
+	})
+}
+
+func TestRecorder_Client(t *testing.T) {
+	g := newFakeGateway(t)
+	var capture bytes.Buffer
+	rec := NewRecorder(&capture)
+	live := newRecorder()
+	c := NewClient(ClientConfig{
+		Token:    "token",
+		URL:      g.url("/"),
+		Shard:    &[2]int{3, 4},
+		Handler:  live.handle,
+		Recorder: rec,
+	})
+	stop, _ := runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+	conn.send(typingFrame)
+	live.waitFor(t, isType[TypingStartDispatch])
+	stop()
+
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Recorder.Err() = %v", err)
+	}
+
+	var shards []int
+	var types []string
+	err := Replay(context.Background(), &capture, ReplayConfig{
+		Handler: func(_ context.Context, shardID int, payload GatewayReceivePayload) {
+			shards = append(shards, shardID)
+			types = append(types, fmt.Sprintf("%T", payload))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+
+	expected := []string{"gateway.Hello", "gateway.ReadyDispatch", "gateway.TypingStartDispatch"}
+	if !slices.Equal(types, expected) {
+		t.Errorf("replayed %v, want %v", types, expected)
+	}
+	if !slices.Equal(shards, []int{3, 3, 3}) {
+		t.Errorf("replayed shards %v, want 3", shards)
+	}
+}
+
+func TestRecorder_UndecodableFrame(t *testing.T) {
+	g := newFakeGateway(t)
+	var capture bytes.Buffer
+	rec := NewRecorder(&capture)
+	c := NewClient(ClientConfig{
+		Token:       "token",
+		URL:         g.url("/"),
+		Compression: GatewayCompressionZlibStream,
+		Recorder:    rec,
+	})
+	stop, _ := runClient(t, c)
+
+	conn := g.accept()
+	identifyAndReady(t, g, conn)
+	conn.send("not json")
+	resumed := g.accept()
+	resumed.hello(45000)
+	resumed.expect(OpcodeResume)
+	stop()
+
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Recorder.Err() = %v", err)
+	}
+
+	// The frame that failed to decode ends the first connection; the second
+	// connection is recorded after it, with its own compression stream.
+	lines := strings.SplitAfter(strings.TrimSuffix(capture.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("captured %d frames, want 4:\n%s", len(lines), capture.String())
+	}
+	first, second := strings.Join(lines[:3], ""), lines[3]
+
+	err := Replay(context.Background(), strings.NewReader(first), ReplayConfig{})
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Replay() of the first connection error = %v, want the undecodable frame on line 3", err)
+	}
+	if got := replayTypes(t, []byte(second), ReplayConfig{}); !slices.Equal(got, []string{"gateway.Hello"}) {
+		t.Errorf("Replay() of the second connection delivered %v, want [gateway.Hello]", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestRecorder_Err(t *testing.T) {
+	rec := NewRecorder(failingWriter{})
+	frame := RecordedFrame{Encoding: GatewayEncodingJSON, Data: []byte(`{"op":11}`)}
+	if err := rec.Record(frame); err == nil {
+		t.Fatal("Record() error = nil, want the write error")
+	}
+	if err := rec.Record(frame); err == nil || rec.Err() != err {
+		t.Errorf("Record() after failure error = %v, Err() = %v, want the first error", err, rec.Err())
+	}
+
+	// A frame that cannot be encoded is dropped without stopping the
+	// recording.
+	var capture bytes.Buffer
+	rec = NewRecorder(&capture)
+	if err := rec.Record(RecordedFrame{Time: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil {
+		t.Error("Record() of an unencodable frame error = nil, want an error")
+	}
+	if err := rec.Record(frame); err != nil || rec.Err() != nil {
+		t.Errorf("Record() after an unencodable frame error = %v, Err() = %v, want nil", err, rec.Err())
+	}
+	if got := replayTypes(t, capture.Bytes(), ReplayConfig{}); !slices.Equal(got, []string{"gateway.HeartbeatAck"}) {
+		t.Errorf("Replay() delivered %v, want [gateway.HeartbeatAck]", got)
+	}
+}
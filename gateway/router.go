@@ -0,0 +1,413 @@
+package gateway
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// Concurrency selects how a Router runs its handlers.
+type Concurrency int
+
+const (
+	// ConcurrencyInline runs every handler on the goroutine that delivered
+	// the payload, one after another. A slow handler delays reading the
+	// connection, so keep handlers short.
+	ConcurrencyInline Concurrency = iota
+
+	// ConcurrencyPerGuild runs the handlers of each guild in order on a
+	// goroutine of its own, so payloads of one guild are handled
+	// sequentially while different guilds are handled in parallel. Payloads
+	// without a guild share one sequence.
+	ConcurrencyPerGuild
+
+	// ConcurrencyParallel runs every handler call on its own goroutine.
+	ConcurrencyParallel
+)
+
+// Middleware wraps the call of a handler. Middleware sees the payload as a
+// GatewayReceivePayload and may inspect it, skip the call, or act around it.
+type Middleware func(next Handler) Handler
+
+// Router delivers received payloads to handlers registered for their type
+// with On and Once.
+//
+// Routing is keyed on the dynamic type of the payload, so every type
+// returned by DecodeReceivePayload is routable as soon as it is decoded;
+// handlers may also be registered for an interface type such as
+// GatewayReceivePayload to receive every payload implementing it.
+//
+// Use Handle as ClientConfig.Handler, or HandleShard as ManagerConfig.Handler.
+type Router struct {
+	concurrency Concurrency
+
+	mu         sync.RWMutex
+	nextID     uint64
+	handlers   map[reflect.Type][]*route
+	interfaces []*route
+	middleware []Middleware
+
+	queueMu sync.Mutex
+	queues  map[discord.Snowflake]*guildQueue
+
+	wg sync.WaitGroup
+}
+
+// route is a handler registered with a Router.
+type route struct {
+	id      uint64
+	typ     reflect.Type
+	once    bool
+	removed atomic.Bool
+	call    func(context.Context, GatewayReceivePayload)
+}
+
+// guildQueue holds the calls waiting to run for one guild.
+type guildQueue struct {
+	calls []func()
+}
+
+// NewRouter returns a Router without handlers that runs them with the given
+// concurrency.
+func NewRouter(concurrency Concurrency) *Router {
+	return &Router{
+		concurrency: concurrency,
+		handlers:    make(map[reflect.Type][]*route),
+		queues:      make(map[discord.Snowflake]*guildQueue),
+	}
+}
+
+// Use appends middleware to the router. The first middleware added is the
+// outermost. Middleware applies to every handler call, including calls of
+// handlers registered before Use.
+func (r *Router) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// On registers fn to be called with every payload of type T, for example
+// On(r, func(ctx context.Context, m MessageCreateDispatch) { ... }). It
+// returns a function that removes the handler.
+//
+// Handlers are called in the order they were registered.
+func On[T GatewayReceivePayload](r *Router, fn func(context.Context, T)) (remove func()) {
+	return r.add(routeFor(fn, false))
+}
+
+// Once registers fn to be called with the next payload of type T only. It
+// returns a function that removes the handler if it has not run yet.
+func Once[T GatewayReceivePayload](r *Router, fn func(context.Context, T)) (remove func()) {
+	return r.add(routeFor(fn, true))
+}
+
+func routeFor[T GatewayReceivePayload](fn func(context.Context, T), once bool) *route {
+	return &route{
+		typ:  reflect.TypeFor[T](),
+		once: once,
+		call: func(ctx context.Context, payload GatewayReceivePayload) {
+			fn(ctx, payload.(T))
+		},
+	}
+}
+
+func (r *Router) add(rt *route) (remove func()) {
+	r.mu.Lock()
+	r.nextID++
+	rt.id = r.nextID
+	if rt.typ.Kind() == reflect.Interface {
+		r.interfaces = append(r.interfaces, rt)
+	} else {
+		r.handlers[rt.typ] = append(r.handlers[rt.typ], rt)
+	}
+	r.mu.Unlock()
+
+	return func() { r.remove(rt) }
+}
+
+func (r *Router) remove(rt *route) {
+	rt.removed.Store(true)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rt.typ.Kind() == reflect.Interface {
+		r.interfaces = slices.DeleteFunc(r.interfaces, func(other *route) bool { return other == rt })
+		return
+	}
+	handlers := slices.DeleteFunc(r.handlers[rt.typ], func(other *route) bool { return other == rt })
+	if len(handlers) == 0 {
+		delete(r.handlers, rt.typ)
+	} else {
+		r.handlers[rt.typ] = handlers
+	}
+}
+
+// Len returns the number of registered handlers.
+func (r *Router) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := len(r.interfaces)
+	for _, handlers := range r.handlers {
+		n += len(handlers)
+	}
+	return n
+}
+
+// Handle delivers payload to its handlers. Its signature matches Handler.
+func (r *Router) Handle(ctx context.Context, payload GatewayReceivePayload) {
+	if payload == nil {
+		return
+	}
+	routes, middleware := r.match(payload)
+	if len(routes) == 0 {
+		return
+	}
+
+	for _, rt := range routes {
+		// The handler may have been removed, or a one-shot handler claimed
+		// by a concurrent delivery, since the routes were matched.
+		if rt.once {
+			if !rt.removed.CompareAndSwap(false, true) {
+				continue
+			}
+			r.remove(rt)
+		} else if rt.removed.Load() {
+			continue
+		}
+
+		var h Handler = rt.call
+		for i := len(middleware) - 1; i >= 0; i-- {
+			h = middleware[i](h)
+		}
+		call := func() { h(ctx, payload) }
+
+		switch r.concurrency {
+		case ConcurrencyPerGuild:
+			guildID, _ := GuildIDOf(payload)
+			r.enqueue(guildID, call)
+		case ConcurrencyParallel:
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				call()
+			}()
+		default:
+			call()
+		}
+	}
+}
+
+// HandleShard delivers payload to its handlers with the shard id stored in
+// the context passed to them; see ShardIDFromContext. Its signature matches
+// ShardHandler.
+func (r *Router) HandleShard(ctx context.Context, shardID int, payload GatewayReceivePayload) {
+	r.Handle(context.WithValue(ctx, shardIDKey{}, shardID), payload)
+}
+
+// Wait blocks until every handler call started so far has returned. It
+// returns immediately for ConcurrencyInline.
+func (r *Router) Wait() {
+	r.wg.Wait()
+}
+
+// match returns the live handlers for payload in registration order,
+// together with the middleware to wrap them in.
+func (r *Router) match(payload GatewayReceivePayload) ([]*route, []Middleware) {
+	typ := reflect.TypeOf(payload)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routes := slices.Clone(r.handlers[typ])
+	concrete := len(routes)
+	for _, rt := range r.interfaces {
+		if typ.Implements(rt.typ) {
+			routes = append(routes, rt)
+		}
+	}
+	if concrete > 0 && len(routes) > concrete {
+		slices.SortFunc(routes, func(a, b *route) int { return cmp.Compare(a.id, b.id) })
+	}
+	return routes, slices.Clone(r.middleware)
+}
+
+// enqueue runs call after every call queued before it for the same guild.
+func (r *Router) enqueue(guildID discord.Snowflake, call func()) {
+	r.wg.Add(1)
+
+	r.queueMu.Lock()
+	defer r.queueMu.Unlock()
+
+	if q, ok := r.queues[guildID]; ok {
+		q.calls = append(q.calls, call)
+		return
+	}
+
+	q := &guildQueue{calls: []func(){call}}
+	r.queues[guildID] = q
+	go func() {
+		for {
+			r.queueMu.Lock()
+			if len(q.calls) == 0 {
+				delete(r.queues, guildID)
+				r.queueMu.Unlock()
+				return
+			}
+			next := q.calls[0]
+			q.calls = q.calls[1:]
+			r.queueMu.Unlock()
+
+			next()
+			r.wg.Done()
+		}
+	}()
+}
+
+// shardIDKey is the context key of the shard id stored by HandleShard.
+type shardIDKey struct{}
+
+// ShardIDFromContext returns the id of the shard that received the payload
+// being handled, if it was delivered through HandleShard.
+func ShardIDFromContext(ctx context.Context) (int, bool) {
+	shardID, ok := ctx.Value(shardIDKey{}).(int)
+	return shardID, ok
+}
+
+// guildIDFields caches, per payload type, the index of the GuildID field of
+// its data, or nil if it has none.
+var guildIDFields sync.Map
+
+var (
+	snowflakeType        = reflect.TypeFor[discord.Snowflake]()
+	snowflakePointerType = reflect.TypeFor[*discord.Snowflake]()
+)
+
+// GuildIDOf returns the id of the guild payload belongs to. It reports false
+// for payloads that are not tied to a guild, such as READY or direct
+// messages.
+//
+// The guild is taken from the guild_id field of the payload's data, or the
+// id of the guild itself for GUILD_CREATE, GUILD_UPDATE, GUILD_DELETE and
+// the guild events of a ReadyTracker.
+func GuildIDOf(payload GatewayReceivePayload) (discord.Snowflake, bool) {
+	switch p := payload.(type) {
+	case GuildCreateDispatch:
+		return p.D.ID, p.D.ID != ""
+	case GuildUpdateDispatch:
+		return p.D.ID, p.D.ID != ""
+	case GuildDeleteDispatch:
+		return p.D.ID, p.D.ID != ""
+	case GuildAvailableEvent:
+		return p.Guild.ID, p.Guild.ID != ""
+	case GuildJoinEvent:
+		return p.Guild.ID, p.Guild.ID != ""
+	case GuildUnavailableEvent:
+		return p.GuildID, p.GuildID != ""
+	case GuildLeaveEvent:
+		return p.GuildID, p.GuildID != ""
+	case nil:
+		return "", false
+	}
+
+	v := reflect.ValueOf(payload)
+	index, ok := guildIDFields.Load(v.Type())
+	if !ok {
+		index = guildIDField(v.Type())
+		guildIDFields.Store(v.Type(), index)
+	}
+	if index == nil {
+		return "", false
+	}
+
+	field := v.FieldByIndex(index.([]int))
+	if field.Type() == snowflakePointerType {
+		if field.IsNil() {
+			return "", false
+		}
+		field = field.Elem()
+	}
+	id := discord.Snowflake(field.String())
+	return id, id != ""
+}
+
+// guildIDField returns the index of D.GuildID in typ, or nil if typ has no
+// such field.
+func guildIDField(typ reflect.Type) any {
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	data, ok := typ.FieldByName("D")
+	if !ok || data.Type.Kind() != reflect.Struct {
+		return nil
+	}
+	guildID, ok := data.Type.FieldByName("GuildID")
+	if !ok || (guildID.Type != snowflakeType && guildID.Type != snowflakePointerType) {
+		return nil
+	}
+	return append(slices.Clone(data.Index), guildID.Index...)
+}
+
+// Recover returns middleware that recovers a panicking handler and reports
+// the recovered value to report, so one faulty handler cannot take down the
+// connection. A nil report discards the panic.
+func Recover(report func(ctx context.Context, payload GatewayReceivePayload, recovered any)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, payload GatewayReceivePayload) {
+			defer func() {
+				if recovered := recover(); recovered != nil && report != nil {
+					report(ctx, payload, recovered)
+				}
+			}()
+			next(ctx, payload)
+		}
+	}
+}
+
+// Logger returns middleware that logs every handler call at debug level
+// with its payload type, guild and duration. A nil logger uses slog.Default.
+func Logger(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, payload GatewayReceivePayload) {
+			start := time.Now()
+			next(ctx, payload)
+
+			attrs := []slog.Attr{
+				slog.String("payload", fmt.Sprintf("%T", payload)),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if guildID, ok := GuildIDOf(payload); ok {
+				attrs = append(attrs, slog.String("guild_id", string(guildID)))
+			}
+			logger.LogAttrs(ctx, slog.LevelDebug, "gateway: handled payload", attrs...)
+		}
+	}
+}
+
+// GuildFilter returns middleware that only calls handlers for payloads of
+// the given guilds. Payloads that are not tied to a guild are let through.
+func GuildFilter(guildIDs ...discord.Snowflake) Middleware {
+	allowed := make(map[discord.Snowflake]bool, len(guildIDs))
+	for _, id := range guildIDs {
+		allowed[id] = true
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, payload GatewayReceivePayload) {
+			if guildID, ok := GuildIDOf(payload); ok && !allowed[guildID] {
+				return
+			}
+			next(ctx, payload)
+		}
+	}
+}
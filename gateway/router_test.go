@@ -0,0 +1,258 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// decodeFrame decodes a frame from testdata/frames.
+func decodeFrame(t *testing.T, name string) GatewayReceivePayload {
+	t.Helper()
+
+	payload, err := DecodeReceivePayload(loadFrames(t)[name])
+	if err != nil {
+		t.Fatalf("DecodeReceivePayload(%s) error = %v", name, err)
+	}
+	return payload
+}
+
+// typingIn returns a Typing Start dispatch in guildID, or outside of a guild if guildID is "".
+func typingIn(guildID discord.Snowflake, userID discord.Snowflake) TypingStartDispatch {
+	p := TypingStartDispatch{Op: OpcodeDispatch, T: string(EventTypingStart), D: TypingStartDispatchData{UserID: userID}}
+	if guildID != "" {
+		p.D.GuildID = &guildID
+	}
+	return p
+}
+
+func TestRouter_On(t *testing.T) {
+	r := NewRouter(ConcurrencyInline)
+	var calls []string
+	On(r, func(_ context.Context, m MessageCreateDispatch) { calls = append(calls, "first "+m.D.Content) })
+	On(r, func(_ context.Context, _ GatewayReceivePayload) { calls = append(calls, "any") })
+	remove := On(r, func(_ context.Context, _ MessageCreateDispatch) { calls = append(calls, "removed") })
+	On(r, func(_ context.Context, _ MessageCreateDispatch) { calls = append(calls, "last") })
+	On(r, func(_ context.Context, _ ReadyDispatch) { calls = append(calls, "ready") })
+
+	if r.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", r.Len())
+	}
+	remove()
+	remove()
+	if r.Len() != 4 {
+		t.Errorf("Len() after remove = %d, want 4", r.Len())
+	}
+
+	message := decodeFrame(t, "message_create.json").(MessageCreateDispatch)
+	r.Handle(context.Background(), message)
+
+	expected := []string{"first " + message.D.Content, "any", "last"}
+	if !slices.Equal(calls, expected) {
+		t.Errorf("calls = %q, want %q", calls, expected)
+	}
+
+	calls = nil
+	r.Handle(context.Background(), decodeFrame(t, "heartbeat_ack.json"))
+	if !slices.Equal(calls, []string{"any"}) {
+		t.Errorf("calls for HeartbeatAck = %q, want [any]", calls)
+	}
+}
+
+func TestRouter_Once(t *testing.T) {
+	r := NewRouter(ConcurrencyParallel)
+	var mu sync.Mutex
+	calls := 0
+	Once(r, func(_ context.Context, _ TypingStartDispatch) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	})
+	removed := Once(r, func(_ context.Context, _ TypingStartDispatch) { t.Error("removed one-shot handler called") })
+	removed()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Handle(context.Background(), typingIn("", "1"))
+		}()
+	}
+	wg.Wait()
+	r.Wait()
+
+	if calls != 1 {
+		t.Errorf("one-shot handler called %d times, want 1", calls)
+	}
+	if r.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", r.Len())
+	}
+}
+
+func TestRouter_Middleware(t *testing.T) {
+	r := NewRouter(ConcurrencyInline)
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, payload GatewayReceivePayload) {
+				calls = append(calls, name)
+				next(ctx, payload)
+			}
+		}
+	}
+
+	var recovered []any
+	r.Use(trace("outer"), Recover(func(_ context.Context, _ GatewayReceivePayload, v any) {
+		recovered = append(recovered, v)
+	}))
+	r.Use(trace("inner"), GuildFilter("41771983423143937"))
+
+	On(r, func(_ context.Context, _ TypingStartDispatch) { panic("boom") })
+	On(r, func(_ context.Context, p TypingStartDispatch) { calls = append(calls, "handler "+p.D.UserID.String()) })
+
+	r.Handle(context.Background(), typingIn("41771983423143937", "1"))
+	r.Handle(context.Background(), typingIn("80351110224678912", "2"))
+	r.Handle(context.Background(), typingIn("", "3"))
+
+	expected := []string{
+		"outer", "inner", "outer", "inner", "handler 1",
+		"outer", "inner", "outer", "inner",
+		"outer", "inner", "outer", "inner", "handler 3",
+	}
+	if !slices.Equal(calls, expected) {
+		t.Errorf("calls = %q, want %q", calls, expected)
+	}
+	if len(recovered) != 2 || recovered[0] != "boom" {
+		t.Errorf("recovered = %v, want two boom panics", recovered)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	r := NewRouter(ConcurrencyInline)
+	r.Use(Logger(logger))
+	On(r, func(context.Context, TypingStartDispatch) {})
+	r.Handle(context.Background(), typingIn("41771983423143937", "1"))
+
+	for _, want := range []string{"payload=gateway.TypingStartDispatch", "guild_id=41771983423143937", "duration="} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log %q does not contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestRouter_ConcurrencyPerGuild(t *testing.T) {
+	r := NewRouter(ConcurrencyPerGuild)
+	release := make(chan struct{})
+	started := make(chan discord.Snowflake, 8)
+	var mu sync.Mutex
+	var order []discord.Snowflake
+	On(r, func(_ context.Context, p TypingStartDispatch) {
+		started <- p.D.UserID
+		if p.D.UserID == "1" {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, p.D.UserID)
+		mu.Unlock()
+	})
+
+	// User 1 blocks its guild; user 2 in the same guild must wait for it,
+	// while user 3 in another guild runs meanwhile.
+	r.Handle(context.Background(), typingIn("41771983423143937", "1"))
+	r.Handle(context.Background(), typingIn("41771983423143937", "2"))
+	r.Handle(context.Background(), typingIn("80351110224678912", "3"))
+
+	got := []discord.Snowflake{<-started, <-started}
+	slices.Sort(got)
+	if !slices.Equal(got, []discord.Snowflake{"1", "3"}) {
+		t.Errorf("started %v before release, want 1 and 3", got)
+	}
+	select {
+	case id := <-started:
+		t.Errorf("user %s started while its guild was busy", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	r.Wait()
+	if i1, i2 := slices.Index(order, "1"), slices.Index(order, "2"); i1 < 0 || i2 < i1 {
+		t.Errorf("order = %v, want 1 before 2", order)
+	}
+}
+
+func TestRouter_ConcurrencyParallel(t *testing.T) {
+	r := NewRouter(ConcurrencyParallel)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	On(r, func(_ context.Context, _ TypingStartDispatch) {
+		// Both calls must be running at once for this to return.
+		wg.Done()
+		wg.Wait()
+	})
+
+	r.Handle(context.Background(), typingIn("41771983423143937", "1"))
+	r.Handle(context.Background(), typingIn("41771983423143937", "2"))
+
+	done := make(chan struct{})
+	go func() {
+		r.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handlers did not run in parallel")
+	}
+}
+
+func TestRouter_HandleShard(t *testing.T) {
+	r := NewRouter(ConcurrencyInline)
+	shardID, ok := -1, false
+	On(r, func(ctx context.Context, _ TypingStartDispatch) { shardID, ok = ShardIDFromContext(ctx) })
+
+	r.HandleShard(context.Background(), 3, typingIn("", "1"))
+	if !ok || shardID != 3 {
+		t.Errorf("ShardIDFromContext() = %d, %v, want 3, true", shardID, ok)
+	}
+	if _, ok := ShardIDFromContext(context.Background()); ok {
+		t.Error("ShardIDFromContext() without shard reported ok")
+	}
+}
+
+func TestGuildIDOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  GatewayReceivePayload
+		expected discord.Snowflake
+	}{
+		{"Guild Create", decodeFrame(t, "guild_create.json"), "41771983423143937"},
+		{"Guild Delete", GuildDeleteDispatch{D: GuildDeleteDispatchData{ID: "80351110224678912"}}, "80351110224678912"},
+		{"Message Create", decodeFrame(t, "message_create.json"), "41771983423143937"},
+		{"Thread Create", decodeFrame(t, "thread_create.json"), "41771983423143937"},
+		{"Presence Update", decodeFrame(t, "presence_update.json"), "41771983423143937"},
+		{"Typing without guild", typingIn("", "1"), ""},
+		{"Ready", decodeFrame(t, "ready.json"), ""},
+		{"Hello", decodeFrame(t, "hello.json"), ""},
+		{"Unknown dispatch", UnknownDispatch{T: "NEW_EVENT"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GuildIDOf(tt.payload)
+			if got != tt.expected || ok != (tt.expected != "") {
+				t.Errorf("GuildIDOf() = %q, %v, want %q", got, ok, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Gateway send rate limit: each connection may send this many payloads per window.
+//
+// See: https://discord.com/developers/docs/topics/gateway#rate-limiting
+const (
+	sendLimit  = 120
+	sendWindow = time.Minute
+)
+
+// SendQueue enforces the per-connection send rate limit of 120 payloads per
+// 60 seconds. Exceeding it closes the connection with CloseCodeRateLimited.
+//
+// Part of the budget is reserved for heartbeats, which also skip the queue,
+// so a busy connection can never delay its heartbeats into a zombie
+// disconnect. Other payloads are sent in the order they were queued.
+//
+// Create a new SendQueue for every connection.
+type SendQueue struct {
+	limit    int
+	reserved int
+	window   time.Duration
+
+	mu         sync.Mutex
+	sent       []time.Time
+	waiting    []uint64
+	nextTicket uint64
+	wake       chan struct{}
+}
+
+// NewSendQueue returns a queue for a connection with the given heartbeat
+// interval, reserving enough of the budget for a window's worth of heartbeats
+// plus one requested by the Gateway.
+func NewSendQueue(heartbeatInterval time.Duration) *SendQueue {
+	reserved := 2
+	if heartbeatInterval > 0 {
+		reserved = min(int(sendWindow/heartbeatInterval)+2, sendLimit/2)
+	}
+	return newSendQueue(sendLimit, reserved, sendWindow)
+}
+
+func newSendQueue(limit, reserved int, window time.Duration) *SendQueue {
+	return &SendQueue{
+		limit:    limit,
+		reserved: reserved,
+		window:   window,
+		wake:     make(chan struct{}),
+	}
+}
+
+// Wait blocks until a payload may be sent and records it against the
+// budget. Priority payloads (heartbeats) skip the queue and may use the
+// reserved part of the budget.
+func (q *SendQueue) Wait(ctx context.Context, priority bool) error {
+	q.mu.Lock()
+
+	// Non-priority payloads take a ticket and proceed in ticket order.
+	var ticket uint64
+	if !priority {
+		q.nextTicket++
+		ticket = q.nextTicket
+		q.waiting = append(q.waiting, ticket)
+	}
+
+	for {
+		now := time.Now()
+		q.expire(now)
+
+		capacity := q.limit - q.reserved
+		if priority {
+			capacity = q.limit
+		}
+
+		if len(q.sent) < capacity && (priority || q.waiting[0] == ticket) {
+			q.sent = append(q.sent, now)
+			if !priority {
+				q.waiting = q.waiting[1:]
+				q.notify()
+			}
+			q.mu.Unlock()
+			return nil
+		}
+
+		// Wait until enough sends have left the window, or until the queue
+		// changes because the head was sent or gave up.
+		var (
+			timer *time.Timer
+			retry <-chan time.Time
+		)
+		if len(q.sent) >= capacity {
+			timer = time.NewTimer(q.sent[len(q.sent)-capacity].Add(q.window).Sub(now))
+			retry = timer.C
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-retry:
+		case <-wake:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		q.mu.Lock()
+		if err != nil {
+			if !priority {
+				q.remove(ticket)
+				q.notify()
+			}
+			q.mu.Unlock()
+			return err
+		}
+	}
+}
+
+// Pending returns the number of payloads waiting in the queue.
+func (q *SendQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting)
+}
+
+// expire drops sends that have left the window.
+func (q *SendQueue) expire(now time.Time) {
+	cutoff := now.Add(-q.window)
+	i := 0
+	for i < len(q.sent) && !q.sent[i].After(cutoff) {
+		i++
+	}
+	q.sent = q.sent[i:]
+}
+
+// notify wakes every waiter so the new head of the queue can proceed.
+func (q *SendQueue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+func (q *SendQueue) remove(ticket uint64) {
+	for i, t := range q.waiting {
+		if t == ticket {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
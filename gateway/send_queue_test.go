@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewSendQueue_Reserve(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		expected int
+	}{
+		{41250 * time.Millisecond, 3},
+		{10 * time.Second, 8},
+		{0, 2},
+		{time.Millisecond, sendLimit / 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval.String(), func(t *testing.T) {
+			if got := NewSendQueue(tt.interval).reserved; got != tt.expected {
+				t.Errorf("reserved = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSendQueue_Budget(t *testing.T) {
+	q := newSendQueue(3, 1, 100*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 2 {
+		if err := q.Wait(ctx, false); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= q.window {
+		t.Fatalf("first payloads waited %v, want immediate", elapsed)
+	}
+
+	// The normal budget is spent; a heartbeat still gets the reserved slot.
+	if err := q.Wait(ctx, true); err != nil {
+		t.Fatalf("Wait(priority) error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= q.window {
+		t.Fatalf("heartbeat waited %v, want immediate", elapsed)
+	}
+
+	if err := q.Wait(ctx, false); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < q.window {
+		t.Errorf("payload over budget waited %v, want at least %v", elapsed, q.window)
+	}
+}
+
+func TestSendQueue_HeartbeatSkipsQueue(t *testing.T) {
+	q := newSendQueue(3, 1, time.Hour)
+	ctx := context.Background()
+
+	for range 2 {
+		q.Wait(ctx, false)
+	}
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- q.Wait(ctx, false) }()
+	for q.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- q.Wait(ctx, true) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait(priority) error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat was queued behind a blocked payload")
+	}
+
+	select {
+	case <-blocked:
+		t.Fatal("payload over budget was sent")
+	default:
+	}
+}
+
+func TestSendQueue_Cancel(t *testing.T) {
+	q := newSendQueue(1, 0, time.Hour)
+	q.Wait(context.Background(), false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Wait(ctx, false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := q.Pending(); got != 0 {
+		t.Errorf("Pending() after cancel = %d, want 0", got)
+	}
+}
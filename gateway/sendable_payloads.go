@@ -8,6 +8,8 @@ type GatewayPayload[T any] struct {
 	D  T             `json:"d"`
 }
 
+func (p GatewayPayload[T]) isSendPayload() {}
+
 // ResumeData represents Resume payload data.
 // See: https://discord.com/developers/docs/topics/gateway-events#resume
 type ResumeData struct {
@@ -103,10 +105,13 @@ type UpdatePresence = GatewayPayload[UpdatePresenceData]
 
 // GatewaySendPayload represents all possible sendable payloads.
 type GatewaySendPayload interface {
-	SendPayload[any]
+	isSendPayload()
 }
 
 // GatewayReceivePayload represents all possible receivable payloads.
+//
+// Use DecodeReceivePayload to turn a raw Gateway frame into one of the
+// concrete payload types in this package.
 type GatewayReceivePayload interface {
-	ReceivePayload[any]
+	isReceivePayload()
 }
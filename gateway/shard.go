@@ -0,0 +1,359 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// identifyInterval is how often each identify bucket may identify.
+const identifyInterval = 5 * time.Second
+
+var (
+	// ErrManagerRunning is returned by Manager.Run when the manager is already running.
+	ErrManagerRunning = errors.New("gateway: manager is already running")
+
+	// ErrManagerNotRunning is returned by Manager.Reshard before Run has been called.
+	ErrManagerNotRunning = errors.New("gateway: manager is not running")
+)
+
+// ShardID returns the shard that receives events for guildID when the bot
+// runs numShards shards. Invalid ids map to shard 0, which also receives
+// direct messages.
+//
+// See: https://discord.com/developers/docs/topics/gateway#sharding-sharding-formula
+func ShardID(guildID discord.Snowflake, numShards int) int {
+	id, err := strconv.ParseUint(string(guildID), 10, 64)
+	if err != nil || numShards <= 0 {
+		return 0
+	}
+	return int((id >> 22) % uint64(numShards))
+}
+
+// IdentifyLimiter gates Identify payloads so a bot stays within its session
+// start rate limit.
+type IdentifyLimiter interface {
+	// WaitIdentify blocks until shardID may identify or ctx is done.
+	WaitIdentify(ctx context.Context, shardID int) error
+}
+
+// BucketIdentifyLimiter is an IdentifyLimiter for max_concurrency identify
+// buckets. Shard shard_id belongs to bucket shard_id % max_concurrency, and
+// each bucket may identify once every 5 seconds.
+//
+// See: https://discord.com/developers/docs/topics/gateway#sharding-max-concurrency
+type BucketIdentifyLimiter struct {
+	maxConcurrency int
+	interval       time.Duration
+
+	mu   sync.Mutex
+	next map[int]time.Time
+}
+
+// NewBucketIdentifyLimiter returns a limiter for the max_concurrency value
+// from Get Gateway Bot. Values below 1 are treated as 1.
+func NewBucketIdentifyLimiter(maxConcurrency int) *BucketIdentifyLimiter {
+	return &BucketIdentifyLimiter{
+		maxConcurrency: max(maxConcurrency, 1),
+		interval:       identifyInterval,
+		next:           make(map[int]time.Time),
+	}
+}
+
+// Bucket returns the identify bucket of shardID.
+func (l *BucketIdentifyLimiter) Bucket(shardID int) int {
+	return shardID % l.maxConcurrency
+}
+
+// WaitIdentify implements IdentifyLimiter. Waiters in the same bucket are
+// served in the order they called WaitIdentify.
+func (l *BucketIdentifyLimiter) WaitIdentify(ctx context.Context, shardID int) error {
+	bucket := l.Bucket(shardID)
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next[bucket]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[bucket] = at.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ShardHandler receives every payload read by any shard of a Manager.
+type ShardHandler func(ctx context.Context, shardID int, payload GatewayReceivePayload)
+
+// ShardError is returned by Manager.Run when a shard stopped with an error
+// it cannot recover from, such as a fatal close code.
+type ShardError struct {
+	// ShardID is the shard that failed.
+	ShardID int
+
+	// Err is the error returned by the shard's Client.Run.
+	Err error
+}
+
+func (e *ShardError) Error() string {
+	return fmt.Sprintf("gateway: shard %d: %v", e.ShardID, e.Err)
+}
+
+func (e *ShardError) Unwrap() error {
+	return e.Err
+}
+
+// ShardStatus is a snapshot of one shard's connection.
+type ShardStatus struct {
+	// ID is the shard id.
+	ID int
+
+	// State is the connection state.
+	State ConnectionState
+
+	// Latency is the round trip time of the last acknowledged heartbeat.
+	Latency time.Duration
+
+	// Sequence is the last sequence number received.
+	Sequence int
+}
+
+// ManagerConfig configures a Manager.
+type ManagerConfig struct {
+	// Client is the configuration shared by every shard. Its Shard, Handler
+	// and IdentifyLimiter fields are set by the Manager.
+	Client ClientConfig
+
+	// ShardCount is the total number of shards, usually the recommended
+	// shard count from Get Gateway Bot.
+	ShardCount int
+
+	// ShardIDs are the shards run by this process. Defaults to all shards;
+	// set it to split a bot across several processes.
+	ShardIDs []int
+
+	// MaxConcurrency is the max_concurrency from Get Gateway Bot. Defaults to 1.
+	MaxConcurrency int
+
+	// Handler receives every payload read by any shard.
+	Handler ShardHandler
+}
+
+// Manager runs a set of shards, one Client each.
+//
+// Identifies are spaced according to MaxConcurrency, and the shard count can
+// be changed while running with Reshard.
+type Manager struct {
+	cfg     ManagerConfig
+	limiter *BucketIdentifyLimiter
+
+	// active is the shard group whose payloads reach the handler.
+	active atomic.Pointer[shardGroup]
+
+	mu      sync.Mutex
+	runCtx  context.Context
+	stopRun context.CancelCauseFunc
+	wg      sync.WaitGroup
+
+	reshardMu sync.Mutex
+}
+
+// shardGroup is one generation of shards sharing a shard count.
+type shardGroup struct {
+	count  int
+	ids    []int
+	shards map[int]*Client
+	cancel context.CancelFunc
+}
+
+// NewManager returns a Manager for the given configuration. Call Run to connect.
+func NewManager(cfg ManagerConfig) *Manager {
+	m := &Manager{
+		cfg:     cfg,
+		limiter: NewBucketIdentifyLimiter(cfg.MaxConcurrency),
+	}
+	m.active.Store(m.newGroup(cfg.ShardCount, cfg.ShardIDs))
+	return m
+}
+
+// newGroup creates the clients for a shard count without starting them.
+func (m *Manager) newGroup(count int, ids []int) *shardGroup {
+	if ids == nil {
+		ids = make([]int, count)
+		for i := range ids {
+			ids[i] = i
+		}
+	}
+	ids = slices.Sorted(slices.Values(ids))
+
+	g := &shardGroup{
+		count:  count,
+		ids:    ids,
+		shards: make(map[int]*Client, len(ids)),
+	}
+	for _, id := range ids {
+		cfg := m.cfg.Client
+		cfg.Shard = &[2]int{id, count}
+		cfg.IdentifyLimiter = m.limiter
+		cfg.Handler = func(ctx context.Context, payload GatewayReceivePayload) {
+			if m.cfg.Handler != nil && m.active.Load() == g {
+				m.cfg.Handler(ctx, id, payload)
+			}
+		}
+		g.shards[id] = NewClient(cfg)
+	}
+	return g
+}
+
+// start runs every shard of g until ctx is done. A shard that fails stops
+// the whole manager.
+func (m *Manager) start(ctx context.Context, g *shardGroup) {
+	ctx, g.cancel = context.WithCancel(ctx)
+	for _, id := range g.ids {
+		client := g.shards[id]
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			if err := client.Run(ctx); err != nil && ctx.Err() == nil {
+				m.stopRun(&ShardError{ShardID: id, Err: err})
+			}
+		}()
+	}
+}
+
+// Run connects every shard and keeps them running until ctx is cancelled or
+// a shard fails with an error it cannot recover from, which is returned as
+// a *ShardError.
+func (m *Manager) Run(ctx context.Context) error {
+	runCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	m.mu.Lock()
+	if m.runCtx != nil {
+		m.mu.Unlock()
+		return ErrManagerRunning
+	}
+	m.runCtx, m.stopRun = runCtx, stop
+	m.start(runCtx, m.active.Load())
+	m.mu.Unlock()
+
+	<-runCtx.Done()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	m.runCtx, m.stopRun = nil, nil
+	m.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return context.Cause(runCtx)
+}
+
+// Reshard moves a running manager to a new shard count. ids are the shards
+// this process runs afterwards, or nil for all of them.
+//
+// The new shards connect alongside the current ones while the current shards
+// keep delivering events. Once every new shard is ready, delivery switches
+// over and the old shards disconnect, so no events are dropped. Payloads the
+// new shards receive before the switch, including their READY and
+// GUILD_CREATE events, are not delivered because the old shards already
+// delivered the same state.
+//
+// If ctx is done before the new shards are ready, they are stopped and the
+// current shards keep running.
+func (m *Manager) Reshard(ctx context.Context, shardCount int, ids []int) error {
+	m.reshardMu.Lock()
+	defer m.reshardMu.Unlock()
+
+	m.mu.Lock()
+	runCtx := m.runCtx
+	if runCtx == nil {
+		m.mu.Unlock()
+		return ErrManagerNotRunning
+	}
+	next := m.newGroup(shardCount, ids)
+	m.start(runCtx, next)
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for !next.ready() {
+		select {
+		case <-ctx.Done():
+			next.cancel()
+			return ctx.Err()
+		case <-runCtx.Done():
+			return runCtx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	prev := m.active.Swap(next)
+	prev.cancel()
+	return nil
+}
+
+// ready reports whether every shard in the group is ready.
+func (g *shardGroup) ready() bool {
+	for _, client := range g.shards {
+		if client.State() != StateReady {
+			return false
+		}
+	}
+	return true
+}
+
+// ShardCount returns the total number of shards.
+func (m *Manager) ShardCount() int {
+	return m.active.Load().count
+}
+
+// Shard returns the client for shardID, or nil if this process does not run it.
+func (m *Manager) Shard(shardID int) *Client {
+	return m.active.Load().shards[shardID]
+}
+
+// ShardForGuild returns the client that receives events for guildID, or
+// nil if this process does not run that shard.
+func (m *Manager) ShardForGuild(guildID discord.Snowflake) *Client {
+	g := m.active.Load()
+	return g.shards[ShardID(guildID, g.count)]
+}
+
+// Status returns the status of every shard run by this process, ordered by id.
+// A shard whose connection dropped is not ready until it has resumed or
+// identified again.
+func (m *Manager) Status() []ShardStatus {
+	g := m.active.Load()
+	statuses := make([]ShardStatus, 0, len(g.ids))
+	for _, id := range g.ids {
+		client := g.shards[id]
+		statuses = append(statuses, ShardStatus{
+			ID:       id,
+			State:    client.State(),
+			Latency:  client.Latency(),
+			Sequence: client.Sequence(),
+		})
+	}
+	return statuses
+}
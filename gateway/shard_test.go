@@ -0,0 +1,328 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+func TestShardID(t *testing.T) {
+	tests := []struct {
+		name      string
+		guildID   discord.Snowflake
+		numShards int
+		expected  int
+	}{
+		{"Single shard", "41771983423143937", 1, 0},
+		{"Two shards", "80351110224678912", 2, 1},
+		{"Sixteen shards", "41771983423143937", 16, 6},
+		{"Thousand shards", "175928847299117063", 1000, 796},
+		{"Invalid snowflake", "not-a-snowflake", 16, 0},
+		{"No shards", "80351110224678912", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShardID(tt.guildID, tt.numShards); got != tt.expected {
+				t.Errorf("ShardID() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBucketIdentifyLimiter(t *testing.T) {
+	l := NewBucketIdentifyLimiter(2)
+	l.interval = 50 * time.Millisecond
+
+	start := time.Now()
+	waited := func(shardID int) time.Duration {
+		t.Helper()
+		if err := l.WaitIdentify(context.Background(), shardID); err != nil {
+			t.Fatalf("WaitIdentify(%d) error = %v", shardID, err)
+		}
+		return time.Since(start)
+	}
+
+	// Shards 0 and 1 are in different buckets and identify immediately;
+	// shards 2 and 4 share bucket 0 with shard 0 and must queue behind it.
+	if d := waited(0); d >= l.interval {
+		t.Errorf("shard 0 waited %v, want immediate", d)
+	}
+	if d := waited(1); d >= l.interval {
+		t.Errorf("shard 1 waited %v, want immediate", d)
+	}
+	if d := waited(2); d < l.interval {
+		t.Errorf("shard 2 waited %v, want at least %v", d, l.interval)
+	}
+	if d := waited(4); d < 2*l.interval {
+		t.Errorf("shard 4 waited %v, want at least %v", d, 2*l.interval)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.WaitIdentify(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitIdentify() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// shardEvent is a payload delivered to a ShardHandler.
+type shardEvent struct {
+	shardID int
+	payload GatewayReceivePayload
+}
+
+// runManager starts m.Run in the background and stops it when the test ends.
+func runManager(t *testing.T, m *Manager) (stop func() error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	var err error
+	go func() {
+		err = m.Run(ctx)
+		close(finished)
+	}()
+
+	stop = func() error {
+		cancel()
+		select {
+		case <-finished:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Run() did not return after cancellation")
+		}
+		return err
+	}
+	t.Cleanup(func() { stop() })
+	return stop
+}
+
+// acceptShard accepts a connection and returns it with the shard pair it identified with.
+func acceptShard(t *testing.T, g *fakeGateway) (*fakeConn, [2]int) {
+	t.Helper()
+
+	conn := g.accept()
+	conn.hello(45000)
+	var identify IdentifyData
+	if err := json.Unmarshal(conn.expect(OpcodeIdentify), &identify); err != nil {
+		t.Fatal(err)
+	}
+	if identify.Shard == nil {
+		t.Fatal("Identify without shard")
+	}
+	return conn, *identify.Shard
+}
+
+func waitShardEvent(t *testing.T, events <-chan shardEvent) shardEvent {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return shardEvent{}
+	}
+}
+
+// waitReady waits until c reaches StateReady.
+func waitReady(t *testing.T, c *Client) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.State() != StateReady {
+		if time.Now().After(deadline) {
+			t.Fatalf("State() = %v, want %v", c.State(), StateReady)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+const typingFrame = `{"op":0,"s":2,"t":"TYPING_START","d":{"channel_id":"1","user_id":"2","timestamp":1}}`
+
+func TestManager_Run(t *testing.T) {
+	g := newFakeGateway(t)
+	events := make(chan shardEvent, 16)
+	m := NewManager(ManagerConfig{
+		Client:     ClientConfig{Token: "token", URL: g.url("/")},
+		ShardCount: 2,
+		Handler: func(_ context.Context, shardID int, payload GatewayReceivePayload) {
+			if _, ok := payload.(TypingStartDispatch); ok {
+				events <- shardEvent{shardID, payload}
+			}
+		},
+	})
+	m.limiter.interval = 0
+
+	if got := m.Status(); len(got) != 2 || got[0].State != StateDisconnected {
+		t.Errorf("Status() before Run = %+v, want 2 disconnected shards", got)
+	}
+	stop := runManager(t, m)
+
+	conns := make(map[int]*fakeConn)
+	for range 2 {
+		conn, shard := acceptShard(t, g)
+		if shard[1] != 2 {
+			t.Errorf("Identify shard count = %d, want 2", shard[1])
+		}
+		conns[shard[0]] = conn
+		conn.send(readyFrame)
+	}
+	if len(conns) != 2 {
+		t.Fatalf("identified shards = %v, want 0 and 1", conns)
+	}
+
+	conns[1].send(typingFrame)
+	if e := waitShardEvent(t, events); e.shardID != 1 {
+		t.Errorf("event shard = %d, want 1", e.shardID)
+	}
+
+	waitReady(t, m.Shard(0))
+	for _, status := range m.Status() {
+		if status.State != StateReady || status.Sequence != 1+status.ID {
+			t.Errorf("Status() shard %d = %+v, want ready with sequence %d", status.ID, status, 1+status.ID)
+		}
+	}
+	if m.ShardForGuild("80351110224678912") != m.Shard(1) {
+		t.Error("ShardForGuild() did not return shard 1")
+	}
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestManager_StatusAfterDrop(t *testing.T) {
+	g := newFakeGateway(t)
+	m := NewManager(ManagerConfig{
+		Client:     ClientConfig{Token: "token", URL: g.url("/")},
+		ShardCount: 1,
+	})
+	// Hold the shard in its reconnect delay once its session is invalidated.
+	m.Shard(0).invalidSessionDelay = func() time.Duration { return time.Hour }
+	runManager(t, m)
+
+	conn, _ := acceptShard(t, g)
+	conn.send(readyFrame)
+	waitReady(t, m.Shard(0))
+	if got := m.Status(); got[0].State != StateReady {
+		t.Fatalf("Status() = %+v, want the shard ready", got)
+	}
+
+	conn.send(`{"op":9,"d":false}`)
+	select {
+	case _, ok := <-conn.frames:
+		for ok {
+			_, ok = <-conn.frames
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not dropped")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Status()[0].State == StateReady {
+		if time.Now().After(deadline) {
+			t.Fatal("Status() still reports the dropped shard as ready")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := m.Status(); got[0].State != StateDisconnected {
+		t.Errorf("Status() = %+v, want the shard disconnected", got)
+	}
+}
+
+func TestManager_FatalShard(t *testing.T) {
+	g := newFakeGateway(t)
+	m := NewManager(ManagerConfig{
+		Client:     ClientConfig{Token: "token", URL: g.url("/")},
+		ShardCount: 1,
+	})
+	stop := runManager(t, m)
+
+	conn, _ := acceptShard(t, g)
+	conn.ws.Close(4013, "Invalid intent(s).")
+
+	var shardErr *ShardError
+	var closeErr *CloseError
+	err := stop()
+	if !errors.As(err, &shardErr) || !errors.As(err, &closeErr) {
+		t.Fatalf("Run() error = %v, want *ShardError wrapping *CloseError", err)
+	}
+	if closeErr.Code != CloseCodeInvalidIntents {
+		t.Errorf("CloseError.Code = %d, want %d", closeErr.Code, CloseCodeInvalidIntents)
+	}
+}
+
+func TestManager_Reshard(t *testing.T) {
+	g := newFakeGateway(t)
+	events := make(chan shardEvent, 16)
+	m := NewManager(ManagerConfig{
+		Client:     ClientConfig{Token: "token", URL: g.url("/")},
+		ShardCount: 1,
+		Handler: func(_ context.Context, shardID int, payload GatewayReceivePayload) {
+			if _, ok := payload.(TypingStartDispatch); ok {
+				events <- shardEvent{shardID, payload}
+			}
+		},
+	})
+	m.limiter.interval = 0
+	runManager(t, m)
+
+	old, _ := acceptShard(t, g)
+	old.send(readyFrame)
+
+	resharded := make(chan error, 1)
+	go func() { resharded <- m.Reshard(context.Background(), 2, nil) }()
+
+	conns := make(map[int]*fakeConn)
+	for range 2 {
+		conn, shard := acceptShard(t, g)
+		if shard[1] != 2 {
+			t.Errorf("Identify shard count = %d, want 2", shard[1])
+		}
+		conns[shard[0]] = conn
+	}
+
+	// Until the new shards are ready, only the old shard's events are delivered.
+	conns[0].send(strings.Replace(typingFrame, `"channel_id":"1"`, `"channel_id":"99"`, 1))
+	old.send(typingFrame)
+	e := waitShardEvent(t, events)
+	if channelID := e.payload.(TypingStartDispatch).D.ChannelID; channelID != "1" || m.ShardCount() != 1 {
+		t.Errorf("event before switch from channel %s with %d shards, want the old shard", channelID, m.ShardCount())
+	}
+
+	for _, conn := range conns {
+		conn.send(readyFrame)
+	}
+	select {
+	case err := <-resharded:
+		if err != nil {
+			t.Fatalf("Reshard() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reshard() did not return")
+	}
+
+	if m.ShardCount() != 2 || len(m.Status()) != 2 {
+		t.Errorf("after Reshard ShardCount() = %d, Status() = %+v", m.ShardCount(), m.Status())
+	}
+
+	// The old connection is closed once delivery has switched.
+	select {
+	case _, ok := <-old.frames:
+		for ok {
+			_, ok = <-old.frames
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("old shard was not disconnected")
+	}
+
+	conns[1].send(typingFrame)
+	if e := waitShardEvent(t, events); e.shardID != 1 {
+		t.Errorf("event after switch from shard %d, want 1", e.shardID)
+	}
+}
@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidPayload is returned when a send payload breaks a rule Discord
+// enforces but the type system does not.
+var ErrInvalidPayload = errors.New("gateway: invalid payload")
+
+// maxNonceLength is the longest nonce Discord echoes back in Guild Members Chunk events.
+const maxNonceLength = 32
+
+// maxRequestGuildMembersUserIDs is the most user ids one Request Guild Members payload may list.
+const maxRequestGuildMembersUserIDs = 100
+
+func invalidPayload(format string, args ...any) error {
+	return fmt.Errorf("%w: "+format, append([]any{ErrInvalidPayload}, args...)...)
+}
+
+// Validate checks the Identify data before it is sent.
+func (d IdentifyData) Validate() error {
+	if d.Token == "" {
+		return invalidPayload("identify token is empty")
+	}
+	if d.LargeThreshold != nil && (*d.LargeThreshold < 50 || *d.LargeThreshold > 250) {
+		return invalidPayload("large_threshold %d is not between 50 and 250", *d.LargeThreshold)
+	}
+	if d.Shard != nil && (d.Shard[1] < 1 || d.Shard[0] < 0 || d.Shard[0] >= d.Shard[1]) {
+		return invalidPayload("shard %v is not a valid [shard_id, num_shards] pair", *d.Shard)
+	}
+	if unknown := IntentBits(d.Intents) &^ allIntents; unknown != 0 {
+		return invalidPayload("unknown intent bits %#x", int(unknown))
+	}
+	if d.Presence != nil {
+		return validatePresence(d.Presence.Since, d.Presence.Activities, d.Presence.Status)
+	}
+	return nil
+}
+
+// Validate checks the Update Presence data before it is sent.
+func (d UpdatePresenceData) Validate() error {
+	return validatePresence(d.Since, d.Activities, d.Status)
+}
+
+func validatePresence(since *int64, activities []ActivityUpdateData, status PresenceUpdateStatus) error {
+	switch status {
+	case PresenceStatusOnline, PresenceStatusDND, PresenceStatusIdle, PresenceStatusInvisible, PresenceStatusOffline:
+	default:
+		return invalidPayload("unknown presence status %q", status)
+	}
+	if since != nil && *since < 0 {
+		return invalidPayload("presence since %d is negative", *since)
+	}
+	for i, activity := range activities {
+		if activity.Name == "" {
+			return invalidPayload("activity %d has no name", i)
+		}
+		if activity.Type < ActivityTypeGame || activity.Type > ActivityTypeCompeting {
+			return invalidPayload("activity %d has unknown type %d", i, activity.Type)
+		}
+	}
+	return nil
+}
+
+// Validate checks the Voice State Update data before it is sent.
+func (d VoiceStateUpdateData) Validate() error {
+	if !d.GuildID.IsValid() {
+		return invalidPayload("guild_id %q is not a valid snowflake", d.GuildID)
+	}
+	if d.ChannelID != nil && !d.ChannelID.IsValid() {
+		return invalidPayload("channel_id %q is not a valid snowflake", *d.ChannelID)
+	}
+	return nil
+}
+
+// Validate checks the Request Guild Members data before it is sent.
+//
+// Validate cannot know the intents of the connection; use ValidateIntents
+// for the rules that depend on them.
+func (d RequestGuildMembersData) Validate() error {
+	if !d.GuildID.IsValid() {
+		return invalidPayload("guild_id %q is not a valid snowflake", d.GuildID)
+	}
+	if len(d.UserIDs) > 0 && d.Query != "" {
+		return invalidPayload("user_ids and query are mutually exclusive")
+	}
+	if len(d.UserIDs) > maxRequestGuildMembersUserIDs {
+		return invalidPayload("%d user_ids requested, at most %d are allowed", len(d.UserIDs), maxRequestGuildMembersUserIDs)
+	}
+	for _, id := range d.UserIDs {
+		if !id.IsValid() {
+			return invalidPayload("user id %q is not a valid snowflake", id)
+		}
+	}
+	if d.Limit < 0 {
+		return invalidPayload("limit %d is negative", d.Limit)
+	}
+	if d.Nonce != nil && len(*d.Nonce) > maxNonceLength {
+		return invalidPayload("nonce is %d bytes, at most %d are allowed", len(*d.Nonce), maxNonceLength)
+	}
+	return nil
+}
+
+// ValidateIntents checks the rules of Request Guild Members that depend on
+// the intents the connection identified with: requesting presences needs
+// IntentGuildPresences, and requesting every member needs IntentGuildMembers.
+func (d RequestGuildMembersData) ValidateIntents(intents IntentBits) error {
+	if d.Presences != nil && *d.Presences && !intents.Has(IntentGuildPresences) {
+		return invalidPayload("presences requires the GUILD_PRESENCES intent")
+	}
+	if len(d.UserIDs) == 0 && d.Query == "" && d.Limit == 0 && !intents.Has(IntentGuildMembers) {
+		return invalidPayload("requesting all members requires the GUILD_MEMBERS intent")
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Discord requires either query or
+// user_ids, so query and limit are always sent when no user ids are set,
+// even if they are empty.
+func (d RequestGuildMembersData) MarshalJSON() ([]byte, error) {
+	type data RequestGuildMembersData
+	if len(d.UserIDs) > 0 {
+		return json.Marshal(data(d))
+	}
+	return json.Marshal(struct {
+		data
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}{data(d), d.Query, d.Limit})
+}
+
+// Validate checks the Request Soundboard Sounds data before it is sent.
+func (d RequestSoundboardSoundsData) Validate() error {
+	if len(d.GuildIDs) == 0 {
+		return invalidPayload("guild_ids is empty")
+	}
+	for _, id := range d.GuildIDs {
+		if !id.IsValid() {
+			return invalidPayload("guild id %q is not a valid snowflake", id)
+		}
+	}
+	return nil
+}
+
+// validateSendPayload validates the data of payload, including the rules
+// that depend on the connection's intents.
+func validateSendPayload(payload GatewaySendPayload, intents IntentBits) error {
+	switch p := payload.(type) {
+	case Identify:
+		return p.D.Validate()
+	case PresenceUpdate:
+		return validatePresence(p.D.Since, p.D.Activities, p.D.Status)
+	case UpdatePresence:
+		return p.D.Validate()
+	case VoiceStateUpdate:
+		return p.D.Validate()
+	case RequestGuildMembers:
+		if err := p.D.Validate(); err != nil {
+			return err
+		}
+		return p.D.ValidateIntents(intents)
+	case RequestSoundboardSounds:
+		return p.D.Validate()
+	}
+	return nil
+}
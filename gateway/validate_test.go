@@ -0,0 +1,218 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestIdentifyData_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    IdentifyData
+		wantErr bool
+	}{
+		{"Valid", IdentifyData{Token: "token", Intents: int(IntentGuilds)}, false},
+		{"Missing token", IdentifyData{Intents: int(IntentGuilds)}, true},
+		{"Large threshold in range", IdentifyData{Token: "token", LargeThreshold: ptr(250)}, false},
+		{"Large threshold too small", IdentifyData{Token: "token", LargeThreshold: ptr(49)}, true},
+		{"Valid shard", IdentifyData{Token: "token", Shard: &[2]int{3, 4}}, false},
+		{"Shard out of range", IdentifyData{Token: "token", Shard: &[2]int{4, 4}}, true},
+		{"No shards", IdentifyData{Token: "token", Shard: &[2]int{0, 0}}, true},
+		{"Unknown intent bit", IdentifyData{Token: "token", Intents: 1 << 17}, true},
+		{
+			"Invalid presence",
+			IdentifyData{Token: "token", Presence: &PresenceUpdateData{Status: "away"}},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.data.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("Validate() error = %v, want %v", err, ErrInvalidPayload)
+			}
+		})
+	}
+}
+
+func TestUpdatePresenceData_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    UpdatePresenceData
+		wantErr bool
+	}{
+		{"Online", UpdatePresenceData{Status: PresenceStatusOnline}, false},
+		{
+			"Custom status",
+			UpdatePresenceData{Status: PresenceStatusIdle, Activities: []ActivityUpdateData{
+				{Name: "Custom Status", Type: ActivityTypeCustom, State: ptr("Busy")},
+			}},
+			false,
+		},
+		{"Unknown status", UpdatePresenceData{Status: "away"}, true},
+		{"Negative since", UpdatePresenceData{Status: PresenceStatusIdle, Since: ptr(int64(-1))}, true},
+		{
+			"Activity without name",
+			UpdatePresenceData{Status: PresenceStatusOnline, Activities: []ActivityUpdateData{{Type: ActivityTypeGame}}},
+			true,
+		},
+		{
+			"Unknown activity type",
+			UpdatePresenceData{Status: PresenceStatusOnline, Activities: []ActivityUpdateData{{Name: "x", Type: 9}}},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.data.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVoiceStateUpdateData_Validate(t *testing.T) {
+	channelID := discord.Snowflake("41771983423143937")
+	tests := []struct {
+		name    string
+		data    VoiceStateUpdateData
+		wantErr bool
+	}{
+		{"Join", VoiceStateUpdateData{GuildID: "80351110224678912", ChannelID: &channelID}, false},
+		{"Leave", VoiceStateUpdateData{GuildID: "80351110224678912"}, false},
+		{"Missing guild", VoiceStateUpdateData{ChannelID: &channelID}, true},
+		{"Invalid channel", VoiceStateUpdateData{GuildID: "80351110224678912", ChannelID: ptr(discord.Snowflake("1"))}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.data.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestGuildMembersData_Validate(t *testing.T) {
+	const guildID = discord.Snowflake("80351110224678912")
+	tests := []struct {
+		name    string
+		data    RequestGuildMembersData
+		wantErr bool
+	}{
+		{"Query", RequestGuildMembersData{GuildID: guildID, Query: "ab", Limit: 10}, false},
+		{"User ids", RequestGuildMembersData{GuildID: guildID, UserIDs: []discord.Snowflake{"41771983423143937"}}, false},
+		{
+			"User ids and query",
+			RequestGuildMembersData{GuildID: guildID, UserIDs: []discord.Snowflake{"41771983423143937"}, Query: "ab"},
+			true,
+		},
+		{"Too many user ids", RequestGuildMembersData{GuildID: guildID, UserIDs: make([]discord.Snowflake, 101)}, true},
+		{"Invalid user id", RequestGuildMembersData{GuildID: guildID, UserIDs: []discord.Snowflake{"abc"}}, true},
+		{"Negative limit", RequestGuildMembersData{GuildID: guildID, Limit: -1}, true},
+		{"Nonce at limit", RequestGuildMembersData{GuildID: guildID, Nonce: ptr(strings.Repeat("n", 32))}, false},
+		{"Nonce too long", RequestGuildMembersData{GuildID: guildID, Nonce: ptr(strings.Repeat("n", 33))}, true},
+		{"Missing guild", RequestGuildMembersData{Query: "ab"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.data.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestGuildMembersData_ValidateIntents(t *testing.T) {
+	const guildID = discord.Snowflake("80351110224678912")
+	tests := []struct {
+		name    string
+		data    RequestGuildMembersData
+		intents IntentBits
+		wantErr bool
+	}{
+		{"Query without intents", RequestGuildMembersData{GuildID: guildID, Query: "ab", Limit: 10}, 0, false},
+		{"Presences without intent", RequestGuildMembersData{GuildID: guildID, Query: "ab", Presences: ptr(true)}, 0, true},
+		{
+			"Presences with intent",
+			RequestGuildMembersData{GuildID: guildID, Query: "ab", Presences: ptr(true)},
+			IntentGuildPresences,
+			false,
+		},
+		{"All members without intent", RequestGuildMembersData{GuildID: guildID}, IntentGuilds, true},
+		{"All members with intent", RequestGuildMembersData{GuildID: guildID}, IntentGuildMembers, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.data.ValidateIntents(tt.intents); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIntents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestGuildMembersData_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     RequestGuildMembersData
+		expected string
+	}{
+		{
+			"All members",
+			RequestGuildMembersData{GuildID: "80351110224678912"},
+			`{"guild_id":"80351110224678912","query":"","limit":0}`,
+		},
+		{
+			"User ids",
+			RequestGuildMembersData{GuildID: "80351110224678912", UserIDs: []discord.Snowflake{"41771983423143937"}, Nonce: ptr("n")},
+			`{"guild_id":"80351110224678912","nonce":"n","user_ids":["41771983423143937"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.data)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Marshal() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRequestSoundboardSoundsData_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    RequestSoundboardSoundsData
+		wantErr bool
+	}{
+		{"Valid", RequestSoundboardSoundsData{GuildIDs: []discord.Snowflake{"80351110224678912"}}, false},
+		{"No guilds", RequestSoundboardSoundsData{}, true},
+		{"Invalid guild", RequestSoundboardSoundsData{GuildIDs: []discord.Snowflake{"x"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.data.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
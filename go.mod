@@ -1,3 +1,8 @@
 module github.com/kolosys/discord-types
 
 go 1.24
+
+require (
+	github.com/coder/websocket v1.8.14
+	github.com/klauspost/compress v1.18.0
+)
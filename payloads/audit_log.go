@@ -36,7 +36,7 @@ type AuditLog struct {
 // See: https://discord.com/developers/docs/resources/audit-log#audit-log-entry-object-audit-log-entry-structure
 type AuditLogEntry struct {
 	// TargetID is the id of the affected entity (webhook, user, role, etc.).
-	TargetID *string `json:"target_id"`
+	TargetID *discord.Snowflake `json:"target_id"`
 
 	// Changes are the changes made to the target_id.
 	Changes []AuditLogChange `json:"changes,omitempty"`
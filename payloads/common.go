@@ -4,6 +4,8 @@
 // guilds, channels, messages, and other API entities.
 package payloads
 
+import "time"
+
 // Common types and constants for Discord API payloads
 
 // PermissionFlagsBits represents Discord permission flags as constants.
@@ -310,6 +312,9 @@ const (
 // RESTError represents a Discord REST API error response.
 //
 // See: https://discord.com/developers/docs/topics/opcodes-and-status-codes#json
+//
+// Deprecated: Use rest.RESTError, which is returned by the REST client,
+// carries the HTTP status and flattens Errors into field errors.
 type RESTError struct {
 	Code    int            `json:"code"`
 	Message string         `json:"message"`
@@ -346,4 +351,44 @@ type RESTRateLimit struct {
 
 	// RetryAfter is the number of seconds to wait before submitting another request.
 	RetryAfter float64 `json:"retry_after"`
+
+	// The remaining fields are sent as X-RateLimit-* headers on every
+	// response of a rate limited route, not only on 429 responses.
+	//
+	// See: https://discord.com/developers/docs/topics/rate-limits#header-format
+
+	// Bucket is the unique string denoting the rate limit being encountered.
+	Bucket string `json:"-"`
+
+	// Limit is the number of requests that can be made.
+	Limit int `json:"-"`
+
+	// Remaining is the number of remaining requests that can be made.
+	Remaining int `json:"-"`
+
+	// Reset is when the rate limit resets.
+	Reset time.Time `json:"-"`
+
+	// ResetAfter is the number of seconds until the rate limit resets.
+	ResetAfter float64 `json:"-"`
+
+	// Scope is the scope of an exceeded rate limit.
+	Scope RateLimitScope `json:"-"`
 }
+
+// RateLimitScope is the scope of an exceeded rate limit.
+//
+// See: https://discord.com/developers/docs/topics/rate-limits#header-format
+type RateLimitScope string
+
+const (
+	// RateLimitScopeUser is a per-route limit of the bot or user.
+	RateLimitScopeUser RateLimitScope = "user"
+
+	// RateLimitScopeGlobal is the global limit of the bot or user.
+	RateLimitScopeGlobal RateLimitScope = "global"
+
+	// RateLimitScopeShared is a per-resource limit shared by every user.
+	// It does not count against the limits of the bot or user.
+	RateLimitScopeShared RateLimitScope = "shared"
+)
@@ -0,0 +1,40 @@
+package payloads
+
+// GatewayInfo represents the response of Get Gateway.
+//
+// See: https://discord.com/developers/docs/topics/gateway#get-gateway
+type GatewayInfo struct {
+	// URL is the WSS URL that can be used for connecting to the Gateway.
+	URL string `json:"url"`
+}
+
+// GatewayBotInfo represents the response of Get Gateway Bot.
+//
+// See: https://discord.com/developers/docs/topics/gateway#get-gateway-bot
+type GatewayBotInfo struct {
+	// URL is the WSS URL that can be used for connecting to the Gateway.
+	URL string `json:"url"`
+
+	// Shards is the recommended number of shards to use when connecting.
+	Shards int `json:"shards"`
+
+	// SessionStartLimit is information on the current session start limit.
+	SessionStartLimit SessionStartLimit `json:"session_start_limit"`
+}
+
+// SessionStartLimit represents a session start limit object.
+//
+// See: https://discord.com/developers/docs/topics/gateway#session-start-limit-object
+type SessionStartLimit struct {
+	// Total is the total number of session starts the current user is allowed.
+	Total int `json:"total"`
+
+	// Remaining is the remaining number of session starts the current user is allowed.
+	Remaining int `json:"remaining"`
+
+	// ResetAfter is the number of milliseconds after which the limit resets.
+	ResetAfter int `json:"reset_after"`
+
+	// MaxConcurrency is the number of identify requests allowed per 5 seconds.
+	MaxConcurrency int `json:"max_concurrency"`
+}
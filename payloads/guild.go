@@ -397,6 +397,100 @@ type RoleTags struct {
 	GuildConnections *bool `json:"guild_connections,omitempty"`
 }
 
+// Integration represents a guild integration.
+//
+// See: https://discord.com/developers/docs/resources/guild#integration-object
+type Integration struct {
+	// ID is the integration id.
+	ID discord.Snowflake `json:"id"`
+
+	// Name is the integration name.
+	Name string `json:"name"`
+
+	// Type is the integration type (twitch, youtube, discord, or guild_subscription).
+	Type string `json:"type"`
+
+	// Enabled is whether this integration is enabled.
+	Enabled bool `json:"enabled"`
+
+	// Syncing is whether this integration is syncing.
+	Syncing *bool `json:"syncing,omitempty"`
+
+	// RoleID is the id that this integration uses for "subscribers".
+	RoleID *discord.Snowflake `json:"role_id,omitempty"`
+
+	// EnableEmoticons is whether emoticons should be synced for this integration (twitch only currently).
+	EnableEmoticons *bool `json:"enable_emoticons,omitempty"`
+
+	// ExpireBehavior is the behavior of expiring subscribers.
+	ExpireBehavior *IntegrationExpireBehavior `json:"expire_behavior,omitempty"`
+
+	// ExpireGracePeriod is the grace period (in days) before expiring subscribers.
+	ExpireGracePeriod *int `json:"expire_grace_period,omitempty"`
+
+	// User is the user for this integration.
+	User *User `json:"user,omitempty"`
+
+	// Account is the integration account information.
+	Account IntegrationAccount `json:"account"`
+
+	// SyncedAt is when this integration was last synced.
+	SyncedAt *string `json:"synced_at,omitempty"`
+
+	// SubscriberCount is how many subscribers this integration has.
+	SubscriberCount *int `json:"subscriber_count,omitempty"`
+
+	// Revoked is whether this integration has been revoked.
+	Revoked *bool `json:"revoked,omitempty"`
+
+	// Application is the bot/OAuth2 application for discord integrations.
+	Application *IntegrationApplication `json:"application,omitempty"`
+
+	// Scopes are the scopes the application has been authorized for.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// IntegrationExpireBehavior represents the behavior of expiring integration subscribers.
+//
+// See: https://discord.com/developers/docs/resources/guild#integration-object-integration-expire-behaviors
+type IntegrationExpireBehavior int
+
+const (
+	IntegrationExpireBehaviorRemoveRole IntegrationExpireBehavior = iota
+	IntegrationExpireBehaviorKick
+)
+
+// IntegrationAccount represents an integration account.
+//
+// See: https://discord.com/developers/docs/resources/guild#integration-account-object
+type IntegrationAccount struct {
+	// ID is the id of the account.
+	ID string `json:"id"`
+
+	// Name is the name of the account.
+	Name string `json:"name"`
+}
+
+// IntegrationApplication represents an integration application.
+//
+// See: https://discord.com/developers/docs/resources/guild#integration-application-object
+type IntegrationApplication struct {
+	// ID is the id of the app.
+	ID discord.Snowflake `json:"id"`
+
+	// Name is the name of the app.
+	Name string `json:"name"`
+
+	// Icon is the icon hash of the app.
+	Icon *string `json:"icon"`
+
+	// Description is the description of the app.
+	Description string `json:"description"`
+
+	// Bot is the bot associated with this application.
+	Bot *User `json:"bot,omitempty"`
+}
+
 // GuildWelcomeScreen represents a guild welcome screen.
 //
 // See: https://discord.com/developers/docs/resources/guild#welcome-screen-object
@@ -433,7 +527,7 @@ type IncidentsData struct {
 // GuildIncident represents a guild incident.
 type GuildIncident struct {
 	// ID is the incident id.
-	ID string `json:"id"`
+	ID discord.Snowflake `json:"id"`
 
 	// Type is the incident type.
 	Type string `json:"type"`
@@ -466,7 +560,7 @@ type GuildIncident struct {
 // GuildIncidentUpdate represents a guild incident update.
 type GuildIncidentUpdate struct {
 	// ID is the update id.
-	ID string `json:"id"`
+	ID discord.Snowflake `json:"id"`
 
 	// Status is the update status.
 	Status string `json:"status"`
@@ -138,6 +138,37 @@ const (
 	ApplicationCommandTypeMessage
 )
 
+// ApplicationCommandGuildPermissions represents guild permissions for an application command
+type ApplicationCommandGuildPermissions struct {
+	// ID of the command or the application ID if this is global permissions
+	ID discord.Snowflake `json:"id"`
+	// ID of the application the command belongs to
+	ApplicationID discord.Snowflake `json:"application_id"`
+	// ID of the guild
+	GuildID discord.Snowflake `json:"guild_id"`
+	// Permissions for the command in the guild, max 100
+	Permissions []ApplicationCommandPermission `json:"permissions"`
+}
+
+// ApplicationCommandPermission represents permissions for an application command
+type ApplicationCommandPermission struct {
+	// ID of the role, user, or channel. It can also be a permission constant
+	ID discord.Snowflake `json:"id"`
+	// role, user, or channel
+	Type ApplicationCommandPermissionType `json:"type"`
+	// true to allow, false to disallow
+	Permission bool `json:"permission"`
+}
+
+// ApplicationCommandPermissionType represents the type of permission
+type ApplicationCommandPermissionType int
+
+const (
+	ApplicationCommandPermissionTypeRole    ApplicationCommandPermissionType = 1
+	ApplicationCommandPermissionTypeUser    ApplicationCommandPermissionType = 2
+	ApplicationCommandPermissionTypeChannel ApplicationCommandPermissionType = 3
+)
+
 // ApplicationCommandInteractionDataOption represents an option in command interaction data
 type ApplicationCommandInteractionDataOption struct {
 	Name    string                                    `json:"name"`
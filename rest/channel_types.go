@@ -133,13 +133,13 @@ type DeleteChannelResult = payloads.GuildTextChannel
 // GetChannelMessagesQuery represents query parameters for GET /channels/{channel.id}/messages
 type GetChannelMessagesQuery struct {
 	// Get messages around this message ID
-	Around *discord.Snowflake `json:"around,omitempty"`
+	Around *discord.Snowflake `url:"around,omitempty"`
 	// Get messages before this message ID
-	Before *discord.Snowflake `json:"before,omitempty"`
+	Before *discord.Snowflake `url:"before,omitempty"`
 	// Get messages after this message ID
-	After *discord.Snowflake `json:"after,omitempty"`
+	After *discord.Snowflake `url:"after,omitempty"`
 	// Max number of messages to return (1-100, default 50)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 }
 
 // GetChannelMessagesResult represents the response from GET /channels/{channel.id}/messages
@@ -153,6 +153,10 @@ type PostChannelMessageJSONBody struct {
 	// Can be used to verify a message was sent (up to 25 characters)
 	Nonce *string `json:"nonce,omitempty"`
 
+	// If true, a message with the same nonce sent by the same author in the
+	// last few minutes is returned instead of creating a new one
+	EnforceNonce *bool `json:"enforce_nonce,omitempty"`
+
 	// True if this is a TTS message
 	TTS *bool `json:"tts,omitempty"`
 
@@ -242,9 +246,9 @@ type DeleteChannelMessageUserReactionQuery struct {
 // GetChannelMessageReactionsQuery represents query parameters for GET /channels/{channel.id}/messages/{message.id}/reactions/{emoji}
 type GetChannelMessageReactionsQuery struct {
 	// Get users after this user ID
-	After *discord.Snowflake `json:"after,omitempty"`
+	After *discord.Snowflake `url:"after,omitempty"`
 	// Max number of users to return (1-100, default 25)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 }
 
 // GetChannelMessageReactionsResult represents the response from GET /channels/{channel.id}/messages/{message.id}/reactions/{emoji}
@@ -381,9 +385,9 @@ type PostChannelMessageThreadsResult = payloads.ThreadChannel
 // GetChannelThreadsQuery represents query parameters for GET /channels/{channel.id}/threads/archived/{type}
 type GetChannelThreadsQuery struct {
 	// Returns threads before this timestamp (ISO8601 timestamp)
-	Before *string `json:"before,omitempty"`
+	Before *string `url:"before,omitempty"`
 	// Optional maximum number of threads to return (default 100)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 }
 
 // ThreadList represents a list of threads
@@ -399,9 +403,9 @@ type GetChannelThreadsResult = ThreadList
 // GetChannelJoinedPrivateArchivedThreadsQuery represents query parameters for GET /channels/{channel.id}/users/@me/threads/archived/private
 type GetChannelJoinedPrivateArchivedThreadsQuery struct {
 	// Returns threads before this id
-	Before *discord.Snowflake `json:"before,omitempty"`
+	Before *discord.Snowflake `url:"before,omitempty"`
 	// Optional maximum number of threads to return (default 100)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 }
 
 // GetChannelJoinedPrivateArchivedThreadsResult represents the response from GET /channels/{channel.id}/users/@me/threads/archived/private
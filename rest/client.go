@@ -0,0 +1,324 @@
+// Package rest provides Discord REST API types and utilities.
+//
+// This file contains the HTTP client that sends requests to the routes
+// built by RouteBuilder.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// TokenType is the authorization scheme of a token.
+//
+// See: https://discord.com/developers/docs/reference#authentication
+type TokenType string
+
+const (
+	// TokenTypeBot authorizes as a bot user.
+	TokenTypeBot TokenType = "Bot"
+
+	// TokenTypeBearer authorizes with an OAuth2 access token.
+	TokenTypeBearer TokenType = "Bearer"
+)
+
+// UserAgent is the User-Agent sent by a Client, in the format Discord
+// requires for bots.
+//
+// See: https://discord.com/developers/docs/reference#user-agent
+var UserAgent = fmt.Sprintf("DiscordBot (https://github.com/kolosys/discord-types, %s)", discord.LibraryVersion)
+
+// ClientConfig configures a Client.
+type ClientConfig struct {
+	// Token authorizes the requests, without the "Bot " or "Bearer " prefix.
+	// Requests are sent without authorization if it is empty.
+	Token string
+
+	// TokenType is the scheme of Token. Defaults to TokenTypeBot.
+	TokenType TokenType
+
+	// BaseURL is prepended to every route. Defaults to RouteBases.API.
+	BaseURL string
+
+	// HTTPClient sends the requests. Defaults to a client without a
+	// timeout whose transport is a RateLimiter; use contexts to bound
+	// requests. A custom client should use a RateLimiter as well.
+	HTTPClient *http.Client
+
+	// UserAgent is appended to the UserAgent of the library, to identify
+	// the application.
+	UserAgent string
+
+	// Retry configures the retries of requests that fail transiently.
+	Retry RetryPolicy
+}
+
+// Client sends requests to the Discord REST API.
+//
+// A Client is safe for concurrent use.
+type Client struct {
+	authorization string
+	baseURL       string
+	httpClient    *http.Client
+	userAgent     string
+	retry         RetryPolicy
+}
+
+// NewClient returns a client configured by cfg.
+func NewClient(cfg ClientConfig) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		httpClient: cfg.HTTPClient,
+		userAgent:  UserAgent,
+		retry:      cfg.Retry.withDefaults(),
+	}
+	if c.baseURL == "" {
+		c.baseURL = RouteBases.API
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Transport: NewRateLimiter(RateLimiterConfig{})}
+	}
+	if cfg.UserAgent != "" {
+		c.userAgent += " " + cfg.UserAgent
+	}
+	if cfg.Token != "" {
+		tokenType := cfg.TokenType
+		if tokenType == "" {
+			tokenType = TokenTypeBot
+		}
+		c.authorization = string(tokenType) + " " + cfg.Token
+	}
+	return c
+}
+
+// Request is a request to a REST API route.
+type Request struct {
+	// Method is the HTTP method, such as http.MethodGet.
+	Method string
+
+	// Route is the path of the route, as returned by RouteBuilder.
+	Route string
+
+	// Query is a query struct, such as GetMessagesQuery, encoded into the
+	// query string of the request. Nil sends no query.
+	Query any
+
+	// Body is encoded as the JSON body of the request. Nil sends no body.
+	Body any
+
+	// Files are uploaded with the request. A request with files sends Body
+	// as the payload_json part of a multipart/form-data body, as described
+	// by NewMultipartBody.
+	Files []File
+
+	// MaxFileSize is the size limit of each file. Defaults to
+	// discord.MaxFileSize; use the AttachmentSizeLimit of an interaction
+	// when responding to it.
+	MaxFileSize int64
+
+	// Header holds additional headers of the request.
+	Header http.Header
+
+	// Reason is the reason of the action recorded in the audit log of the
+	// guild. It is sent URL-encoded in the X-Audit-Log-Reason header, which
+	// only the methods whose RouteMethod has AuditLogReason set accept;
+	// other methods ignore it. Reasons longer than discord.MaxReasonLength
+	// characters fail with ErrReasonTooLong.
+	Reason string
+}
+
+// ErrReasonTooLong is returned for a Request whose Reason is longer than
+// discord.MaxReasonLength characters.
+var ErrReasonTooLong = errors.New("rest: audit log reason too long")
+
+// headerAuditLogReason is the header of the reason of a request.
+const headerAuditLogReason = "X-Audit-Log-Reason"
+
+// encodeReason returns reason encoded for the X-Audit-Log-Reason header.
+// Discord decodes the header as a URL component, which lets reasons hold
+// non-ASCII text that header values cannot. Every reserved character is
+// escaped, and spaces as %20, since a literal "+" would decode to a space.
+func encodeReason(reason string) (string, error) {
+	if n := utf8.RuneCountInString(reason); n > discord.MaxReasonLength {
+		return "", fmt.Errorf("%w: %d characters, the limit is %d", ErrReasonTooLong, n, discord.MaxReasonLength)
+	}
+	return strings.ReplaceAll(url.QueryEscape(reason), "+", "%20"), nil
+}
+
+// Do sends req and decodes the response body into result, which must be a
+// pointer, or nil to discard the body. A response with an error status is
+// returned as a RESTError.
+//
+// Requests that fail transiently are retried as configured by the
+// RetryPolicy of c, until its attempts run out or ctx is done. Retries
+// that would end after the deadline of ctx are not attempted. Requests
+// with files are only retried if every file reader is an io.Seeker.
+func (c *Client) Do(ctx context.Context, req Request, result any) error {
+	rewind, replayable := rewindFiles(req.Files)
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := c.do(ctx, req, result)
+
+		retry := err != nil && replayable && attempt < c.retry.MaxAttempts && c.retry.ShouldRetry(req, err)
+		var delay time.Duration
+		if retry {
+			delay = c.retry.delay(attempt, err)
+			if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+				retry, delay = false, 0
+			}
+		}
+		if c.retry.OnAttempt != nil {
+			c.retry.OnAttempt(RetryAttempt{
+				Method:   req.Method,
+				Route:    req.Route,
+				Attempt:  attempt,
+				Err:      err,
+				Duration: time.Since(start),
+				Retry:    retry,
+				Delay:    delay,
+			})
+		}
+		if !retry {
+			return err
+		}
+		if sleep(ctx, delay) != nil || rewind() != nil {
+			return err
+		}
+	}
+}
+
+// do sends a single attempt of req.
+func (c *Client) do(ctx context.Context, req Request, result any) error {
+	httpReq, err := c.newRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("rest: %s %s: %w", req.Method, req.Route, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newRESTError(resp)
+	}
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("rest: %s %s: decode response: %w", req.Method, req.Route, err)
+	}
+	return nil
+}
+
+// Send sends req with c and returns the decoded response body, such as a
+// PostChannelMessageResult:
+//
+//	message, err := rest.Send[rest.PostChannelMessageResult](ctx, client, rest.Request{
+//		Method: http.MethodPost,
+//		Route:  rest.Routes.ChannelMessages(channelID),
+//		Body:   rest.PostChannelMessageJSONBody{Content: rest.NewString("Hello")},
+//	})
+//
+// Routes that respond with 204 No Content return the zero Result.
+func Send[Result any](ctx context.Context, c *Client, req Request) (Result, error) {
+	var result Result
+	err := c.Do(ctx, req, &result)
+	return result, err
+}
+
+func (c *Client) newRequest(ctx context.Context, req Request) (*http.Request, error) {
+	reason, err := encodeReason(req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("rest: %s %s: %w", req.Method, req.Route, err)
+	}
+
+	target := c.baseURL + req.Route
+	query, err := EncodeQuery(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("rest: %s %s: %w", req.Method, req.Route, err)
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	contentType := "application/json"
+	switch {
+	case len(req.Files) > 0:
+		multipartBody, multipartType, err := NewMultipartBody(req.Body, req.Files, req.MaxFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("rest: %s %s: %w", req.Method, req.Route, err)
+		}
+		body, contentType = multipartBody, multipartType
+	case req.Body != nil:
+		data, err := json.Marshal(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("rest: %s %s: encode body: %w", req.Method, req.Route, err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target, body)
+	if err != nil {
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("rest: %s %s: %w", req.Method, req.Route, err)
+	}
+
+	for name, values := range req.Header {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if reason != "" {
+		httpReq.Header.Set(headerAuditLogReason, reason)
+	}
+	if c.authorization != "" {
+		httpReq.Header.Set("Authorization", c.authorization)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	return httpReq, nil
+}
+
+// newRESTError reads the error response resp into a RESTError. Responses
+// without a JSON error body, such as those of proxies, carry the status
+// text as their message.
+func newRESTError(resp *http.Response) error {
+	restErr := RESTError{Status: resp.StatusCode}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if retryAfter, err := strconv.ParseFloat(resp.Header.Get(headerRetryAfter), 64); err == nil {
+			restErr.RetryAfter = seconds(retryAfter)
+		}
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || json.Unmarshal(data, &restErr) != nil || restErr.Message == "" {
+		return restErr.withStatusText()
+	}
+	return restErr
+}
+
+// withStatusText returns e with the text of its status as the message, if
+// it has none.
+func (e RESTError) withStatusText() RESTError {
+	if e.Message == "" {
+		e.Message = http.StatusText(e.Status)
+	}
+	return e
+}
@@ -0,0 +1,278 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+const (
+	channelID = discord.Snowflake("290926798999357250")
+	messageID = discord.Snowflake("334385199974967042")
+)
+
+// newTestClient returns a client for a test server running handler.
+func newTestClient(t *testing.T, cfg ClientConfig, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL + "/api/v10"
+	return NewClient(cfg)
+}
+
+func TestClient_Send(t *testing.T) {
+	var got *http.Request
+	var body PostChannelMessageJSONBody
+	c := newTestClient(t, ClientConfig{Token: "token", UserAgent: "TestBot/1.0"}, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"334385199974967042","channel_id":"290926798999357250",`+
+			`"author":{"id":"80351110224678912","username":"Nelly"},"content":"Hello","timestamp":"2017-07-11T17:27:07.299000+00:00","type":0}`)
+	})
+
+	message, err := Send[PostChannelMessageResult](context.Background(), c, Request{
+		Method: http.MethodPost,
+		Route:  Routes.ChannelMessages(channelID),
+		Body:   PostChannelMessageJSONBody{Content: NewString("Hello")},
+		Header: http.Header{"X-Test": {"1"}},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if message.ID != messageID || message.Author.Username != "Nelly" {
+		t.Errorf("Send() = %+v, want the created message", message)
+	}
+	if got.Method != http.MethodPost || got.URL.Path != "/api/v10/channels/290926798999357250/messages" {
+		t.Errorf("request = %s %s", got.Method, got.URL.Path)
+	}
+	if body.Content == nil || *body.Content != "Hello" {
+		t.Errorf("request body = %+v", body)
+	}
+	wantUserAgent := "DiscordBot (https://github.com/kolosys/discord-types, " + discord.LibraryVersion + ") TestBot/1.0"
+	headers := map[string]string{
+		"Authorization": "Bot token",
+		"User-Agent":    wantUserAgent,
+		"Content-Type":  "application/json",
+		"X-Test":        "1",
+	}
+	for name, want := range headers {
+		if got := got.Header.Get(name); got != want {
+			t.Errorf("%s header = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ClientConfig
+		req     Request
+		handler http.HandlerFunc
+		check   func(t *testing.T, r *http.Request)
+	}{
+		{
+			name: "Bearer token",
+			cfg:  ClientConfig{Token: "access", TokenType: TokenTypeBearer},
+			req:  Request{Method: http.MethodGet, Route: Routes.User("@me")},
+			check: func(t *testing.T, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer access" {
+					t.Errorf("Authorization header = %q", got)
+				}
+			},
+		},
+		{
+			name: "No token",
+			req:  Request{Method: http.MethodGet, Route: Routes.Gateway()},
+			check: func(t *testing.T, r *http.Request) {
+				if _, ok := r.Header["Authorization"]; ok {
+					t.Error("Authorization header sent without a token")
+				}
+			},
+		},
+		{
+			name: "Query",
+			req: Request{
+				Method: http.MethodGet,
+				Route:  Routes.ChannelMessages(channelID),
+				Query:  GetMessagesQuery{Before: NewSnowflake(messageID), Limit: NewLimit(50)},
+			},
+			check: func(t *testing.T, r *http.Request) {
+				if got := r.URL.Query().Get("before"); got != messageID.String() {
+					t.Errorf("before = %q", got)
+				}
+				if got := r.URL.Query().Get("limit"); got != "50" {
+					t.Errorf("limit = %q", got)
+				}
+				if r.Header.Get("Content-Type") != "" {
+					t.Error("Content-Type sent without a body")
+				}
+			},
+		},
+		{
+			name: "No content",
+			req:  Request{Method: http.MethodDelete, Route: Routes.ChannelMessage(channelID, messageID)},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			c := newTestClient(t, tt.cfg, func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				if tt.handler != nil {
+					tt.handler(w, r)
+					return
+				}
+				io.WriteString(w, `{}`)
+			})
+
+			var result map[string]any
+			if err := c.Do(context.Background(), tt.req, &result); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
+
+func TestClient_Reason(t *testing.T) {
+	tests := []struct {
+		name    string
+		reason  string
+		header  string
+		wantErr bool
+	}{
+		{name: "None"},
+		{name: "ASCII", reason: "Spam links", header: "Spam%20links"},
+		{name: "Non-ASCII", reason: "Spam 🚫 ñ/é", header: "Spam%20%F0%9F%9A%AB%20%C3%B1%2F%C3%A9"},
+		{name: "Reserved", reason: "spam + raid & más = ban: @everyone $1, ok;", header: "spam%20%2B%20raid%20%26%20m%C3%A1s%20%3D%20ban%3A%20%40everyone%20%241%2C%20ok%3B"},
+		{name: "Newline", reason: "line\nbreak", header: "line%0Abreak"},
+		{name: "Longest", reason: strings.Repeat("é", discord.MaxReasonLength), header: strings.Repeat("%C3%A9", discord.MaxReasonLength)},
+		{name: "Too long", reason: strings.Repeat("a", discord.MaxReasonLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			c := newTestClient(t, ClientConfig{Token: "token"}, func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			err := c.Do(context.Background(), Request{
+				Method: http.MethodDelete,
+				Route:  Routes.ChannelMessage(channelID, messageID),
+				Reason: tt.reason,
+			}, nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrReasonTooLong) || got != nil {
+					t.Errorf("Do() error = %v, sent = %v, want ErrReasonTooLong before sending", err, got != nil)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if header, ok := got.Header["X-Audit-Log-Reason"]; (tt.header == "") == ok || ok && header[0] != tt.header {
+				t.Errorf("X-Audit-Log-Reason = %q, want %q", header, tt.header)
+			}
+		})
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   RESTError
+	}{
+		{
+			name:   "JSON error",
+			status: http.StatusNotFound,
+			body:   `{"message":"Unknown Message","code":10008}`,
+			want:   RESTError{Status: http.StatusNotFound, Code: 10008, Message: "Unknown Message"},
+		},
+		{
+			name:   "Form errors",
+			status: http.StatusBadRequest,
+			body:   `{"code":50035,"message":"Invalid Form Body","errors":{"content":{"_errors":[{"code":"BASE_TYPE_MAX_LENGTH","message":"Must be 2000 or fewer in length."}]}}}`,
+			want: RESTError{Status: http.StatusBadRequest, Code: 50035, Message: "Invalid Form Body", Errors: []FieldError{
+				{Path: "content", Code: "BASE_TYPE_MAX_LENGTH", Message: "Must be 2000 or fewer in length."},
+			}},
+		},
+		{
+			name:   "Proxy error",
+			status: http.StatusBadGateway,
+			body:   `<html><body>502 Bad Gateway</body></html>`,
+			want:   RESTError{Status: http.StatusBadGateway, Message: "Bad Gateway"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, ClientConfig{Token: "token"}, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+
+			_, err := Send[GetChannelMessageResult](context.Background(), c, Request{
+				Method: http.MethodGet,
+				Route:  Routes.ChannelMessage(channelID, messageID),
+			})
+			var restErr RESTError
+			if !errors.As(err, &restErr) {
+				t.Fatalf("Send() error = %v, want a RESTError", err)
+			}
+			if restErr.Status != tt.want.Status || restErr.Code != tt.want.Code || restErr.Message != tt.want.Message {
+				t.Errorf("Send() error = %+v, want %+v", restErr, tt.want)
+			}
+			if !slices.Equal(restErr.Errors, tt.want.Errors) {
+				t.Errorf("Send() field errors = %+v, want %+v", restErr.Errors, tt.want.Errors)
+			}
+			if !errors.Is(err, tt.want.Code) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want.Code)
+			}
+		})
+	}
+
+	t.Run("Context", func(t *testing.T) {
+		c := newTestClient(t, ClientConfig{}, func(w http.ResponseWriter, r *http.Request) {})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := c.Do(ctx, Request{Method: http.MethodGet, Route: Routes.Gateway()}, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Do() error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		c := newTestClient(t, ClientConfig{}, func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"url":`)
+		})
+
+		_, err := Send[GetChannelMessageResult](context.Background(), c, Request{Method: http.MethodGet, Route: Routes.Gateway()})
+		if err == nil || !strings.Contains(err.Error(), "decode response") {
+			t.Errorf("Send() error = %v, want a decode error", err)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+// Package rest provides Discord REST API types and utilities.
+//
+// This file contains the error returned for REST API error responses and
+// helpers to classify it.
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RESTError represents a REST API error response.
+//
+// A RESTError matches its code with errors.Is:
+//
+//	if errors.Is(err, rest.UnknownMessage) {
+//		// The message was already deleted.
+//	}
+//
+// See: https://discord.com/developers/docs/topics/opcodes-and-status-codes#json
+type RESTError struct {
+	// Status is the HTTP status code of the response.
+	Status int `json:"-"`
+
+	// Code is the JSON error code, or GeneralError for responses without
+	// one.
+	Code RESTJSONErrorCode `json:"code"`
+
+	// Message describes the error.
+	Message string `json:"message"`
+
+	// Errors are the errors of the fields of the request, in the order
+	// Discord reported them.
+	Errors []FieldError `json:"-"`
+
+	// RetryAfter is the wait requested by the retry_after of a 429 response
+	// body, or its Retry-After header. It is zero for other responses.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FieldError is an error of a field of a request body or query, such as
+// an embed field value that is too long.
+type FieldError struct {
+	// Path is the path of the field, with the keys and indexes of the
+	// nested objects and arrays separated by dots: embeds.0.fields.2.value.
+	// It is empty for errors of the whole request.
+	Path string
+
+	// Code is the error code of the field, such as BASE_TYPE_MAX_LENGTH.
+	Code string
+
+	// Message describes the error.
+	Message string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Error implements the error interface.
+func (e RESTError) Error() string {
+	var b strings.Builder
+	b.WriteString("rest: ")
+	if e.Status != 0 {
+		b.WriteString(strconv.Itoa(e.Status) + " ")
+	}
+	b.WriteString(e.Message)
+	if e.Code != GeneralError {
+		fmt.Fprintf(&b, " (%d)", e.Code)
+	}
+	for i, fieldErr := range e.Errors {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(fieldErr.Error())
+	}
+	return b.String()
+}
+
+// Is reports whether target is the RESTJSONErrorCode of e.
+func (e RESTError) Is(target error) bool {
+	code, ok := target.(RESTJSONErrorCode)
+	return ok && code == e.Code
+}
+
+// UnmarshalJSON decodes an error response, flattening its errors tree
+// into Errors.
+func (e *RESTError) UnmarshalJSON(data []byte) error {
+	var body struct {
+		Code       RESTJSONErrorCode `json:"code"`
+		Message    string            `json:"message"`
+		Errors     json.RawMessage   `json:"errors"`
+		RetryAfter float64           `json:"retry_after"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+
+	e.Code, e.Message, e.Errors = body.Code, body.Message, nil
+	if body.RetryAfter > 0 {
+		e.RetryAfter = seconds(body.RetryAfter)
+	}
+	if len(body.Errors) == 0 || string(body.Errors) == "null" {
+		return nil
+	}
+	return flattenErrors(body.Errors, "", &e.Errors)
+}
+
+// flattenErrors appends the field errors of the errors tree data, at path,
+// to errs. The leaves of the tree are "_errors" arrays, and the other keys
+// are field names or array indexes.
+func flattenErrors(data json.RawMessage, path string, errs *[]FieldError) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("rest: errors at %q is not an object", path)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+
+		if key == "_errors" {
+			var leaves []struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := dec.Decode(&leaves); err != nil {
+				return err
+			}
+			for _, leaf := range leaves {
+				*errs = append(*errs, FieldError{Path: path, Code: leaf.Code, Message: leaf.Message})
+			}
+			continue
+		}
+
+		var child json.RawMessage
+		if err := dec.Decode(&child); err != nil {
+			return err
+		}
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		if err := flattenErrors(child, childPath, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Error implements the error interface, so that a code can be the target
+// of errors.Is.
+func (e RESTJSONErrorCode) Error() string {
+	return fmt.Sprintf("%s (%d)", e.String(), int(e))
+}
+
+// IsRetryable reports whether err is a RESTError that may succeed when
+// the request is sent again: a rate limit, a server error or an
+// overloaded resource.
+func IsRetryable(err error) bool {
+	var restErr RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	return restErr.Status == http.StatusTooManyRequests || restErr.Status >= http.StatusInternalServerError ||
+		restErr.Code == APIResourceIsCurrentlyOverloaded
+}
+
+// IsPermission reports whether err is a RESTError caused by missing access
+// or permissions.
+func IsPermission(err error) bool {
+	var restErr RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	switch restErr.Code {
+	case MissingAccess, YouLackPermissionsToPerformThatAction, MissingRequiredOAuth2Scope,
+		YouDoNotHavePermissionToSendThisSticker, CannotReplyWithoutPermissionToReadMessageHistory:
+		return true
+	}
+	return restErr.Status == http.StatusForbidden
+}
+
+// IsNotFound reports whether err is a RESTError for a resource that does
+// not exist, such as UnknownMessage.
+func IsNotFound(err error) bool {
+	var restErr RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	return restErr.Status == http.StatusNotFound || restErr.Code >= 10000 && restErr.Code < 20000
+}
@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestRESTError_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected RESTError
+	}{
+		{
+			name:     "No errors",
+			body:     `{"message":"Unknown Message","code":10008}`,
+			expected: RESTError{Code: UnknownMessage, Message: "Unknown Message"},
+		},
+		{
+			name: "Nested errors",
+			body: `{"code":50035,"message":"Invalid Form Body","errors":{` +
+				`"content":{"_errors":[{"code":"BASE_TYPE_MAX_LENGTH","message":"Must be 2000 or fewer in length."}]},` +
+				`"embeds":{"0":{"fields":{"2":{"value":{"_errors":[{"code":"BASE_TYPE_REQUIRED","message":"This field is required"}]}}}}}}}`,
+			expected: RESTError{Code: InvalidFormBodyOrContentType, Message: "Invalid Form Body", Errors: []FieldError{
+				{Path: "content", Code: "BASE_TYPE_MAX_LENGTH", Message: "Must be 2000 or fewer in length."},
+				{Path: "embeds.0.fields.2.value", Code: "BASE_TYPE_REQUIRED", Message: "This field is required"},
+			}},
+		},
+		{
+			name: "Request errors",
+			body: `{"code":50035,"message":"Invalid Form Body","errors":{"_errors":[{"code":"APPLICATION_COMMAND_TOO_LARGE","message":"Command exceeds maximum size (8000)"}]}}`,
+			expected: RESTError{Code: InvalidFormBodyOrContentType, Message: "Invalid Form Body", Errors: []FieldError{
+				{Code: "APPLICATION_COMMAND_TOO_LARGE", Message: "Command exceeds maximum size (8000)"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RESTError
+			if err := json.Unmarshal([]byte(tt.body), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.Code != tt.expected.Code || got.Message != tt.expected.Message || !slices.Equal(got.Errors, tt.expected.Errors) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRESTError_Error(t *testing.T) {
+	tests := []struct {
+		err      RESTError
+		expected string
+	}{
+		{RESTError{Status: http.StatusNotFound, Code: UnknownMessage, Message: "Unknown Message"}, "rest: 404 Unknown Message (10008)"},
+		{RESTError{Status: http.StatusBadGateway, Message: "Bad Gateway"}, "rest: 502 Bad Gateway"},
+		{
+			RESTError{Status: http.StatusBadRequest, Code: InvalidFormBodyOrContentType, Message: "Invalid Form Body", Errors: []FieldError{
+				{Path: "content", Message: "Must be 2000 or fewer in length."},
+				{Message: "Command exceeds maximum size (8000)"},
+			}},
+			"rest: 400 Invalid Form Body (50035): content: Must be 2000 or fewer in length.; Command exceeds maximum size (8000)",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.expected {
+			t.Errorf("Error() = %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+func TestRESTError_Classify(t *testing.T) {
+	tests := []struct {
+		name                            string
+		err                             error
+		retryable, permission, notFound bool
+		is                              error
+	}{
+		{
+			name:     "Unknown message",
+			err:      RESTError{Status: http.StatusNotFound, Code: UnknownMessage, Message: "Unknown Message"},
+			notFound: true,
+			is:       UnknownMessage,
+		},
+		{
+			name:       "Missing permissions",
+			err:        fmt.Errorf("delete message: %w", RESTError{Status: http.StatusForbidden, Code: YouLackPermissionsToPerformThatAction}),
+			permission: true,
+			is:         YouLackPermissionsToPerformThatAction,
+		},
+		{
+			name:      "Rate limited",
+			err:       RESTError{Status: http.StatusTooManyRequests, Message: "You are being rate limited."},
+			retryable: true,
+		},
+		{
+			name:      "Server error",
+			err:       RESTError{Status: http.StatusBadGateway, Message: "Bad Gateway"},
+			retryable: true,
+		},
+		{
+			name:      "Overloaded",
+			err:       RESTError{Status: http.StatusServiceUnavailable, Code: APIResourceIsCurrentlyOverloaded},
+			retryable: true,
+			is:        APIResourceIsCurrentlyOverloaded,
+		},
+		{
+			name: "Not a RESTError",
+			err:  errors.New("connection reset"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable() = %v", got)
+			}
+			if got := IsPermission(tt.err); got != tt.permission {
+				t.Errorf("IsPermission() = %v", got)
+			}
+			if got := IsNotFound(tt.err); got != tt.notFound {
+				t.Errorf("IsNotFound() = %v", got)
+			}
+			if tt.is != nil && !errors.Is(tt.err, tt.is) {
+				t.Errorf("errors.Is(%v) = false", tt.is)
+			}
+			if errors.Is(tt.err, UnknownChannel) {
+				t.Error("errors.Is(UnknownChannel) = true")
+			}
+		})
+	}
+}
@@ -101,7 +101,7 @@ type PostGuildMFAResult = PostGuildMFAJSONBody
 // GetGuildQuery represents query parameters for GET /guilds/{guild.id}
 type GetGuildQuery struct {
 	// When true, will return approximate member and presence counts for the guild
-	WithCounts *bool `json:"with_counts,omitempty"`
+	WithCounts *bool `url:"with_counts,omitempty"`
 }
 
 // GetGuildResult represents the response from GET /guilds/{guild.id}
@@ -239,9 +239,9 @@ type GetGuildMemberResult = payloads.GuildMember
 // GetGuildMembersQuery represents query parameters for GET /guilds/{guild.id}/members
 type GetGuildMembersQuery struct {
 	// Max number of members to return (1-1000, default 1)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 	// The highest user id in the previous page
-	After *discord.Snowflake `json:"after,omitempty"`
+	After *discord.Snowflake `url:"after,omitempty"`
 }
 
 // GetGuildMembersResult represents the response from GET /guilds/{guild.id}/members
@@ -250,9 +250,9 @@ type GetGuildMembersResult = []payloads.GuildMember
 // SearchGuildMembersQuery represents query parameters for GET /guilds/{guild.id}/members/search
 type SearchGuildMembersQuery struct {
 	// Query string to match username(s) and nickname(s) against
-	Query string `json:"query"`
+	Query string `url:"query"`
 	// Max number of members to return (1-1000, default 1)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 }
 
 // SearchGuildMembersResult represents the response from GET /guilds/{guild.id}/members/search
@@ -317,11 +317,11 @@ type DeleteGuildMemberResult struct{} // Empty response
 // GetGuildBansQuery represents query parameters for GET /guilds/{guild.id}/bans
 type GetGuildBansQuery struct {
 	// Consider only users before given user id
-	Before *discord.Snowflake `json:"before,omitempty"`
+	Before *discord.Snowflake `url:"before,omitempty"`
 	// Consider only users after given user id
-	After *discord.Snowflake `json:"after,omitempty"`
+	After *discord.Snowflake `url:"after,omitempty"`
 	// Number of users to return (1-1000, default 1000)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 }
 
 // GetGuildBansResult represents the response from GET /guilds/{guild.id}/bans
@@ -426,9 +426,9 @@ type DeleteGuildRoleResult struct{} // Empty response
 // GetGuildPruneCountQuery represents query parameters for GET /guilds/{guild.id}/prune
 type GetGuildPruneCountQuery struct {
 	// Number of days to prune (1-30, default 7)
-	Days *int `json:"days,omitempty"`
+	Days *int `url:"days,omitempty"`
 	// Role(s) to include
-	IncludeRoles []discord.Snowflake `json:"include_roles,omitempty"`
+	IncludeRoles []discord.Snowflake `url:"include_roles,omitempty"`
 }
 
 // GuildPruneCountResponse represents the response from GET /guilds/{guild.id}/prune
@@ -460,25 +460,8 @@ type GetGuildVoiceRegionsResult = []payloads.VoiceRegion
 // GetGuildInvitesResult represents the response from GET /guilds/{guild.id}/invites
 type GetGuildInvitesResult = []Invite
 
-// GuildIntegration represents a simplified guild integration
-type GuildIntegration struct {
-	ID                discord.Snowflake  `json:"id"`
-	Name              string             `json:"name"`
-	Type              string             `json:"type"`
-	Enabled           bool               `json:"enabled"`
-	Syncing           *bool              `json:"syncing,omitempty"`
-	RoleID            *discord.Snowflake `json:"role_id,omitempty"`
-	EnableEmoticons   *bool              `json:"enable_emoticons,omitempty"`
-	ExpireBehavior    *int               `json:"expire_behavior,omitempty"`
-	ExpireGracePeriod *int               `json:"expire_grace_period,omitempty"`
-	User              *payloads.User     `json:"user,omitempty"`
-	Account           interface{}        `json:"account,omitempty"`
-	SyncedAt          *string            `json:"synced_at,omitempty"`
-	SubscriberCount   *int               `json:"subscriber_count,omitempty"`
-	Revoked           *bool              `json:"revoked,omitempty"`
-	Application       interface{}        `json:"application,omitempty"`
-	Scopes            []string           `json:"scopes,omitempty"`
-}
+// GuildIntegration represents a guild integration
+type GuildIntegration = payloads.Integration
 
 // GetGuildIntegrationsResult represents the response from GET /guilds/{guild.id}/integrations
 type GetGuildIntegrationsResult = []GuildIntegration
@@ -553,7 +536,7 @@ const (
 // GetGuildWidgetImageQuery represents query parameters for GET /guilds/{guild.id}/widget.png
 type GetGuildWidgetImageQuery struct {
 	// Style of the widget image returned
-	Style *GuildWidgetStyle `json:"style,omitempty"`
+	Style *GuildWidgetStyle `url:"style,omitempty"`
 }
 
 // GuildWelcomeScreen represents a guild welcome screen
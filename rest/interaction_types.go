@@ -15,7 +15,7 @@ import (
 // GetApplicationCommandsQuery represents query parameters for GET /applications/{application.id}/commands
 type GetApplicationCommandsQuery struct {
 	// Whether to include full localization dictionaries instead of the localized fields
-	WithLocalizations *bool `json:"with_localizations,omitempty"`
+	WithLocalizations *bool `url:"with_localizations,omitempty"`
 }
 
 // GetApplicationCommandsResult represents the response from GET /applications/{application.id}/commands
@@ -92,7 +92,7 @@ type PutApplicationCommandsResult = []ApplicationCommand
 // GetGuildApplicationCommandsQuery represents query parameters for GET /applications/{application.id}/guilds/{guild.id}/commands
 type GetGuildApplicationCommandsQuery struct {
 	// Whether to include full localization dictionaries instead of the localized fields
-	WithLocalizations *bool `json:"with_localizations,omitempty"`
+	WithLocalizations *bool `url:"with_localizations,omitempty"`
 }
 
 // GetGuildApplicationCommandsResult represents the response from GET /applications/{application.id}/guilds/{guild.id}/commands
@@ -138,34 +138,18 @@ type PutApplicationCommandPermissionsJSONBody struct {
 type PutApplicationCommandPermissionsResult = ApplicationCommandGuildPermissions
 
 // ApplicationCommandGuildPermissions represents guild permissions for an application command
-type ApplicationCommandGuildPermissions struct {
-	// ID of the command or the application ID if this is global permissions
-	ID discord.Snowflake `json:"id"`
-	// ID of the application the command belongs to
-	ApplicationID discord.Snowflake `json:"application_id"`
-	// ID of the guild
-	GuildID discord.Snowflake `json:"guild_id"`
-	// Permissions for the command in the guild, max 100
-	Permissions []ApplicationCommandPermission `json:"permissions"`
-}
+type ApplicationCommandGuildPermissions = payloads.ApplicationCommandGuildPermissions
 
 // ApplicationCommandPermission represents permissions for an application command
-type ApplicationCommandPermission struct {
-	// ID of the role, user, or channel. It can also be a permission constant
-	ID discord.Snowflake `json:"id"`
-	// role, user, or channel
-	Type ApplicationCommandPermissionType `json:"type"`
-	// true to allow, false to disallow
-	Permission bool `json:"permission"`
-}
+type ApplicationCommandPermission = payloads.ApplicationCommandPermission
 
 // ApplicationCommandPermissionType represents the type of permission
-type ApplicationCommandPermissionType int
+type ApplicationCommandPermissionType = payloads.ApplicationCommandPermissionType
 
 const (
-	ApplicationCommandPermissionTypeRole    ApplicationCommandPermissionType = 1
-	ApplicationCommandPermissionTypeUser    ApplicationCommandPermissionType = 2
-	ApplicationCommandPermissionTypeChannel ApplicationCommandPermissionType = 3
+	ApplicationCommandPermissionTypeRole    = payloads.ApplicationCommandPermissionTypeRole
+	ApplicationCommandPermissionTypeUser    = payloads.ApplicationCommandPermissionTypeUser
+	ApplicationCommandPermissionTypeChannel = payloads.ApplicationCommandPermissionTypeChannel
 )
 
 // PostInteractionCallbackJSONBody represents the request body for POST /interactions/{interaction.id}/{interaction.token}/callback
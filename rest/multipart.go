@@ -0,0 +1,231 @@
+// Package rest provides Discord REST API types and utilities.
+//
+// This file contains the multipart/form-data encoding of requests that
+// upload files.
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+// ErrFileTooLarge is returned when a file exceeds the size limit of a
+// request.
+var ErrFileTooLarge = errors.New("rest: file too large")
+
+// File is a file uploaded with a request.
+//
+// See: https://discord.com/developers/docs/reference#uploading-files
+type File struct {
+	// Name is the filename of the attachment, such as "image.png".
+	Name string
+
+	// ContentType is the media type of the file. Defaults to the type of
+	// the extension of Name, or application/octet-stream.
+	ContentType string
+
+	// Reader is read for the contents of the file when the request is sent.
+	Reader io.Reader
+
+	// Description is the alt text of the attachment (up to 1024
+	// characters).
+	Description string
+
+	// Spoiler marks the attachment as a spoiler by prefixing its filename
+	// with SPOILER_.
+	Spoiler bool
+}
+
+// filename returns the filename sent for f.
+func (f File) filename() string {
+	if f.Spoiler && !strings.HasPrefix(f.Name, "SPOILER_") {
+		return "SPOILER_" + f.Name
+	}
+	return f.Name
+}
+
+func (f File) contentType() string {
+	if f.ContentType != "" {
+		return f.ContentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(f.Name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// size returns the size of the contents of f if the reader knows it, or
+// -1.
+func (f File) size() int64 {
+	switch r := f.Reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case *os.File:
+		if info, err := r.Stat(); err == nil && info.Mode().IsRegular() {
+			return info.Size()
+		}
+	}
+	return -1
+}
+
+// formBody is implemented by bodies that Discord expects as plain form
+// fields and a single "file" part rather than payload_json and files[n].
+type formBody interface {
+	formFields() [][2]string
+}
+
+// formFields returns the fields of the Create Guild Sticker form.
+func (b PostGuildStickerJSONBody) formFields() [][2]string {
+	return [][2]string{{"name", b.Name}, {"description", b.Description}, {"tags", b.Tags}}
+}
+
+// NewMultipartBody returns a multipart/form-data body uploading files with
+// payload, and its content type.
+//
+// The payload is sent as the payload_json part, and the files as the
+// files[0], files[1], ... parts. An attachment with the id of the index of
+// each file is added to the "attachments" array of the payload, unless it
+// already references that id, so that the description and filename of the
+// file are sent with it. A PostGuildStickerJSONBody is sent as form
+// fields instead, with exactly one file. The soundboard routes take their
+// sound as a data URI in the JSON body, not as a file.
+//
+// The files are streamed as the body is read, not buffered. Files larger
+// than maxFileSize fail with ErrFileTooLarge: before reading if their
+// reader knows its size, like *os.File and *bytes.Reader do, or when the
+// limit is exceeded otherwise. A maxFileSize of 0 means
+// discord.MaxFileSize; use the AttachmentSizeLimit of an interaction when
+// responding to it.
+//
+// The body must be closed if it is not read to the end.
+func NewMultipartBody(payload any, files []File, maxFileSize int64) (io.ReadCloser, string, error) {
+	if maxFileSize <= 0 {
+		maxFileSize = discord.MaxFileSize
+	}
+	for _, f := range files {
+		if size := f.size(); size > maxFileSize {
+			return nil, "", fmt.Errorf("%w: %s is %d bytes, the limit is %d", ErrFileTooLarge, f.Name, size, maxFileSize)
+		}
+	}
+
+	var fields [][2]string
+	form, isForm := payload.(formBody)
+	if isForm {
+		if len(files) != 1 {
+			return nil, "", fmt.Errorf("rest: %T takes exactly one file, got %d", payload, len(files))
+		}
+		fields = form.formFields()
+	} else {
+		payloadJSON, err := multipartPayload(payload, files)
+		if err != nil {
+			return nil, "", err
+		}
+		fields = [][2]string{{"payload_json", string(payloadJSON)}}
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files, isForm, maxFileSize))
+	}()
+	return pr, mw.FormDataContentType(), nil
+}
+
+// multipartPayload returns payload as JSON, with an attachment for each
+// file.
+func multipartPayload(payload any, files []File) ([]byte, error) {
+	object := make(map[string]json.RawMessage)
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("rest: encode payload_json: %w", err)
+		}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, fmt.Errorf("rest: payload_json must be a JSON object: %w", err)
+		}
+	}
+	if len(files) == 0 {
+		return json.Marshal(object)
+	}
+
+	var attachments []map[string]any
+	if data, ok := object["attachments"]; ok && string(data) != "null" {
+		if err := json.Unmarshal(data, &attachments); err != nil {
+			return nil, fmt.Errorf("rest: decode attachments: %w", err)
+		}
+	}
+	referenced := make(map[string]bool)
+	for _, attachment := range attachments {
+		referenced[fmt.Sprint(attachment["id"])] = true
+	}
+	for i, f := range files {
+		id := strconv.Itoa(i)
+		if referenced[id] {
+			continue
+		}
+		attachment := map[string]any{"id": id, "filename": f.filename()}
+		if f.Description != "" {
+			attachment["description"] = f.Description
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return nil, err
+	}
+	object["attachments"] = data
+	return json.Marshal(object)
+}
+
+// writeMultipart writes the fields and files to mw and closes it.
+func writeMultipart(mw *multipart.Writer, fields [][2]string, files []File, form bool, maxFileSize int64) error {
+	for _, field := range fields {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field[0]}))
+		if field[0] == "payload_json" {
+			h.Set("Content-Type", "application/json")
+		}
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, field[1]); err != nil {
+			return err
+		}
+	}
+
+	for i, f := range files {
+		name := "files[" + strconv.Itoa(i) + "]"
+		if form {
+			name = "file"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": name, "filename": f.filename()}))
+		h.Set("Content-Type", f.contentType())
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+
+		n, err := io.Copy(w, io.LimitReader(f.Reader, maxFileSize+1))
+		if err != nil {
+			return fmt.Errorf("rest: upload %s: %w", f.Name, err)
+		}
+		if n > maxFileSize {
+			return fmt.Errorf("%w: %s exceeds the limit of %d bytes", ErrFileTooLarge, f.Name, maxFileSize)
+		}
+	}
+	return mw.Close()
+}
@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+)
+
+// part is a decoded part of a multipart body.
+type part struct {
+	name, filename, contentType, content string
+}
+
+func readParts(t *testing.T, body io.Reader, contentType string) []part {
+	t.Helper()
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("ParseMediaType(%q) error = %v", contentType, err)
+	}
+	var parts []part
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		content, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatalf("read part %s: %v", p.FormName(), err)
+		}
+		parts = append(parts, part{p.FormName(), p.FileName(), p.Header.Get("Content-Type"), string(content)})
+	}
+}
+
+// unsized hides the length of a reader.
+type unsized struct{ io.Reader }
+
+func TestNewMultipartBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  any
+		files    []File
+		expected []part
+	}{
+		{
+			name:    "Message",
+			payload: PostChannelMessageJSONBody{Content: NewString("Hello")},
+			files: []File{
+				{Name: "cat.png", Reader: strings.NewReader("png"), Description: "A cat"},
+				{Name: "notes.txt", Reader: strings.NewReader("text"), Spoiler: true},
+			},
+			expected: []part{
+				{
+					name: "payload_json", contentType: "application/json",
+					content: `{"attachments":[{"description":"A cat","filename":"cat.png","id":"0"},{"filename":"SPOILER_notes.txt","id":"1"}],"content":"Hello"}`,
+				},
+				{name: "files[0]", filename: "cat.png", contentType: "image/png", content: "png"},
+				{name: "files[1]", filename: "SPOILER_notes.txt", contentType: "text/plain; charset=utf-8", content: "text"},
+			},
+		},
+		{
+			name: "Referenced attachment",
+			payload: ExecuteWebhookRequest{Attachments: []payloads.PartialAttachment{
+				{ID: "334385199974967042"},
+				{ID: "0", Description: NewString("Kept")},
+			}},
+			files: []File{{Name: "log", ContentType: "text/x-log", Reader: unsized{strings.NewReader("log")}}},
+			expected: []part{
+				{
+					name: "payload_json", contentType: "application/json",
+					content: `{"attachments":[{"id":"334385199974967042"},{"description":"Kept","id":"0"}]}`,
+				},
+				{name: "files[0]", filename: "log", contentType: "text/x-log", content: "log"},
+			},
+		},
+		{
+			name:    "Sticker",
+			payload: PostGuildStickerJSONBody{Name: "Wumpus", Description: "Waving", Tags: "wave"},
+			files:   []File{{Name: "wumpus.gif", Reader: strings.NewReader("gif")}},
+			expected: []part{
+				{name: "name", content: "Wumpus"},
+				{name: "description", content: "Waving"},
+				{name: "tags", content: "wave"},
+				{name: "file", filename: "wumpus.gif", contentType: "image/gif", content: "gif"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType, err := NewMultipartBody(tt.payload, tt.files, 0)
+			if err != nil {
+				t.Fatalf("NewMultipartBody() error = %v", err)
+			}
+			defer body.Close()
+
+			parts := readParts(t, body, contentType)
+			if len(parts) != len(tt.expected) {
+				t.Fatalf("parts = %+v, want %+v", parts, tt.expected)
+			}
+			for i, want := range tt.expected {
+				if parts[i] != want {
+					t.Errorf("part %d = %+v, want %+v", i, parts[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewMultipartBody_Limit(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 1025)
+
+	t.Run("Known size", func(t *testing.T) {
+		_, _, err := NewMultipartBody(nil, []File{{Name: "large.bin", Reader: bytes.NewReader(large)}}, 1024)
+		if !errors.Is(err, ErrFileTooLarge) {
+			t.Errorf("NewMultipartBody() error = %v, want ErrFileTooLarge", err)
+		}
+	})
+
+	t.Run("Streamed", func(t *testing.T) {
+		body, _, err := NewMultipartBody(nil, []File{{Name: "large.bin", Reader: unsized{bytes.NewReader(large)}}}, 1024)
+		if err != nil {
+			t.Fatalf("NewMultipartBody() error = %v", err)
+		}
+		defer body.Close()
+		if _, err := io.ReadAll(body); !errors.Is(err, ErrFileTooLarge) {
+			t.Errorf("reading the body error = %v, want ErrFileTooLarge", err)
+		}
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		f := File{Name: "large.bin", Reader: bytes.NewReader(make([]byte, discord.MaxFileSize+1))}
+		if _, _, err := NewMultipartBody(nil, []File{f}, 0); !errors.Is(err, ErrFileTooLarge) {
+			t.Errorf("NewMultipartBody() error = %v, want ErrFileTooLarge", err)
+		}
+	})
+
+	t.Run("Sticker without file", func(t *testing.T) {
+		if _, _, err := NewMultipartBody(PostGuildStickerJSONBody{Name: "Wumpus"}, nil, 0); err == nil {
+			t.Error("NewMultipartBody() of a sticker without a file succeeded")
+		}
+	})
+}
+
+func TestClient_Files(t *testing.T) {
+	var parts []part
+	var aborted bool
+	c := newTestClient(t, ClientConfig{Token: "token"}, func(w http.ResponseWriter, r *http.Request) {
+		if aborted {
+			// The client fails the upload partway through.
+			io.Copy(io.Discard, r.Body)
+			return
+		}
+		parts = readParts(t, r.Body, r.Header.Get("Content-Type"))
+		json.NewEncoder(w).Encode(payloads.Message{ID: messageID, ChannelID: channelID})
+	})
+
+	message, err := Send[PostChannelMessageResult](context.Background(), c, Request{
+		Method: http.MethodPost,
+		Route:  Routes.ChannelMessages(channelID),
+		Body:   PostChannelMessageJSONBody{Content: NewString("Hello")},
+		Files:  []File{{Name: "cat.png", Reader: strings.NewReader("png")}},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if message.ID != messageID {
+		t.Errorf("Send() = %+v", message)
+	}
+	if len(parts) != 2 || parts[0].name != "payload_json" || parts[1].content != "png" {
+		t.Errorf("parts = %+v", parts)
+	}
+
+	aborted = true
+	_, err = Send[PostChannelMessageResult](context.Background(), c, Request{
+		Method:      http.MethodPost,
+		Route:       Routes.ChannelMessages(channelID),
+		Files:       []File{{Name: "large.bin", Reader: unsized{strings.NewReader(strings.Repeat("x", 1025))}}},
+		MaxFileSize: 1024,
+	})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Send() of a large file error = %v, want ErrFileTooLarge", err)
+	}
+}
@@ -0,0 +1,330 @@
+// Package rest provides Discord REST API types and utilities.
+//
+// This file contains iterators over the pages of cursor-based list
+// endpoints.
+package rest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+	"github.com/kolosys/discord-types/utils"
+)
+
+// PageDirection is the direction in which a paginator moves through a
+// list.
+type PageDirection int
+
+const (
+	// PageBefore pages from the newest items to the oldest with the before
+	// parameter. It is the default of the endpoints that list the newest
+	// items first, like GetChannelMessages.
+	PageBefore PageDirection = iota + 1
+
+	// PageAfter pages from the oldest items to the newest with the after
+	// parameter. It is the default of the endpoints that list the oldest
+	// items first, like GetGuildBans, and of those that only support after,
+	// like GuildMembers.
+	PageAfter
+)
+
+// PageOptions configures a paginator.
+type PageOptions struct {
+	// Direction is the direction of the pages. Defaults to the order in
+	// which the endpoint lists items without a cursor.
+	Direction PageDirection
+
+	// Cursor is the id to start after (or before, with PageBefore),
+	// excluding it. Defaults to the newest item with PageBefore, or the
+	// oldest item with PageAfter, within Since and Until.
+	Cursor discord.Snowflake
+
+	// Limit is the maximum number of items to yield. Zero yields every item.
+	Limit int
+
+	// Since and Until bound the creation times of the items, as encoded in
+	// their ids. A zero time leaves that end unbounded.
+	Since, Until time.Time
+}
+
+// pageSpec describes a cursor-based list endpoint.
+type pageSpec[T, Result any] struct {
+	route string
+
+	// maxLimit is the largest page size of the endpoint.
+	maxLimit int
+
+	// afterOnly is set for endpoints without the before parameter.
+	afterOnly bool
+
+	// ascending is set for endpoints that list the oldest items first
+	// when given no cursor. They default to PageAfter, and PageBefore
+	// starts them from maxSnowflake.
+	ascending bool
+
+	// query returns the query of a page.
+	query func(before, after *discord.Snowflake, limit int) any
+
+	// items returns the items of a page.
+	items func(Result) []T
+
+	// id returns the cursor id of an item.
+	id func(T) discord.Snowflake
+}
+
+// paginate returns an iterator over the items of spec, fetching pages
+// with c as the iteration asks for them. Pages are sent through c, and
+// so wait for its rate limits. The iteration stops after the first
+// error, which is yielded with the zero T.
+func paginate[T, Result any](ctx context.Context, c *Client, opts PageOptions, spec pageSpec[T, Result]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		direction := opts.Direction
+		if direction == 0 {
+			direction = PageBefore
+			if spec.afterOnly || spec.ascending {
+				direction = PageAfter
+			}
+		}
+		if direction == PageBefore && spec.afterOnly {
+			yield(zero, fmt.Errorf("rest: %s can only be paginated with PageAfter", spec.route))
+			return
+		}
+
+		var since, until discord.Snowflake
+		if !opts.Since.IsZero() {
+			since = utils.SnowflakeFromTime(opts.Since)
+		}
+		if !opts.Until.IsZero() {
+			until = utils.SnowflakeFromTime(opts.Until)
+		}
+
+		cursor := opts.Cursor
+		if cursor == "" {
+			if direction == PageBefore {
+				cursor = until
+				if cursor == "" && spec.ascending {
+					cursor = maxSnowflake
+				}
+			} else if since != "" {
+				cursor = since
+			} else {
+				cursor = "0"
+			}
+		}
+
+		yielded := 0
+		for {
+			limit := spec.maxLimit
+			if opts.Limit > 0 {
+				limit = min(limit, opts.Limit-yielded)
+			}
+
+			var before, after *discord.Snowflake
+			if direction == PageBefore {
+				if cursor != "" {
+					before = NewSnowflake(cursor)
+				}
+			} else {
+				after = NewSnowflake(cursor)
+			}
+
+			result, err := Send[Result](ctx, c, Request{
+				Method: http.MethodGet,
+				Route:  spec.route,
+				Query:  spec.query(before, after, limit),
+			})
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			items := spec.items(result)
+			// Sort the page in the direction of the iteration, whatever
+			// order the endpoint returns it in.
+			slices.SortFunc(items, func(a, b T) int {
+				order := compareSnowflakes(spec.id(a), spec.id(b))
+				if direction == PageBefore {
+					return -order
+				}
+				return order
+			})
+
+			for _, item := range items {
+				id := spec.id(item)
+				if direction == PageBefore && since != "" && compareSnowflakes(id, since) < 0 ||
+					direction == PageAfter && until != "" && compareSnowflakes(id, until) > 0 {
+					return
+				}
+				if !yield(item, nil) {
+					return
+				}
+				yielded++
+				if opts.Limit > 0 && yielded >= opts.Limit {
+					return
+				}
+			}
+
+			if len(items) < limit {
+				return
+			}
+			cursor = spec.id(items[len(items)-1])
+		}
+	}
+}
+
+// maxSnowflake is the largest id Discord accepts as a cursor.
+const maxSnowflake = discord.Snowflake("9223372036854775807")
+
+// compareSnowflakes compares two ids numerically.
+func compareSnowflakes(a, b discord.Snowflake) int {
+	if c := cmp.Compare(len(a), len(b)); c != 0 {
+		return c
+	}
+	return cmp.Compare(a, b)
+}
+
+// Messages returns an iterator over the messages of a channel, newest
+// first by default.
+//
+//	for message, err := range rest.Messages(ctx, client, channelID, rest.PageOptions{Limit: 500}) {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+//
+// See: https://discord.com/developers/docs/resources/message#get-channel-messages
+func Messages(ctx context.Context, c *Client, channelID discord.Snowflake, opts PageOptions) iter.Seq2[payloads.Message, error] {
+	return paginate(ctx, c, opts, pageSpec[payloads.Message, GetChannelMessagesResult]{
+		route:    Routes.ChannelMessages(channelID),
+		maxLimit: 100,
+		query: func(before, after *discord.Snowflake, limit int) any {
+			return GetMessagesQuery{Before: before, After: after, Limit: NewLimit(limit)}
+		},
+		items: func(r GetChannelMessagesResult) []payloads.Message { return r },
+		id:    func(m payloads.Message) discord.Snowflake { return m.ID },
+	})
+}
+
+// ReactionUsers returns an iterator over the users that reacted to a
+// message with emoji, which must be URL-encoded as for
+// Routes.ChannelMessageReaction. Reactions are only paginated after.
+//
+// See: https://discord.com/developers/docs/resources/message#get-reactions
+func ReactionUsers(ctx context.Context, c *Client, channelID, messageID discord.Snowflake, emoji string, opts PageOptions) iter.Seq2[payloads.User, error] {
+	return paginate(ctx, c, opts, pageSpec[payloads.User, GetChannelMessageReactionsResult]{
+		route:     Routes.ChannelMessageReaction(channelID, messageID, emoji),
+		maxLimit:  100,
+		afterOnly: true,
+		query: func(_, after *discord.Snowflake, limit int) any {
+			return GetReactionsQuery{After: after, Limit: NewLimit(limit)}
+		},
+		items: func(r GetChannelMessageReactionsResult) []payloads.User { return r },
+		id:    func(u payloads.User) discord.Snowflake { return u.ID },
+	})
+}
+
+// Bans returns an iterator over the bans of a guild, ordered by the id of
+// the banned user, lowest first by default.
+//
+// See: https://discord.com/developers/docs/resources/guild#get-guild-bans
+func Bans(ctx context.Context, c *Client, guildID discord.Snowflake, opts PageOptions) iter.Seq2[Ban, error] {
+	return paginate(ctx, c, opts, pageSpec[Ban, GetGuildBansResult]{
+		route:     Routes.GuildBans(guildID),
+		maxLimit:  1000,
+		ascending: true,
+		query: func(before, after *discord.Snowflake, limit int) any {
+			return GetBansQuery{Before: before, After: after, Limit: NewLimit(limit)}
+		},
+		items: func(r GetGuildBansResult) []Ban { return r },
+		id:    func(b Ban) discord.Snowflake { return b.User.ID },
+	})
+}
+
+// Members returns an iterator over the members of a guild, ordered by user
+// id. Members are only paginated after, and need the GUILD_MEMBERS
+// intent.
+//
+// See: https://discord.com/developers/docs/resources/guild#list-guild-members
+func Members(ctx context.Context, c *Client, guildID discord.Snowflake, opts PageOptions) iter.Seq2[payloads.GuildMember, error] {
+	return paginate(ctx, c, opts, pageSpec[payloads.GuildMember, GetGuildMembersResult]{
+		route:     Routes.GuildMembers(guildID),
+		maxLimit:  1000,
+		afterOnly: true,
+		query: func(_, after *discord.Snowflake, limit int) any {
+			return GetGuildMembersQuery{After: after, Limit: NewLimit(limit)}
+		},
+		items: func(r GetGuildMembersResult) []payloads.GuildMember { return r },
+		id: func(m payloads.GuildMember) discord.Snowflake {
+			if m.User == nil {
+				return ""
+			}
+			return m.User.ID
+		},
+	})
+}
+
+// AuditLogEntries returns an iterator over the audit log entries of a
+// guild that match filter, newest first by default. The cursor and limit
+// fields of filter are ignored.
+//
+// See: https://discord.com/developers/docs/resources/audit-log#get-guild-audit-log
+func AuditLogEntries(ctx context.Context, c *Client, guildID discord.Snowflake, filter GetAuditLogQuery, opts PageOptions) iter.Seq2[payloads.AuditLogEntry, error] {
+	return paginate(ctx, c, opts, pageSpec[payloads.AuditLogEntry, payloads.AuditLog]{
+		route:    Routes.GuildAuditLog(guildID),
+		maxLimit: 100,
+		query: func(before, after *discord.Snowflake, limit int) any {
+			query := filter
+			query.Before, query.After, query.Limit = before, after, NewLimit(limit)
+			return query
+		},
+		items: func(r payloads.AuditLog) []payloads.AuditLogEntry { return r.AuditLogEntries },
+		id:    func(e payloads.AuditLogEntry) discord.Snowflake { return e.ID },
+	})
+}
+
+// Entitlements returns an iterator over the entitlements of an
+// application that match filter, newest first by default. The cursor and
+// limit fields of filter are ignored.
+//
+// See: https://discord.com/developers/docs/resources/entitlement#list-entitlements
+func Entitlements(ctx context.Context, c *Client, applicationID discord.Snowflake, filter GetEntitlementsQuery, opts PageOptions) iter.Seq2[payloads.Entitlement, error] {
+	return paginate(ctx, c, opts, pageSpec[payloads.Entitlement, []payloads.Entitlement]{
+		route:    Routes.Entitlements(applicationID),
+		maxLimit: 100,
+		query: func(before, after *discord.Snowflake, limit int) any {
+			query := filter
+			query.Before, query.After, query.Limit = before, after, NewLimit(limit)
+			return query
+		},
+		items: func(r []payloads.Entitlement) []payloads.Entitlement { return r },
+		id:    func(e payloads.Entitlement) discord.Snowflake { return e.ID },
+	})
+}
+
+// ScheduledEventUsers returns an iterator over the users subscribed to a
+// guild scheduled event, ordered by user id, lowest first by default,
+// with their members if withMember is set.
+//
+// See: https://discord.com/developers/docs/resources/guild-scheduled-event#get-guild-scheduled-event-users
+func ScheduledEventUsers(ctx context.Context, c *Client, guildID, eventID discord.Snowflake, withMember bool, opts PageOptions) iter.Seq2[payloads.GuildScheduledEventUser, error] {
+	return paginate(ctx, c, opts, pageSpec[payloads.GuildScheduledEventUser, []payloads.GuildScheduledEventUser]{
+		route:     Routes.GuildScheduledEventUsers(guildID, eventID),
+		maxLimit:  100,
+		ascending: true,
+		query: func(before, after *discord.Snowflake, limit int) any {
+			return GetScheduledEventUsersQuery{Before: before, After: after, Limit: NewLimit(limit), WithMember: NewBool(withMember)}
+		},
+		items: func(r []payloads.GuildScheduledEventUser) []payloads.GuildScheduledEventUser { return r },
+		id:    func(u payloads.GuildScheduledEventUser) discord.Snowflake { return u.User.ID },
+	})
+}
@@ -0,0 +1,474 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+	"github.com/kolosys/discord-types/utils"
+)
+
+// messagesEpoch is the creation time of the first test message.
+var messagesEpoch = time.Date(2017, 7, 11, 17, 0, 0, 0, time.UTC)
+
+// messageIDs returns the ids of n messages sent a minute apart, oldest
+// first.
+func messageIDs(n int) []discord.Snowflake {
+	ids := make([]discord.Snowflake, n)
+	for i := range ids {
+		ids[i] = utils.SnowflakeFromTime(messagesEpoch.Add(time.Duration(i) * time.Minute))
+	}
+	return ids
+}
+
+// newChannelClient returns a client for a channel with the messages ids,
+// which pages them like Discord: newest first, whatever the cursor. The
+// queries of the requests are appended to queries.
+func newChannelClient(t *testing.T, ids []discord.Snowflake, queries *[]GetChannelMessagesQuery) *Client {
+	t.Helper()
+
+	return newTestClient(t, ClientConfig{Token: "token"}, func(w http.ResponseWriter, r *http.Request) {
+		var query GetChannelMessagesQuery
+		if err := DecodeQuery(r.URL.Query(), &query); err != nil {
+			t.Errorf("DecodeQuery() error = %v", err)
+		}
+		*queries = append(*queries, query)
+
+		limit := 50
+		if query.Limit != nil {
+			limit = *query.Limit
+		}
+		var page []payloads.Message
+		switch {
+		case query.After != nil:
+			for _, id := range ids {
+				if compareSnowflakes(id, *query.After) > 0 && len(page) < limit {
+					page = append(page, payloads.Message{ID: id, ChannelID: channelID})
+				}
+			}
+			slices.Reverse(page)
+		default:
+			for _, id := range slices.Backward(ids) {
+				if (query.Before == nil || compareSnowflakes(id, *query.Before) < 0) && len(page) < limit {
+					page = append(page, payloads.Message{ID: id, ChannelID: channelID})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+}
+
+func TestMessages(t *testing.T) {
+	ids := messageIDs(250)
+
+	tests := []struct {
+		name     string
+		opts     PageOptions
+		expected []discord.Snowflake
+		requests int
+	}{
+		{
+			name:     "All before",
+			expected: reversed(ids),
+			requests: 3,
+		},
+		{
+			name:     "Limit",
+			opts:     PageOptions{Limit: 150},
+			expected: reversed(ids)[:150],
+			requests: 2,
+		},
+		{
+			name:     "After",
+			opts:     PageOptions{Direction: PageAfter, Limit: 120},
+			expected: ids[:120],
+			requests: 2,
+		},
+		{
+			name:     "Cursor",
+			opts:     PageOptions{Direction: PageAfter, Cursor: ids[199]},
+			expected: ids[200:],
+			requests: 1,
+		},
+		{
+			name:     "Since",
+			opts:     PageOptions{Since: messagesEpoch.Add(130 * time.Minute)},
+			expected: reversed(ids)[:120],
+			requests: 2,
+		},
+		{
+			name: "Since and until after",
+			opts: PageOptions{
+				Direction: PageAfter,
+				Since:     messagesEpoch.Add(9*time.Minute + time.Second),
+				Until:     messagesEpoch.Add(20 * time.Minute),
+			},
+			expected: ids[10:21],
+			requests: 1,
+		},
+		{
+			name:     "Until",
+			opts:     PageOptions{Until: messagesEpoch.Add(5 * time.Minute)},
+			expected: reversed(ids[:5]),
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []GetChannelMessagesQuery
+			c := newChannelClient(t, ids, &queries)
+
+			var got []discord.Snowflake
+			for message, err := range Messages(context.Background(), c, channelID, tt.opts) {
+				if err != nil {
+					t.Fatalf("Messages() error = %v", err)
+				}
+				got = append(got, message.ID)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Messages() = %d messages %v, want %d messages %v", len(got), got, len(tt.expected), tt.expected)
+			}
+			if len(queries) != tt.requests {
+				t.Errorf("requests = %d, want %d", len(queries), tt.requests)
+			}
+		})
+	}
+}
+
+func TestMessages_Break(t *testing.T) {
+	var queries []GetChannelMessagesQuery
+	c := newChannelClient(t, messageIDs(250), &queries)
+
+	n := 0
+	for _, err := range Messages(context.Background(), c, channelID, PageOptions{}) {
+		if err != nil {
+			t.Fatalf("Messages() error = %v", err)
+		}
+		if n++; n == 100 {
+			break
+		}
+	}
+	if len(queries) != 1 {
+		t.Errorf("requests = %d, want 1", len(queries))
+	}
+}
+
+func TestPaginate_Errors(t *testing.T) {
+	c := newTestClient(t, ClientConfig{Token: "token"}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(RESTError{Code: UnknownChannel, Message: "Unknown Channel"})
+	})
+
+	var errs []error
+	for _, err := range Messages(context.Background(), c, channelID, PageOptions{}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !IsNotFound(errs[0]) {
+		t.Errorf("Messages() errors = %v, want one not found error", errs)
+	}
+
+	errs = nil
+	for _, err := range Members(context.Background(), c, "41771983423143937", PageOptions{Direction: PageBefore}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] == nil {
+		t.Errorf("Members() with PageBefore errors = %v, want one error", errs)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	ids := messageIDs(1500)
+	var afters []discord.Snowflake
+	c := newTestClient(t, ClientConfig{Token: "token"}, func(w http.ResponseWriter, r *http.Request) {
+		var query GetGuildMembersQuery
+		if err := DecodeQuery(r.URL.Query(), &query); err != nil {
+			t.Errorf("DecodeQuery() error = %v", err)
+		}
+		afters = append(afters, *query.After)
+
+		var page []payloads.GuildMember
+		for _, id := range ids {
+			if compareSnowflakes(id, *query.After) > 0 && len(page) < *query.Limit {
+				page = append(page, payloads.GuildMember{User: &payloads.User{ID: id}})
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+
+	n := 0
+	for member, err := range Members(context.Background(), c, "41771983423143937", PageOptions{}) {
+		if err != nil {
+			t.Fatalf("Members() error = %v", err)
+		}
+		if member.User.ID != ids[n] {
+			t.Fatalf("member %d = %s, want %s", n, member.User.ID, ids[n])
+		}
+		n++
+	}
+	if n != len(ids) {
+		t.Errorf("Members() = %d members, want %d", n, len(ids))
+	}
+	if want := []discord.Snowflake{"0", ids[999]}; !slices.Equal(afters, want) {
+		t.Errorf("after = %v, want %v", afters, want)
+	}
+}
+
+// pageQuery holds the cursor parameters shared by the paginated endpoints.
+type pageQuery struct {
+	Before *discord.Snowflake `url:"before,omitempty"`
+	After  *discord.Snowflake `url:"after,omitempty"`
+	Limit  *int               `url:"limit,omitempty"`
+}
+
+// pagedEndpoint describes a paginated endpoint for newPagedClient.
+type pagedEndpoint struct {
+	// ascending is set for endpoints that Discord lists oldest first.
+	ascending bool
+	// encode returns the response body for a page of ids.
+	encode func(ids []discord.Snowflake) any
+	// list iterates over the ids of the endpoint.
+	list func(c *Client, opts PageOptions) iter.Seq2[discord.Snowflake, error]
+}
+
+var (
+	bansEndpoint = pagedEndpoint{
+		ascending: true,
+		encode: func(ids []discord.Snowflake) any {
+			page := GetGuildBansResult{}
+			for _, id := range ids {
+				page = append(page, Ban{User: payloads.User{ID: id}})
+			}
+			return page
+		},
+		list: func(c *Client, opts PageOptions) iter.Seq2[discord.Snowflake, error] {
+			return pageIDs(Bans(context.Background(), c, "41771983423143937", opts), func(b Ban) discord.Snowflake { return b.User.ID })
+		},
+	}
+	auditLogEndpoint = pagedEndpoint{
+		encode: func(ids []discord.Snowflake) any {
+			var page payloads.AuditLog
+			for _, id := range ids {
+				page.AuditLogEntries = append(page.AuditLogEntries, payloads.AuditLogEntry{ID: id})
+			}
+			return page
+		},
+		list: func(c *Client, opts PageOptions) iter.Seq2[discord.Snowflake, error] {
+			seq := AuditLogEntries(context.Background(), c, "41771983423143937", GetAuditLogQuery{}, opts)
+			return pageIDs(seq, func(e payloads.AuditLogEntry) discord.Snowflake { return e.ID })
+		},
+	}
+	entitlementsEndpoint = pagedEndpoint{
+		encode: func(ids []discord.Snowflake) any {
+			page := []payloads.Entitlement{}
+			for _, id := range ids {
+				page = append(page, payloads.Entitlement{ID: id})
+			}
+			return page
+		},
+		list: func(c *Client, opts PageOptions) iter.Seq2[discord.Snowflake, error] {
+			seq := Entitlements(context.Background(), c, "80351110224678912", GetEntitlementsQuery{}, opts)
+			return pageIDs(seq, func(e payloads.Entitlement) discord.Snowflake { return e.ID })
+		},
+	}
+	eventUsersEndpoint = pagedEndpoint{
+		ascending: true,
+		encode: func(ids []discord.Snowflake) any {
+			page := []payloads.GuildScheduledEventUser{}
+			for _, id := range ids {
+				page = append(page, payloads.GuildScheduledEventUser{User: payloads.User{ID: id}})
+			}
+			return page
+		},
+		list: func(c *Client, opts PageOptions) iter.Seq2[discord.Snowflake, error] {
+			seq := ScheduledEventUsers(context.Background(), c, "41771983423143937", "1095414227232723045", false, opts)
+			return pageIDs(seq, func(u payloads.GuildScheduledEventUser) discord.Snowflake { return u.User.ID })
+		},
+	}
+)
+
+// pageIDs maps an iterator over items to an iterator over their ids.
+func pageIDs[T any](seq iter.Seq2[T, error], id func(T) discord.Snowflake) iter.Seq2[discord.Snowflake, error] {
+	return func(yield func(discord.Snowflake, error) bool) {
+		for item, err := range seq {
+			if !yield(id(item), err) {
+				return
+			}
+		}
+	}
+}
+
+// newPagedClient returns a client for the endpoint with the items ids,
+// which pages them like Discord: without a cursor, ascending endpoints
+// list the oldest items first and the others the newest first. The
+// number of requests is stored in requests.
+func newPagedClient(t *testing.T, endpoint pagedEndpoint, ids []discord.Snowflake, requests *int) *Client {
+	t.Helper()
+
+	return newTestClient(t, ClientConfig{Token: "token"}, func(w http.ResponseWriter, r *http.Request) {
+		var query pageQuery
+		if err := DecodeQuery(r.URL.Query(), &query); err != nil {
+			t.Errorf("DecodeQuery() error = %v", err)
+		}
+		*requests++
+
+		var page []discord.Snowflake
+		switch {
+		case query.After != nil || query.Before == nil && endpoint.ascending:
+			for _, id := range ids {
+				if (query.After == nil || compareSnowflakes(id, *query.After) > 0) && len(page) < *query.Limit {
+					page = append(page, id)
+				}
+			}
+		default:
+			for _, id := range slices.Backward(ids) {
+				if (query.Before == nil || compareSnowflakes(id, *query.Before) < 0) && len(page) < *query.Limit {
+					page = append(page, id)
+				}
+			}
+			slices.Reverse(page)
+		}
+		if !endpoint.ascending {
+			slices.Reverse(page)
+		}
+		json.NewEncoder(w).Encode(endpoint.encode(page))
+	})
+}
+
+func TestPaginatedEndpoints(t *testing.T) {
+	ids := messageIDs(2500)
+	sinceUntil := PageOptions{
+		Since: messagesEpoch.Add(9*time.Minute + time.Second),
+		Until: messagesEpoch.Add(20 * time.Minute),
+	}
+
+	tests := []struct {
+		name     string
+		endpoint pagedEndpoint
+		opts     PageOptions
+		expected []discord.Snowflake
+		requests int
+	}{
+		{
+			name:     "Bans",
+			endpoint: bansEndpoint,
+			expected: ids,
+			requests: 3,
+		},
+		{
+			name:     "Bans limit",
+			endpoint: bansEndpoint,
+			opts:     PageOptions{Limit: 1500},
+			expected: ids[:1500],
+			requests: 2,
+		},
+		{
+			name:     "Bans since and until",
+			endpoint: bansEndpoint,
+			opts:     sinceUntil,
+			expected: ids[10:21],
+			requests: 1,
+		},
+		{
+			name:     "Bans before",
+			endpoint: bansEndpoint,
+			opts:     PageOptions{Direction: PageBefore, Limit: 1200},
+			expected: reversed(ids)[:1200],
+			requests: 2,
+		},
+		{
+			name:     "Audit log entries",
+			endpoint: auditLogEndpoint,
+			expected: reversed(ids),
+			requests: 26,
+		},
+		{
+			name:     "Audit log entries limit",
+			endpoint: auditLogEndpoint,
+			opts:     PageOptions{Limit: 250},
+			expected: reversed(ids)[:250],
+			requests: 3,
+		},
+		{
+			name:     "Audit log entries since and until",
+			endpoint: auditLogEndpoint,
+			opts:     sinceUntil,
+			expected: reversed(ids[10:20]),
+			requests: 1,
+		},
+		{
+			name:     "Entitlements",
+			endpoint: entitlementsEndpoint,
+			expected: reversed(ids),
+			requests: 26,
+		},
+		{
+			name:     "Entitlements limit",
+			endpoint: entitlementsEndpoint,
+			opts:     PageOptions{Limit: 250},
+			expected: reversed(ids)[:250],
+			requests: 3,
+		},
+		{
+			name:     "Entitlements since and until",
+			endpoint: entitlementsEndpoint,
+			opts:     sinceUntil,
+			expected: reversed(ids[10:20]),
+			requests: 1,
+		},
+		{
+			name:     "Scheduled event users",
+			endpoint: eventUsersEndpoint,
+			expected: ids,
+			requests: 26,
+		},
+		{
+			name:     "Scheduled event users limit",
+			endpoint: eventUsersEndpoint,
+			opts:     PageOptions{Limit: 250},
+			expected: ids[:250],
+			requests: 3,
+		},
+		{
+			name:     "Scheduled event users since and until",
+			endpoint: eventUsersEndpoint,
+			opts:     sinceUntil,
+			expected: ids[10:21],
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			c := newPagedClient(t, tt.endpoint, ids, &requests)
+
+			var got []discord.Snowflake
+			for id, err := range tt.endpoint.list(c, tt.opts) {
+				if err != nil {
+					t.Fatalf("list error = %v", err)
+				}
+				got = append(got, id)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("list = %d items %v, want %d items %v", len(got), got, len(tt.expected), tt.expected)
+			}
+			if requests != tt.requests {
+				t.Errorf("requests = %d, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
+
+// reversed returns a reversed copy of ids.
+func reversed(ids []discord.Snowflake) []discord.Snowflake {
+	ids = slices.Clone(ids)
+	slices.Reverse(ids)
+	return ids
+}
@@ -5,9 +5,13 @@
 package rest
 
 import (
+	"encoding"
+	"fmt"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/kolosys/discord-types/discord"
 )
@@ -118,148 +122,222 @@ type GetEntitlementsQuery struct {
 	ExcludeEnded *bool `url:"exclude_ended,omitempty"`
 }
 
-// BuildQueryString converts a query struct to a URL query string.
+// BuildQueryString converts a query struct to a URL query string, with a
+// leading "?", or "" if no parameter is set. See EncodeQuery for the
+// encoding; queries that cannot be encoded return "".
 func BuildQueryString(query interface{}) string {
-	values := url.Values{}
+	values, err := EncodeQuery(query)
+	if err != nil || len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
 
-	switch q := query.(type) {
-	case GetMessagesQuery:
-		if q.Around != nil {
-			values.Set("around", q.Around.String())
-		}
-		if q.Before != nil {
-			values.Set("before", q.Before.String())
-		}
-		if q.After != nil {
-			values.Set("after", q.After.String())
-		}
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-	case GetGuildMembersQuery:
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-		if q.After != nil {
-			values.Set("after", q.After.String())
-		}
-	case SearchGuildMembersQuery:
-		values.Set("query", q.Query)
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-	case GetReactionsQuery:
-		if q.After != nil {
-			values.Set("after", q.After.String())
-		}
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-	case GetAuditLogQuery:
-		if q.UserID != nil {
-			values.Set("user_id", q.UserID.String())
-		}
-		if q.ActionType != nil {
-			values.Set("action_type", strconv.Itoa(*q.ActionType))
-		}
-		if q.Before != nil {
-			values.Set("before", q.Before.String())
-		}
-		if q.After != nil {
-			values.Set("after", q.After.String())
-		}
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-	case GetBansQuery:
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-		if q.Before != nil {
-			values.Set("before", q.Before.String())
-		}
-		if q.After != nil {
-			values.Set("after", q.After.String())
-		}
-	case GetInvitesQuery:
-		if q.WithCounts != nil {
-			values.Set("with_counts", strconv.FormatBool(*q.WithCounts))
-		}
-		if q.WithExpiration != nil {
-			values.Set("with_expiration", strconv.FormatBool(*q.WithExpiration))
-		}
-		if q.GuildScheduledEventID != nil {
-			values.Set("guild_scheduled_event_id", q.GuildScheduledEventID.String())
-		}
-	case GetThreadsQuery:
-		if q.Before != nil {
-			values.Set("before", *q.Before)
-		}
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-	case GetScheduledEventUsersQuery:
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-		if q.WithMember != nil {
-			values.Set("with_member", strconv.FormatBool(*q.WithMember))
-		}
-		if q.Before != nil {
-			values.Set("before", q.Before.String())
-		}
-		if q.After != nil {
-			values.Set("after", q.After.String())
-		}
-	case GetGuildsQuery:
-		if q.Before != nil {
-			values.Set("before", q.Before.String())
-		}
-		if q.After != nil {
-			values.Set("after", q.After.String())
-		}
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
-		}
-		if q.WithCounts != nil {
-			values.Set("with_counts", strconv.FormatBool(*q.WithCounts))
+// EncodeQuery encodes a query struct, such as GetMessagesQuery, into URL
+// values. A nil query, or url.Values, is returned as is.
+//
+// The fields are encoded under the name in their url tag, like
+// `url:"limit,omitempty"`; fields without a tag, or tagged "-", are
+// skipped. Nil pointers are omitted, as are zero values with omitempty.
+// Strings, snowflakes, booleans, numbers and encoding.TextMarshaler
+// values are formatted as text, and slices of them as comma-separated
+// lists, as Discord expects for parameters like sku_ids.
+func EncodeQuery(query any) (url.Values, error) {
+	if values, ok := query.(url.Values); ok || query == nil {
+		return values, nil
+	}
+
+	v := reflect.ValueOf(query)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
 		}
-	case GetEntitlementsQuery:
-		if q.UserID != nil {
-			values.Set("user_id", q.UserID.String())
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("rest: query must be a struct, got %T", query)
+	}
+
+	values := url.Values{}
+	for _, field := range queryFieldsOf(v.Type()) {
+		fv := v.FieldByIndex(field.index)
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if field.omitEmpty && fv.IsZero() {
+			continue
 		}
-		if len(q.SKUIDS) > 0 {
-			var skuStrs []string
-			for _, sku := range q.SKUIDS {
-				skuStrs = append(skuStrs, sku.String())
+
+		var text string
+		if fv.Kind() == reflect.Slice && !isTextValue(fv) {
+			if field.omitEmpty && fv.Len() == 0 {
+				continue
+			}
+			elems := make([]string, fv.Len())
+			for i := range elems {
+				var err error
+				if elems[i], err = formatQueryValue(fv.Index(i)); err != nil {
+					return nil, fmt.Errorf("rest: encode query %s: %w", field.name, err)
+				}
+			}
+			text = strings.Join(elems, ",")
+		} else {
+			var err error
+			if text, err = formatQueryValue(fv); err != nil {
+				return nil, fmt.Errorf("rest: encode query %s: %w", field.name, err)
 			}
-			values.Set("sku_ids", strings.Join(skuStrs, ","))
 		}
-		if q.Before != nil {
-			values.Set("before", q.Before.String())
+		values.Set(field.name, text)
+	}
+	return values, nil
+}
+
+// DecodeQuery decodes URL values into the query struct pointed to by
+// query, the reverse of EncodeQuery. Parameters without a field are
+// ignored, and fields without a parameter are left unchanged.
+func DecodeQuery(values url.Values, query any) error {
+	v := reflect.ValueOf(query)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("rest: query must be a non-nil pointer to a struct, got %T", query)
+	}
+	v = v.Elem()
+
+	for _, field := range queryFieldsOf(v.Type()) {
+		if !values.Has(field.name) {
+			continue
 		}
-		if q.After != nil {
-			values.Set("after", q.After.String())
+		text := values.Get(field.name)
+
+		fv := v.FieldByIndex(field.index)
+		if fv.Kind() == reflect.Pointer {
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Slice && !isTextValue(fv) {
+			var elems []string
+			for _, value := range values[field.name] {
+				if value != "" {
+					elems = append(elems, strings.Split(value, ",")...)
+				}
+			}
+			slice := reflect.MakeSlice(fv.Type(), len(elems), len(elems))
+			for i, elem := range elems {
+				if err := parseQueryValue(slice.Index(i), elem); err != nil {
+					return fmt.Errorf("rest: decode query %s: %w", field.name, err)
+				}
+			}
+			fv.Set(slice)
+			continue
 		}
-		if q.Limit != nil {
-			values.Set("limit", strconv.Itoa(*q.Limit))
+		if err := parseQueryValue(fv, text); err != nil {
+			return fmt.Errorf("rest: decode query %s: %w", field.name, err)
 		}
-		if q.GuildID != nil {
-			values.Set("guild_id", q.GuildID.String())
+	}
+	return nil
+}
+
+// queryField is a field of a query struct.
+type queryField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// queryFields caches the fields of query struct types.
+var queryFields sync.Map // map[reflect.Type][]queryField
+
+func queryFieldsOf(t reflect.Type) []queryField {
+	if fields, ok := queryFields.Load(t); ok {
+		return fields.([]queryField)
+	}
+
+	var fields []queryField
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup("url")
+		if !ok || !f.IsExported() || f.Anonymous {
+			continue
 		}
-		if q.ExcludeEnded != nil {
-			values.Set("exclude_ended", strconv.FormatBool(*q.ExcludeEnded))
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
 		}
-	case CDNQuery:
-		if q.Size != nil {
-			values.Set("size", strconv.Itoa(int(*q.Size)))
+		if name == "" {
+			name = f.Name
 		}
+		fields = append(fields, queryField{name: name, index: f.Index, omitEmpty: opts == "omitempty"})
 	}
+	queryFields.Store(t, fields)
+	return fields
+}
 
-	if len(values) == 0 {
-		return ""
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// isTextValue reports whether v encodes itself as text, such as a
+// json.Number or a net.IP, rather than as a list.
+func isTextValue(v reflect.Value) bool {
+	return v.Type().Implements(textMarshalerType)
+}
+
+func formatQueryValue(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
 	}
-	return "?" + values.Encode()
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func parseQueryValue(v reflect.Value, text string) error {
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
 }
 
 // BuildURL constructs a complete URL from a base URL, route, and optional query parameters.
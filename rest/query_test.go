@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+func TestEncodeQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    any
+		expected string
+	}{
+		{
+			name:     "Snowflake and limit",
+			query:    GetChannelMessagesQuery{Before: NewSnowflake(messageID), Limit: NewLimit(50)},
+			expected: "before=334385199974967042&limit=50",
+		},
+		{
+			name:     "Pointer to query",
+			query:    &GetPollAnswerVotersQuery{After: NewSnowflake("80351110224678912")},
+			expected: "after=80351110224678912",
+		},
+		{
+			name:     "Booleans",
+			query:    GetInviteQuery{WithCounts: NewBool(true), WithExpiration: NewBool(false)},
+			expected: "with_counts=true&with_expiration=false",
+		},
+		{
+			name:     "Snowflake slice",
+			query:    GetGuildPruneCountQuery{Days: NewLimit(7), IncludeRoles: []discord.Snowflake{"41771983423143937", "81384788765712384"}},
+			expected: "days=7&include_roles=41771983423143937%2C81384788765712384",
+		},
+		{
+			name:     "Named types",
+			query:    GetGuildWidgetImageQuery{Style: &[]GuildWidgetStyle{GuildWidgetStyleBanner2}[0]},
+			expected: "style=banner2",
+		},
+		{
+			name:     "Without omitempty",
+			query:    SearchGuildMembersQuery{},
+			expected: "query=",
+		},
+		{
+			name:  "Unset",
+			query: GetCurrentUserGuildsQuery{},
+		},
+		{
+			name:  "Nil",
+			query: nil,
+		},
+		{
+			name:     "Values",
+			query:    url.Values{"with_localizations": {"true"}},
+			expected: "with_localizations=true",
+		},
+		{
+			name: "Tags and text",
+			query: struct {
+				Since    time.Time `url:"since"`
+				Skipped  string    `url:"-"`
+				Untagged string
+			}{Since: time.Date(2017, 7, 11, 17, 27, 7, 0, time.UTC), Skipped: "x", Untagged: "y"},
+			expected: "since=2017-07-11T17%3A27%3A07Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := EncodeQuery(tt.query)
+			if err != nil {
+				t.Fatalf("EncodeQuery() error = %v", err)
+			}
+			if got := values.Encode(); got != tt.expected {
+				t.Errorf("EncodeQuery() = %q, want %q", got, tt.expected)
+			}
+
+			want := ""
+			if tt.expected != "" {
+				want = "?" + tt.expected
+			}
+			if got := BuildQueryString(tt.query); got != want {
+				t.Errorf("BuildQueryString() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	if _, err := EncodeQuery(42); err == nil {
+		t.Error("EncodeQuery() of an int succeeded")
+	}
+	if _, err := EncodeQuery(struct {
+		Roles map[string]bool `url:"roles"`
+	}{Roles: map[string]bool{}}); err == nil {
+		t.Error("EncodeQuery() of a map field succeeded")
+	}
+}
+
+func TestDecodeQuery(t *testing.T) {
+	queries := []any{
+		&GetChannelMessagesQuery{Around: NewSnowflake(messageID), Limit: NewLimit(100)},
+		&GetInviteQuery{WithCounts: NewBool(true), GuildScheduledEventID: NewSnowflake("290926798999357250")},
+		&GetGuildPruneCountQuery{IncludeRoles: []discord.Snowflake{"41771983423143937", "81384788765712384"}},
+		&GetEntitlementsQuery{SKUIDS: []discord.Snowflake{"1088510058284990888"}, ExcludeEnded: NewBool(false)},
+		&SearchGuildMembersQuery{Query: "Nelly", Limit: NewLimit(1)},
+		&CDNQuery{Size: Size512},
+	}
+
+	for _, query := range queries {
+		t.Run(reflect.TypeOf(query).Elem().Name(), func(t *testing.T) {
+			values, err := EncodeQuery(query)
+			if err != nil {
+				t.Fatalf("EncodeQuery() error = %v", err)
+			}
+
+			got := reflect.New(reflect.TypeOf(query).Elem()).Interface()
+			if err := DecodeQuery(values, got); err != nil {
+				t.Fatalf("DecodeQuery(%v) error = %v", values, err)
+			}
+			if !reflect.DeepEqual(got, query) {
+				t.Errorf("DecodeQuery(%v) = %+v, want %+v", values, got, query)
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		var query GetChannelMessagesQuery
+		if err := DecodeQuery(url.Values{"limit": {"fifty"}}, &query); err == nil {
+			t.Error("DecodeQuery() of an invalid limit succeeded")
+		}
+		if err := DecodeQuery(url.Values{}, query); err == nil {
+			t.Error("DecodeQuery() into a struct value succeeded")
+		}
+	})
+}
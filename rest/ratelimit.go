@@ -0,0 +1,495 @@
+// Package rest provides Discord REST API types and utilities.
+//
+// This file contains the rate limiter that keeps requests within the
+// per-route and global rate limits.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kolosys/discord-types/payloads"
+)
+
+// DefaultGlobalLimit is the number of requests per second a bot may make
+// across all routes.
+//
+// See: https://discord.com/developers/docs/topics/rate-limits#global-rate-limit
+const DefaultGlobalLimit = 50
+
+// Rate limit headers.
+const (
+	headerRateLimitBucket     = "X-RateLimit-Bucket"
+	headerRateLimitLimit      = "X-RateLimit-Limit"
+	headerRateLimitRemaining  = "X-RateLimit-Remaining"
+	headerRateLimitReset      = "X-RateLimit-Reset"
+	headerRateLimitResetAfter = "X-RateLimit-Reset-After"
+	headerRateLimitGlobal     = "X-RateLimit-Global"
+	headerRateLimitScope      = "X-RateLimit-Scope"
+	headerRetryAfter          = "Retry-After"
+)
+
+// ParseRateLimit reads the rate limit headers of a response. It reports
+// false if the response carries none, as for routes without a limit.
+//
+// The message, code and precise retry_after of a 429 response are in its
+// body, which ParseRateLimit does not read.
+//
+// See: https://discord.com/developers/docs/topics/rate-limits#header-format
+func ParseRateLimit(h http.Header) (payloads.RESTRateLimit, bool) {
+	var rl payloads.RESTRateLimit
+	found := false
+	parse := func(name string) (float64, bool) {
+		value := h.Get(name)
+		if value == "" {
+			return 0, false
+		}
+		found = true
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	}
+
+	if rl.Bucket = h.Get(headerRateLimitBucket); rl.Bucket != "" {
+		found = true
+	}
+	if limit, ok := parse(headerRateLimitLimit); ok {
+		rl.Limit = int(limit)
+	}
+	if remaining, ok := parse(headerRateLimitRemaining); ok {
+		rl.Remaining = int(remaining)
+	}
+	if reset, ok := parse(headerRateLimitReset); ok {
+		rl.Reset = time.UnixMilli(int64(math.Round(reset * 1000)))
+	}
+	if resetAfter, ok := parse(headerRateLimitResetAfter); ok {
+		rl.ResetAfter = resetAfter
+	}
+	if retryAfter, ok := parse(headerRetryAfter); ok {
+		rl.RetryAfter = retryAfter
+	}
+	if global := h.Get(headerRateLimitGlobal); global != "" {
+		found = true
+		rl.Global = global == "true"
+	}
+	if scope := h.Get(headerRateLimitScope); scope != "" {
+		found = true
+		rl.Scope = payloads.RateLimitScope(scope)
+	}
+	return rl, found
+}
+
+// RateLimiterConfig configures a RateLimiter.
+type RateLimiterConfig struct {
+	// Transport sends the requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// GlobalLimit is the number of requests per second allowed across all
+	// routes. Defaults to DefaultGlobalLimit; large bots are granted more.
+	GlobalLimit int
+}
+
+// RateLimiter is an http.RoundTripper that delays requests to stay within
+// Discord's rate limits:
+//
+//   - Per-route limits, tracked in buckets keyed by the route and its major
+//     parameter (channel, guild, or webhook id and token). Routes that
+//     report the same X-RateLimit-Bucket hash share a bucket once the hash
+//     is learned from a response.
+//   - The global limit of GlobalLimit requests per second, which interaction
+//     callbacks are exempt from.
+//   - The wait requested by a 429 response, for its bucket or, for a global
+//     429, for every request. Shared-scope 429s block the bucket for the
+//     wait without counting against its limit.
+//
+// Requests waiting for the same bucket are sent in the order they arrived.
+// A 429 response is still returned to the caller; retrying it through the
+// limiter waits as long as Discord asked.
+//
+// Limits are per token, so a RateLimiter must not be shared by clients
+// with different tokens.
+//
+// See: https://discord.com/developers/docs/topics/rate-limits
+type RateLimiter struct {
+	transport    http.RoundTripper
+	globalLimit  int
+	globalWindow time.Duration
+
+	mu          sync.Mutex
+	hashes      map[string]string
+	buckets     map[string]*bucket
+	globalSent  []time.Time
+	globalUntil time.Time
+	globalQueue fifo
+	lastSweep   time.Time
+	wake        chan struct{}
+}
+
+// bucket is the state of one rate limit.
+type bucket struct {
+	limit     int // 0 while unknown
+	remaining int
+	resetAt   time.Time
+	until     time.Time // requested by a 429
+	inflight  int
+	queue     fifo
+}
+
+// fifo hands out tickets so waiters proceed in arrival order.
+type fifo struct {
+	waiting []uint64
+	next    uint64
+}
+
+func (q *fifo) enqueue() uint64 {
+	q.next++
+	q.waiting = append(q.waiting, q.next)
+	return q.next
+}
+
+func (q *fifo) head() uint64 {
+	if len(q.waiting) == 0 {
+		return 0
+	}
+	return q.waiting[0]
+}
+
+func (q *fifo) remove(ticket uint64) {
+	for i, t := range q.waiting {
+		if t == ticket {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// NewRateLimiter returns a rate limiter that knows no limits yet.
+func NewRateLimiter(cfg RateLimiterConfig) *RateLimiter {
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+	if cfg.GlobalLimit <= 0 {
+		cfg.GlobalLimit = DefaultGlobalLimit
+	}
+	return &RateLimiter{
+		transport:    cfg.Transport,
+		globalLimit:  cfg.GlobalLimit,
+		globalWindow: time.Second,
+		hashes:       make(map[string]string),
+		buckets:      make(map[string]*bucket),
+		wake:         make(chan struct{}),
+	}
+}
+
+// RoundTrip implements http.RoundTripper. It waits until the request may
+// be sent, or its context is done.
+func (l *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	route, major := routeKey(req.Method, req.URL.Path)
+	global := !strings.HasPrefix(route, http.MethodPost+" /interactions/")
+
+	b, err := l.acquire(req.Context(), route, major, global)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := l.transport.RoundTrip(req)
+	if err != nil {
+		l.release(b, route, major, nil)
+		return nil, err
+	}
+	l.release(b, route, major, resp)
+	return resp, nil
+}
+
+// acquire waits until a request to route may be sent and reserves it
+// against the limits.
+func (l *RateLimiter) acquire(ctx context.Context, route, major string, global bool) (*bucket, error) {
+	l.mu.Lock()
+	now := time.Now()
+	l.sweep(now)
+
+	b := l.bucket(route, major)
+	ticket := b.queue.enqueue()
+	var globalTicket uint64
+
+	for {
+		// A response may have revealed that the route shares the bucket
+		// of another route.
+		if learned := l.bucket(route, major); learned != b {
+			b.queue.remove(ticket)
+			b, ticket = learned, learned.queue.enqueue()
+		}
+
+		now = time.Now()
+		var wait time.Duration
+		switch {
+		case b.queue.head() != ticket:
+		case now.Before(b.until):
+			wait = b.until.Sub(now)
+		case b.limit > 0 && b.remaining <= 0 && now.Before(b.resetAt):
+			wait = b.resetAt.Sub(now)
+		case b.inflight > 0 && (b.limit == 0 || b.remaining <= 0):
+			// The limit, or the next window once the current one has
+			// passed, is learned from the response.
+		default:
+			if b.limit > 0 && b.remaining <= 0 {
+				b.remaining = b.limit
+			}
+			if global {
+				if globalTicket == 0 {
+					globalTicket = l.globalQueue.enqueue()
+				}
+				if wait = l.globalWait(now, globalTicket); wait != 0 {
+					break
+				}
+				l.globalQueue.remove(globalTicket)
+				l.globalSent = append(l.globalSent, now)
+			}
+
+			if b.limit > 0 {
+				b.remaining--
+			}
+			b.inflight++
+			b.queue.remove(ticket)
+			l.notify()
+			l.mu.Unlock()
+			return b, nil
+		}
+
+		var (
+			timer *time.Timer
+			retry <-chan time.Time
+		)
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			retry = timer.C
+		}
+		wake := l.wake
+		l.mu.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-retry:
+		case <-wake:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		l.mu.Lock()
+		if err != nil {
+			b.queue.remove(ticket)
+			l.globalQueue.remove(globalTicket)
+			l.notify()
+			l.mu.Unlock()
+			return nil, err
+		}
+	}
+}
+
+// globalWait returns how long the holder of ticket must wait for the
+// global limit, or 0 if it may send now. A negative duration waits for
+// the queue to move.
+func (l *RateLimiter) globalWait(now time.Time, ticket uint64) time.Duration {
+	if now.Before(l.globalUntil) {
+		return l.globalUntil.Sub(now)
+	}
+
+	cutoff := now.Add(-l.globalWindow)
+	i := 0
+	for i < len(l.globalSent) && !l.globalSent[i].After(cutoff) {
+		i++
+	}
+	l.globalSent = l.globalSent[i:]
+
+	if len(l.globalSent) >= l.globalLimit {
+		return l.globalSent[len(l.globalSent)-l.globalLimit].Add(l.globalWindow).Sub(now)
+	}
+	if l.globalQueue.head() != ticket {
+		return -1
+	}
+	return 0
+}
+
+// release records the response to a request acquired from b.
+func (l *RateLimiter) release(b *bucket, route, major string, resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer l.notify()
+
+	b.inflight--
+	if resp == nil {
+		return
+	}
+
+	rl, _ := ParseRateLimit(resp.Header)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		readRateLimitBody(resp, &rl)
+	}
+	now := time.Now()
+
+	inflight := b.inflight
+	if rl.Bucket != "" && l.hashes[route] != rl.Bucket {
+		l.hashes[route] = rl.Bucket
+		learned := l.bucket(route, major)
+		if learned != b && learned.limit == 0 {
+			learned.limit, learned.remaining, learned.resetAt = b.limit, b.remaining, b.resetAt
+		}
+		b = learned
+	}
+	if rl.Limit > 0 {
+		remaining := max(0, rl.Remaining-inflight)
+		resetAt := now.Add(seconds(rl.ResetAfter))
+		if b.limit == rl.Limit && now.Before(b.resetAt) {
+			// Responses of the same window may arrive out of order.
+			remaining = min(remaining, b.remaining)
+			resetAt = later(resetAt, b.resetAt)
+		}
+		b.limit, b.remaining, b.resetAt = rl.Limit, remaining, resetAt
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	until := now.Add(seconds(rl.RetryAfter))
+	switch {
+	case rl.Global || rl.Scope == payloads.RateLimitScopeGlobal:
+		l.globalUntil = until
+	case rl.Scope == payloads.RateLimitScopeShared:
+		b.until = until
+	default:
+		b.until = until
+		b.remaining = 0
+	}
+}
+
+// later returns the later of a and b.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// bucket returns the bucket of route and major, creating it if needed.
+func (l *RateLimiter) bucket(route, major string) *bucket {
+	key := route
+	if hash, ok := l.hashes[route]; ok {
+		key = hash
+	}
+	key += "|" + major
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// sweep drops idle buckets whose limits have reset, at most once a minute,
+// so buckets of channels that are no longer used do not accumulate.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.inflight == 0 && len(b.queue.waiting) == 0 && now.After(b.resetAt) && now.After(b.until) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// notify wakes every waiter to recheck the limits.
+func (l *RateLimiter) notify() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// readRateLimitBody reads the body of a 429 response into rl, which has
+// the precise retry_after, and leaves the body readable for the caller.
+func readRateLimitBody(resp *http.Response, rl *payloads.RESTRateLimit) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	var body payloads.RESTRateLimit
+	if json.Unmarshal(data, &body) == nil && body.RetryAfter > 0 {
+		rl.RetryAfter = body.RetryAfter
+		rl.Global = rl.Global || body.Global
+		rl.Message, rl.Code = body.Message, body.Code
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// routeKey returns the rate limit key of a request: its method and path
+// with ids replaced by placeholders, and its major parameter.
+//
+// See: https://discord.com/developers/docs/topics/rate-limits#rate-limits
+func routeKey(method, path string) (route, major string) {
+	segments := pathSegments(path)
+	if len(segments) == 0 {
+		return method + " /", ""
+	}
+
+	key := make([]string, len(segments))
+	copy(key, segments)
+	switch segments[0] {
+	case "channels", "guilds":
+		if len(segments) > 1 {
+			major, key[1] = segments[1], ":major"
+		}
+	case "webhooks":
+		if len(segments) > 2 && !isID(segments[2]) {
+			major, key[1], key[2] = segments[1]+"/"+segments[2], ":major", ":major"
+		} else if len(segments) > 1 {
+			major, key[1] = segments[1], ":major"
+		}
+	case "interactions":
+		if len(segments) > 2 {
+			key[1], key[2] = ":id", ":token"
+		}
+	}
+
+	for i := 1; i < len(key); i++ {
+		switch {
+		case key[i-1] == "reactions":
+			// Every emoji shares the limits of the reaction routes.
+			key[i] = ":emoji"
+		case isID(key[i]):
+			key[i] = ":id"
+		}
+	}
+	return method + " /" + strings.Join(key, "/"), major
+}
+
+func isID(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
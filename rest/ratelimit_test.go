@@ -0,0 +1,372 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/payloads"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    payloads.RESTRateLimit
+		found   bool
+	}{
+		{
+			name: "Route limit",
+			headers: map[string]string{
+				"X-RateLimit-Limit":       "5",
+				"X-RateLimit-Remaining":   "4",
+				"X-RateLimit-Reset":       "1470173023.123",
+				"X-RateLimit-Reset-After": "1.2",
+				"X-RateLimit-Bucket":      "abcd1234",
+			},
+			want: payloads.RESTRateLimit{
+				Bucket: "abcd1234", Limit: 5, Remaining: 4, ResetAfter: 1.2,
+				Reset: time.Unix(1470173023, 123000000),
+			},
+			found: true,
+		},
+		{
+			name: "Global 429",
+			headers: map[string]string{
+				"X-RateLimit-Global": "true",
+				"X-RateLimit-Scope":  "global",
+				"Retry-After":        "65",
+			},
+			want:  payloads.RESTRateLimit{Global: true, Scope: payloads.RateLimitScopeGlobal, RetryAfter: 65},
+			found: true,
+		},
+		{
+			name: "Shared 429",
+			headers: map[string]string{
+				"X-RateLimit-Limit":       "10",
+				"X-RateLimit-Remaining":   "0",
+				"X-RateLimit-Reset-After": "64.57",
+				"X-RateLimit-Bucket":      "abcd1234",
+				"X-RateLimit-Scope":       "shared",
+				"Retry-After":             "1",
+			},
+			want: payloads.RESTRateLimit{
+				Bucket: "abcd1234", Limit: 10, ResetAfter: 64.57,
+				Scope: payloads.RateLimitScopeShared, RetryAfter: 1,
+			},
+			found: true,
+		},
+		{
+			name: "No limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for name, value := range tt.headers {
+				h.Set(name, value)
+			}
+
+			got, found := ParseRateLimit(h)
+			if found != tt.found {
+				t.Errorf("ParseRateLimit() found = %v, want %v", found, tt.found)
+			}
+			if !got.Reset.Equal(tt.want.Reset) {
+				t.Errorf("Reset = %v, want %v", got.Reset, tt.want.Reset)
+			}
+			got.Reset, tt.want.Reset = time.Time{}, time.Time{}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseRateLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteKey(t *testing.T) {
+	tests := []struct {
+		method, path string
+		route, major string
+	}{
+		{"GET", "/api/v10/channels/290926798999357250/messages", "GET /channels/:major/messages", "290926798999357250"},
+		{"DELETE", "/api/v10/channels/290926798999357250/messages/334385199974967042", "DELETE /channels/:major/messages/:id", "290926798999357250"},
+		{"PUT", "/api/v10/channels/290926798999357250/messages/334385199974967042/reactions/%F0%9F%94%A5/@me", "PUT /channels/:major/messages/:id/reactions/:emoji/@me", "290926798999357250"},
+		{"PATCH", "/api/v10/guilds/41771983423143937/members/80351110224678912", "PATCH /guilds/:major/members/:id", "41771983423143937"},
+		{"POST", "/api/v10/webhooks/223704706495545344/3d89bb7572e0fb30d8128367b3b1b44f", "POST /webhooks/:major/:major", "223704706495545344/3d89bb7572e0fb30d8128367b3b1b44f"},
+		{"GET", "/api/v10/webhooks/223704706495545344", "GET /webhooks/:major", "223704706495545344"},
+		{"POST", "/api/v10/interactions/1183467547452964864/aW50ZXJhY3Rpb24/callback", "POST /interactions/:id/:token/callback", ""},
+		{"GET", "/api/v10/users/@me/guilds", "GET /users/@me/guilds", ""},
+		{"GET", "/gateway/bot", "GET /gateway/bot", ""},
+	}
+
+	for _, tt := range tests {
+		route, major := routeKey(tt.method, tt.path)
+		if route != tt.route || major != tt.major {
+			t.Errorf("routeKey(%s %s) = %q, %q, want %q, %q", tt.method, tt.path, route, major, tt.route, tt.major)
+		}
+	}
+}
+
+// limitedServer is a Discord-like server that allows limit requests per
+// window in each bucket and answers requests over the limit with 429.
+type limitedServer struct {
+	limit  int
+	window time.Duration
+	// bucketOf returns the bucket hash and key of a request.
+	bucketOf func(r *http.Request) (hash, key string)
+
+	mu       sync.Mutex
+	windows  map[string]time.Time
+	counts   map[string]int
+	limited  int
+	requests []string
+}
+
+func (s *limitedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hash, key := s.bucketOf(r)
+
+	s.mu.Lock()
+	now := time.Now()
+	if s.windows == nil {
+		s.windows, s.counts = make(map[string]time.Time), make(map[string]int)
+	}
+	if now.After(s.windows[key]) {
+		s.windows[key], s.counts[key] = now.Add(s.window), 0
+	}
+	s.counts[key]++
+	count, reset := s.counts[key], s.windows[key].Sub(now)
+	s.requests = append(s.requests, r.URL.Path+"?"+r.URL.RawQuery)
+	if count > s.limit {
+		s.limited++
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("X-RateLimit-Bucket", hash)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(0, s.limit-count)))
+	w.Header().Set("X-RateLimit-Reset-After", strconv.FormatFloat(reset.Seconds(), 'f', 3, 64))
+	if count > s.limit {
+		w.Header().Set("X-RateLimit-Scope", "user")
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprintf(w, `{"message":"You are being rate limited.","retry_after":%.3f,"global":false}`, reset.Seconds())
+		return
+	}
+	io.WriteString(w, `{}`)
+}
+
+// get sends a GET request for path through l.
+func get(t *testing.T, l *RateLimiter, base, path string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, base+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := l.RoundTrip(req)
+	if err != nil {
+		t.Errorf("RoundTrip(%s) error = %v", path, err)
+		return nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+func TestRateLimiter_Buckets(t *testing.T) {
+	byChannel := func(r *http.Request) (string, string) {
+		_, major := routeKey(r.Method, r.URL.Path)
+		return "messages", major
+	}
+
+	t.Run("Concurrent", func(t *testing.T) {
+		s := &limitedServer{limit: 2, window: 100 * time.Millisecond, bucketOf: byChannel}
+		srv := httptest.NewServer(s)
+		defer srv.Close()
+		l := NewRateLimiter(RateLimiterConfig{})
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for range 6 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				get(t, l, srv.URL, "/api/v10/channels/290926798999357250/messages")
+			}()
+		}
+		wg.Wait()
+
+		if s.limited != 0 {
+			t.Errorf("server answered %d requests with 429", s.limited)
+		}
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+			t.Errorf("6 requests at 2 per 100ms took %v, want at least 200ms", elapsed)
+		}
+	})
+
+	t.Run("Major parameters", func(t *testing.T) {
+		s := &limitedServer{limit: 1, window: time.Hour, bucketOf: byChannel}
+		srv := httptest.NewServer(s)
+		defer srv.Close()
+		l := NewRateLimiter(RateLimiterConfig{})
+
+		get(t, l, srv.URL, "/api/v10/channels/290926798999357250/messages")
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// Another channel has its own bucket and is not delayed.
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v10/channels/381870553235193857/messages", nil)
+		if _, err := l.RoundTrip(req); err != nil {
+			t.Errorf("RoundTrip() of another channel error = %v", err)
+		}
+
+		// The exhausted channel waits until the context is done.
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v10/channels/290926798999357250/messages", nil)
+		if _, err := l.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("RoundTrip() of an exhausted bucket error = %v, want context.DeadlineExceeded", err)
+		}
+		if s.limited != 0 {
+			t.Errorf("server answered %d requests with 429", s.limited)
+		}
+	})
+
+	t.Run("Shared hash", func(t *testing.T) {
+		// Both routes report the same bucket hash, so they share one limit.
+		s := &limitedServer{limit: 1, window: 100 * time.Millisecond, bucketOf: func(r *http.Request) (string, string) {
+			_, major := routeKey(r.Method, r.URL.Path)
+			return "members", major
+		}}
+		srv := httptest.NewServer(s)
+		defer srv.Close()
+		l := NewRateLimiter(RateLimiterConfig{})
+
+		get(t, l, srv.URL, "/api/v10/guilds/41771983423143937/members/80351110224678912")
+		get(t, l, srv.URL, "/api/v10/guilds/41771983423143937/members/53908099506183680")
+		start := time.Now()
+		get(t, l, srv.URL, "/api/v10/guilds/41771983423143937/members")
+		get(t, l, srv.URL, "/api/v10/guilds/41771983423143937/members/80351110224678912")
+
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("requests to a learned shared bucket took %v, want a wait for the reset", elapsed)
+		}
+		// Only the first request to the second route could not know the hash.
+		if s.limited > 1 {
+			t.Errorf("server answered %d requests with 429, want at most 1", s.limited)
+		}
+	})
+
+	t.Run("Fair queue", func(t *testing.T) {
+		s := &limitedServer{limit: 1, window: 20 * time.Millisecond, bucketOf: byChannel}
+		srv := httptest.NewServer(s)
+		defer srv.Close()
+		l := NewRateLimiter(RateLimiterConfig{})
+		get(t, l, srv.URL, "/api/v10/channels/290926798999357250/messages")
+
+		var wg sync.WaitGroup
+		var want []string
+		for i := range 5 {
+			path := fmt.Sprintf("/api/v10/channels/290926798999357250/messages?n=%d", i)
+			want = append(want, "/api/v10/channels/290926798999357250/messages?n="+strconv.Itoa(i))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				get(t, l, srv.URL, path)
+			}()
+			// Let the request join the queue before the next one.
+			time.Sleep(2 * time.Millisecond)
+		}
+		wg.Wait()
+
+		if got := s.requests[1:]; !slices.Equal(got, want) {
+			t.Errorf("requests were sent in order %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRateLimiter_Global(t *testing.T) {
+	t.Run("Limit", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer srv.Close()
+		l := NewRateLimiter(RateLimiterConfig{GlobalLimit: 3})
+		l.globalWindow = 100 * time.Millisecond
+
+		start := time.Now()
+		for i := range 4 {
+			get(t, l, srv.URL, fmt.Sprintf("/api/v10/channels/29092679899935725%d", i))
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("4 requests at 3 per 100ms took %v, want at least 100ms", elapsed)
+		}
+
+		// Interaction callbacks are exempt.
+		start = time.Now()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v10/interactions/1183467547452964864/aW50ZXJhY3Rpb24/callback", nil)
+		if _, err := l.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+			t.Errorf("interaction callback waited %v for the global limit", elapsed)
+		}
+	})
+
+	scoped := func(scope string) http.HandlerFunc {
+		var once sync.Once
+		return func(w http.ResponseWriter, r *http.Request) {
+			limited := false
+			once.Do(func() { limited = true })
+			if !limited {
+				return
+			}
+			w.Header().Set("X-RateLimit-Scope", scope)
+			w.Header().Set("Retry-After", "1")
+			if scope == "global" {
+				w.Header().Set("X-RateLimit-Global", "true")
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			io.WriteString(w, `{"message":"You are being rate limited.","retry_after":0.1,"global":`+strconv.FormatBool(scope == "global")+`}`)
+		}
+	}
+
+	tests := []struct {
+		scope string
+		// other is whether a request to another channel waits as well.
+		other bool
+	}{
+		{scope: "global", other: true},
+		{scope: "shared", other: false},
+		{scope: "user", other: false},
+	}
+
+	for _, tt := range tests {
+		t.Run("429 "+tt.scope, func(t *testing.T) {
+			srv := httptest.NewServer(scoped(tt.scope))
+			defer srv.Close()
+			l := NewRateLimiter(RateLimiterConfig{})
+
+			resp := get(t, l, srv.URL, "/api/v10/channels/290926798999357250/messages")
+			if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+				t.Fatal("first request was not rate limited")
+			}
+
+			start := time.Now()
+			get(t, l, srv.URL, "/api/v10/channels/381870553235193857/messages")
+			if waited := time.Since(start) >= 80*time.Millisecond; waited != tt.other {
+				t.Errorf("request to another channel waited = %v, want %v", waited, tt.other)
+			}
+
+			start = time.Now()
+			get(t, l, srv.URL, "/api/v10/channels/290926798999357250/messages")
+			if elapsed := time.Since(start); elapsed > time.Second || (!tt.other && elapsed < 80*time.Millisecond) {
+				t.Errorf("retry waited %v, want the retry_after of 100ms", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package resttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/payloads"
+	"github.com/kolosys/discord-types/rest"
+)
+
+// Request is a request received by a Server.
+type Request struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Path is the escaped path of the request without the API version, as
+	// built by rest.Routes.
+	Path string
+
+	// Route is the route of Path. Its Template is empty for paths that
+	// match no route.
+	Route rest.RouteMatch
+
+	// Query is the query of the request.
+	Query url.Values
+
+	// Header is the header of the request.
+	Header http.Header
+
+	// Body is the JSON body of the request, or the payload_json part of a
+	// multipart/form-data body.
+	Body []byte
+
+	// Files are the file parts of a multipart/form-data body.
+	Files []RequestFile
+
+	// Reason is the decoded X-Audit-Log-Reason header.
+	Reason string
+
+	// Status is the status code of the response.
+	Status int
+}
+
+// RequestFile is a file uploaded with a request.
+type RequestFile struct {
+	// Field is the name of the form field, such as files[0].
+	Field string
+
+	// Name is the file name.
+	Name string
+
+	// ContentType is the content type of the part.
+	ContentType string
+
+	// Data is the content of the file.
+	Data []byte
+}
+
+// DecodeBody decodes the JSON body of r into v.
+func (r Request) DecodeBody(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// DecodeQuery decodes the query of r into v, a pointer to a query struct
+// such as rest.GetMessagesQuery.
+func (r Request) DecodeQuery(v any) error {
+	return rest.DecodeQuery(r.Query, v)
+}
+
+// matches reports whether r was sent with method to route, a path as
+// built by rest.Routes or a route template. An empty method matches any
+// method.
+func (r Request) matches(method, route string) bool {
+	if method != "" && r.Method != method {
+		return false
+	}
+	return route == r.Path || route == r.Route.Template
+}
+
+// Requests returns the requests received by the server, in the order they
+// were received.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// ResetRequests forgets the requests received so far.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// requestsTo returns the requests sent with method to route.
+func (s *Server) requestsTo(method, route string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for _, req := range s.requests {
+		if req.matches(method, route) {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// AssertRequested fails t unless the server received a request with
+// method to route, which is a path as built by rest.Routes or a route
+// template such as "/channels/{channel.id}/messages". It returns the last
+// such request.
+func (s *Server) AssertRequested(t testing.TB, method, route string) Request {
+	t.Helper()
+
+	requests := s.requestsTo(method, route)
+	if len(requests) == 0 {
+		t.Fatalf("resttest: no %s %s request in %s", method, route, s.describeRequests())
+	}
+	return requests[len(requests)-1]
+}
+
+// AssertNotRequested fails t if the server received a request with method
+// to route.
+func (s *Server) AssertNotRequested(t testing.TB, method, route string) {
+	t.Helper()
+
+	if requests := s.requestsTo(method, route); len(requests) > 0 {
+		t.Errorf("resttest: got %d %s %s requests, want none", len(requests), method, route)
+	}
+}
+
+// AssertRequestCount fails t unless the server received n requests with
+// method to route.
+func (s *Server) AssertRequestCount(t testing.TB, method, route string, n int) {
+	t.Helper()
+
+	if requests := s.requestsTo(method, route); len(requests) != n {
+		t.Errorf("resttest: got %d %s %s requests, want %d", len(requests), method, route, n)
+	}
+}
+
+func (s *Server) describeRequests() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.requests) == 0 {
+		return "no requests"
+	}
+	lines := make([]string, len(s.requests))
+	for i, req := range s.requests {
+		lines[i] = fmt.Sprintf("%s %s (%d)", req.Method, req.Path, req.Status)
+	}
+	return "[" + strings.Join(lines, ", ") + "]"
+}
+
+// RateLimit is a 429 response injected with Server.InjectRateLimit.
+type RateLimit struct {
+	// Method and Route select the requests to reject: a route is a path as
+	// built by rest.Routes or a route template. Empty values match every
+	// request.
+	Method, Route string
+
+	// Count is the number of requests to reject. Defaults to 1.
+	Count int
+
+	// RetryAfter is the wait requested by the responses. Defaults to one
+	// second.
+	RetryAfter time.Duration
+
+	// Global responds with a global rate limit, which applies to every
+	// route.
+	Global bool
+
+	// Scope is the X-RateLimit-Scope of the responses. Defaults to
+	// payloads.RateLimitScopeGlobal for global limits and
+	// payloads.RateLimitScopeUser otherwise.
+	Scope payloads.RateLimitScope
+
+	// Bucket is the X-RateLimit-Bucket of route limits. Defaults to
+	// "resttest".
+	Bucket string
+}
+
+// InjectRateLimit makes the server reject the next requests matched by rl
+// with 429 responses, before they reach the model. Rate limits are
+// applied in the order they were injected.
+func (s *Server) InjectRateLimit(rl RateLimit) {
+	if rl.Count <= 0 {
+		rl.Count = 1
+	}
+	if rl.RetryAfter <= 0 {
+		rl.RetryAfter = time.Second
+	}
+	if rl.Scope == "" {
+		rl.Scope = payloads.RateLimitScopeUser
+		if rl.Global {
+			rl.Scope = payloads.RateLimitScopeGlobal
+		}
+	}
+	if rl.Bucket == "" {
+		rl.Bucket = "resttest"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimits = append(s.rateLimits, rl)
+}
+
+// takeRateLimit removes a use of the first rate limit that matches req.
+func (s *Server) takeRateLimit(req Request) (RateLimit, bool) {
+	for i := range s.rateLimits {
+		rl := &s.rateLimits[i]
+		if rl.Method != "" && rl.Method != req.Method || rl.Route != "" && !req.matches("", rl.Route) {
+			continue
+		}
+		taken := *rl
+		if rl.Count--; rl.Count == 0 {
+			s.rateLimits = slices.Delete(s.rateLimits, i, i+1)
+		}
+		return taken, true
+	}
+	return RateLimit{}, false
+}
+
+// writeRateLimit writes the 429 response of rl, with the headers and body
+// of the real API.
+func writeRateLimit(w http.ResponseWriter, rl RateLimit) {
+	retryAfter := rl.RetryAfter.Seconds()
+	h := w.Header()
+	if rl.Global {
+		h.Set("X-RateLimit-Global", "true")
+	} else {
+		h.Set("X-RateLimit-Limit", "1")
+		h.Set("X-RateLimit-Remaining", "0")
+		h.Set("X-RateLimit-Reset", strconv.FormatFloat(float64(time.Now().Add(rl.RetryAfter).UnixMilli())/1000, 'f', 3, 64))
+		h.Set("X-RateLimit-Reset-After", strconv.FormatFloat(retryAfter, 'f', 3, 64))
+		h.Set("X-RateLimit-Bucket", rl.Bucket)
+	}
+	h.Set("X-RateLimit-Scope", string(rl.Scope))
+	h.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter))))
+	writeJSON(w, http.StatusTooManyRequests, payloads.RESTRateLimit{
+		Message:    "You are being rate limited.",
+		RetryAfter: math.Round(retryAfter*1000) / 1000,
+		Global:     rl.Global,
+	})
+}
+
+// serve handles r with s.mu held and records it.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	req := Request{
+		Method: r.Method,
+		Path:   trimVersion(r.URL.EscapedPath()),
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+	}
+	req.Reason, _ = url.PathUnescape(r.Header.Get("X-Audit-Log-Reason"))
+	req.Status = s.respond(w, r, &req)
+	s.requests = append(s.requests, req)
+}
+
+// respond writes the response to req and returns its status.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, req *Request) int {
+	if err := readBody(r, req); err != nil {
+		return writeError(w, rest.RESTError{
+			Status:  http.StatusBadRequest,
+			Code:    rest.RequestBodyContainsInvalidJSON,
+			Message: "The request body contains invalid JSON.",
+		})
+	}
+
+	match, ok := rest.MatchRoute(req.Path)
+	if !ok {
+		return writeError(w, rest.RESTError{Status: http.StatusNotFound, Message: "404: Not Found"})
+	}
+	req.Route = match
+	method, ok := match.Method(req.Method)
+	if !ok {
+		return writeError(w, rest.RESTError{Status: http.StatusMethodNotAllowed, Message: "405: Method Not Allowed"})
+	}
+
+	if rl, ok := s.takeRateLimit(*req); ok {
+		writeRateLimit(w, rl)
+		return http.StatusTooManyRequests
+	}
+	if method.Auth && r.Header.Get("Authorization") == "" {
+		return writeError(w, rest.RESTError{Status: http.StatusUnauthorized, Message: "401: Unauthorized"})
+	}
+
+	handler := handlers[req.Method+" "+match.Template]
+	if handler == nil {
+		return writeError(w, rest.RESTError{Status: http.StatusNotFound, Message: "404: Not Found"})
+	}
+	c := &call{s: s, req: req, match: match, method: method, status: http.StatusOK}
+	result, err := handler(c)
+	switch {
+	case err != nil:
+		return writeError(w, err)
+	case result == nil:
+		w.WriteHeader(http.StatusNoContent)
+		return http.StatusNoContent
+	}
+	writeJSON(w, c.status, result)
+	return c.status
+}
+
+// trimVersion removes the /api and /api/vN prefixes of path.
+func trimVersion(path string) string {
+	tail, ok := strings.CutPrefix(path, "/api")
+	if !ok {
+		return path
+	}
+	if version, ok := strings.CutPrefix(tail, "/v"); ok {
+		if i := strings.IndexByte(version, '/'); i > 0 {
+			if _, err := strconv.Atoi(version[:i]); err == nil {
+				return version[i:]
+			}
+		}
+	}
+	return tail
+}
+
+// readBody reads the body of r into req: JSON bodies as they are, and the
+// payload_json and files of multipart/form-data bodies.
+func readBody(r *http.Request, req *Request) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		if len(body) > 0 && !json.Valid(body) {
+			return fmt.Errorf("invalid JSON body")
+		}
+		req.Body = body
+		return nil
+	}
+
+	form, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := form.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		switch {
+		case part.FileName() != "":
+			req.Files = append(req.Files, RequestFile{
+				Field:       part.FormName(),
+				Name:        part.FileName(),
+				ContentType: part.Header.Get("Content-Type"),
+				Data:        data,
+			})
+		case part.FormName() == "payload_json":
+			if !json.Valid(data) {
+				return fmt.Errorf("invalid payload_json")
+			}
+			req.Body = data
+		}
+	}
+}
+
+// errorBody is the body of an error response.
+type errorBody struct {
+	Message string                 `json:"message"`
+	Code    rest.RESTJSONErrorCode `json:"code"`
+	Errors  map[string]any         `json:"errors,omitempty"`
+}
+
+// writeError writes err, a rest.RESTError, as an error response and
+// returns its status.
+func writeError(w http.ResponseWriter, err error) int {
+	restErr, ok := err.(rest.RESTError)
+	if !ok {
+		restErr = rest.RESTError{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+
+	body := errorBody{Message: restErr.Message, Code: restErr.Code}
+	if len(restErr.Errors) > 0 {
+		body.Errors = make(map[string]any)
+	}
+	// Field errors are nested by their path, with the errors of each field
+	// in its "_errors" array.
+	for _, fieldErr := range restErr.Errors {
+		node := body.Errors
+		if fieldErr.Path != "" {
+			for key := range strings.SplitSeq(fieldErr.Path, ".") {
+				child, ok := node[key].(map[string]any)
+				if !ok {
+					child = make(map[string]any)
+					node[key] = child
+				}
+				node = child
+			}
+		}
+		leaves, _ := node["_errors"].([]map[string]string)
+		node["_errors"] = append(leaves, map[string]string{"code": fieldErr.Code, "message": fieldErr.Message})
+	}
+
+	writeJSON(w, restErr.Status, body)
+	return restErr.Status
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,1001 @@
+package resttest
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+	"github.com/kolosys/discord-types/rest"
+)
+
+// handler handles a request to a route. It returns the result, or nil for
+// 204 No Content, or a rest.RESTError.
+type handler func(c *call) (any, error)
+
+// handlers are the handlers of the modelled routes, keyed by method and
+// template.
+var handlers = map[string]handler{
+	"GET /guilds/{guild.id}":    (*call).getGuild,
+	"PATCH /guilds/{guild.id}":  (*call).patchGuild,
+	"DELETE /guilds/{guild.id}": (*call).deleteGuild,
+
+	"GET /guilds/{guild.id}/channels":  (*call).getGuildChannels,
+	"POST /guilds/{guild.id}/channels": (*call).postGuildChannel,
+	"GET /channels/{channel.id}":       (*call).getChannel,
+	"PATCH /channels/{channel.id}":     (*call).patchChannel,
+	"DELETE /channels/{channel.id}":    (*call).deleteChannel,
+
+	"GET /channels/{channel.id}/messages":                 (*call).getMessages,
+	"POST /channels/{channel.id}/messages":                (*call).postMessage,
+	"GET /channels/{channel.id}/messages/{message.id}":    (*call).getMessage,
+	"PATCH /channels/{channel.id}/messages/{message.id}":  (*call).patchMessage,
+	"DELETE /channels/{channel.id}/messages/{message.id}": (*call).deleteMessage,
+	"POST /channels/{channel.id}/messages/bulk-delete":    (*call).bulkDeleteMessages,
+
+	"GET /guilds/{guild.id}/roles":              (*call).getRoles,
+	"POST /guilds/{guild.id}/roles":             (*call).postRole,
+	"GET /guilds/{guild.id}/roles/{role.id}":    (*call).getRole,
+	"PATCH /guilds/{guild.id}/roles/{role.id}":  (*call).patchRole,
+	"DELETE /guilds/{guild.id}/roles/{role.id}": (*call).deleteRole,
+
+	"GET /guilds/{guild.id}/members":                              (*call).getMembers,
+	"GET /guilds/{guild.id}/members/{user.id}":                    (*call).getMember,
+	"PUT /guilds/{guild.id}/members/{user.id}":                    (*call).putMember,
+	"PATCH /guilds/{guild.id}/members/{user.id}":                  (*call).patchMember,
+	"DELETE /guilds/{guild.id}/members/{user.id}":                 (*call).deleteMember,
+	"PUT /guilds/{guild.id}/members/{user.id}/roles/{role.id}":    (*call).putMemberRole,
+	"DELETE /guilds/{guild.id}/members/{user.id}/roles/{role.id}": (*call).deleteMemberRole,
+
+	"GET /channels/{channel.id}/webhooks":           (*call).getChannelWebhooks,
+	"POST /channels/{channel.id}/webhooks":          (*call).postWebhook,
+	"GET /guilds/{guild.id}/webhooks":               (*call).getGuildWebhooks,
+	"GET /webhooks/{webhook.id}":                    (*call).getWebhook,
+	"PATCH /webhooks/{webhook.id}":                  (*call).patchWebhook,
+	"DELETE /webhooks/{webhook.id}":                 (*call).deleteWebhook,
+	"GET /webhooks/{webhook.id}/{webhook.token}":    (*call).getWebhook,
+	"PATCH /webhooks/{webhook.id}/{webhook.token}":  (*call).patchWebhook,
+	"DELETE /webhooks/{webhook.id}/{webhook.token}": (*call).deleteWebhook,
+	"POST /webhooks/{webhook.id}/{webhook.token}":   (*call).executeWebhook,
+}
+
+// call is a request being handled, with s.mu held.
+type call struct {
+	s      *Server
+	req    *Request
+	match  rest.RouteMatch
+	method rest.RouteMethod
+
+	// status is the status of a successful response.
+	status int
+}
+
+// id returns the value of a parameter of the route.
+func (c *call) id(param string) discord.Snowflake {
+	return discord.Snowflake(c.match.Params[param])
+}
+
+// decode decodes the body of the request into v.
+func (c *call) decode(v any) error {
+	if len(c.req.Body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(c.req.Body, v); err != nil {
+		return invalidForm(rest.FieldError{Code: "MODEL_TYPE_CONVERT", Message: err.Error()})
+	}
+	return nil
+}
+
+// query decodes the query of the request into v.
+func (c *call) query(v any) error {
+	if err := rest.DecodeQuery(c.req.Query, v); err != nil {
+		return invalidForm(rest.FieldError{Code: "NUMBER_TYPE_COERCE", Message: err.Error()})
+	}
+	return nil
+}
+
+// patch applies the body of the request to v, after checking that it
+// decodes into the body type of the route. The body fields of the modelled
+// routes have the names of the fields of their objects.
+func (c *call) patch(v any) error {
+	if c.method.Body != nil {
+		if err := c.decode(reflect.New(c.method.Body).Interface()); err != nil {
+			return err
+		}
+	}
+	return c.decode(v)
+}
+
+func (c *call) guild() (*payloads.Guild, error) {
+	guild := c.s.guilds[c.id("guild.id")]
+	if guild == nil {
+		return nil, notFound(rest.UnknownGuild)
+	}
+	return guild, nil
+}
+
+func (c *call) channel() (*payloads.GuildTextChannel, error) {
+	channel := c.s.channels[c.id("channel.id")]
+	if channel == nil {
+		return nil, notFound(rest.UnknownChannel)
+	}
+	return channel, nil
+}
+
+func (c *call) message() (*payloads.Message, error) {
+	if _, err := c.channel(); err != nil {
+		return nil, err
+	}
+	channelID := c.id("channel.id")
+	i, ok := c.s.findMessage(channelID, c.id("message.id"))
+	if !ok {
+		return nil, notFound(rest.UnknownMessage)
+	}
+	return &c.s.messages[channelID][i], nil
+}
+
+func (c *call) role(guild *payloads.Guild) (int, error) {
+	i := slices.IndexFunc(guild.Roles, func(r payloads.Role) bool { return r.ID == c.id("role.id") })
+	if i < 0 {
+		return 0, notFound(rest.UnknownRole)
+	}
+	return i, nil
+}
+
+func (c *call) member() (*payloads.GuildMember, error) {
+	guildID := c.id("guild.id")
+	i, ok := c.s.findMember(guildID, c.id("user.id"))
+	if !ok {
+		return nil, notFound(rest.UnknownMember)
+	}
+	return &c.s.members[guildID][i], nil
+}
+
+// webhook returns the webhook of the route, checking its token if the
+// route has one.
+func (c *call) webhook() (*payloads.Webhook, error) {
+	webhook := c.s.webhooks[c.id("webhook.id")]
+	if webhook == nil {
+		return nil, notFound(rest.UnknownWebhook)
+	}
+	if token, ok := c.match.Params["webhook.token"]; ok && (webhook.Token == nil || *webhook.Token != token) {
+		return nil, rest.RESTError{Status: http.StatusUnauthorized, Code: rest.InvalidWebhookTokenProvided, Message: "Invalid Webhook Token"}
+	}
+	return webhook, nil
+}
+
+func notFound(code rest.RESTJSONErrorCode) error {
+	return rest.RESTError{Status: http.StatusNotFound, Code: code, Message: code.String()}
+}
+
+func invalidForm(errs ...rest.FieldError) error {
+	return rest.RESTError{
+		Status:  http.StatusBadRequest,
+		Code:    rest.InvalidFormBodyOrContentType,
+		Message: "Invalid Form Body",
+		Errors:  errs,
+	}
+}
+
+// checkLength returns a field error if s is not between minLength and
+// maxLength characters long.
+func checkLength(path, s string, minLength, maxLength int) error {
+	n := utf8.RuneCountInString(s)
+	switch {
+	case n == 0 && minLength > 0:
+		return invalidForm(rest.FieldError{Path: path, Code: "BASE_TYPE_REQUIRED", Message: "This field is required"})
+	case n < minLength || n > maxLength:
+		message := "Must be between " + strconv.Itoa(minLength) + " and " + strconv.Itoa(maxLength) + " in length."
+		if minLength == 0 {
+			return invalidForm(rest.FieldError{Path: path, Code: "BASE_TYPE_MAX_LENGTH", Message: "Must be " + strconv.Itoa(maxLength) + " or fewer in length."})
+		}
+		return invalidForm(rest.FieldError{Path: path, Code: "BASE_TYPE_BAD_LENGTH", Message: message})
+	}
+	return nil
+}
+
+// checkLimit returns the limit of a list query, or a field error if it is
+// not between 1 and maxLimit.
+func checkLimit(limit *int, defaultLimit, maxLimit int) (int, error) {
+	if limit == nil {
+		return defaultLimit, nil
+	}
+	if *limit < 1 || *limit > maxLimit {
+		return 0, invalidForm(rest.FieldError{
+			Path:    "limit",
+			Code:    "NUMBER_TYPE_MAX",
+			Message: "int value should be between 1 and " + strconv.Itoa(maxLimit) + ".",
+		})
+	}
+	return *limit, nil
+}
+
+// Guilds
+
+func (c *call) getGuild() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	return *guild, nil
+}
+
+func (c *call) patchGuild() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.patch(guild); err != nil {
+		return nil, err
+	}
+	return *guild, nil
+}
+
+func (c *call) deleteGuild() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	if guild.OwnerID != c.s.bot.ID {
+		return nil, rest.RESTError{Status: http.StatusForbidden, Code: rest.MissingAccess, Message: "Missing Access"}
+	}
+	for id, channel := range c.s.channels {
+		if channel.GuildID != nil && *channel.GuildID == guild.ID {
+			c.s.removeChannel(id)
+		}
+	}
+	delete(c.s.members, guild.ID)
+	delete(c.s.guilds, guild.ID)
+	return nil, nil
+}
+
+// Channels
+
+func (c *call) getGuildChannels() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	channels := rest.GetGuildChannelsResult{}
+	for _, channel := range c.s.channels {
+		if channel.GuildID != nil && *channel.GuildID == guild.ID {
+			channels = append(channels, *channel)
+		}
+	}
+	slices.SortFunc(channels, func(a, b payloads.GuildTextChannel) int {
+		return cmp.Or(cmp.Compare(a.Position, b.Position), compareIDs(a.ID, b.ID))
+	})
+	return channels, nil
+}
+
+func (c *call) postGuildChannel() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.PostGuildChannelJSONBody
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if err := checkLength("name", body.Name, 1, 100); err != nil {
+		return nil, err
+	}
+
+	channel := payloads.GuildTextChannel{}
+	channel.ID = c.s.newID()
+	channel.Name = body.Name
+	channel.GuildID = rest.NewSnowflake(guild.ID)
+	channel.ParentID = body.ParentID
+	channel.NSFW = body.NSFW
+	channel.Topic = body.Topic
+	channel.RateLimitPerUser = body.RateLimitPerUser
+	if body.Type != nil {
+		channel.Type = *body.Type
+	}
+	if body.Position != nil {
+		channel.Position = *body.Position
+	}
+	c.s.channels[channel.ID] = &channel
+	c.status = http.StatusCreated
+	return channel, nil
+}
+
+func (c *call) getChannel() (any, error) {
+	channel, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	return *channel, nil
+}
+
+func (c *call) patchChannel() (any, error) {
+	channel, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.patch(channel); err != nil {
+		return nil, err
+	}
+	return *channel, nil
+}
+
+func (c *call) deleteChannel() (any, error) {
+	channel, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	deleted := *channel
+	c.s.removeChannel(channel.ID)
+	return deleted, nil
+}
+
+// removeChannel deletes a channel with its messages and webhooks.
+func (s *Server) removeChannel(id discord.Snowflake) {
+	for webhookID, webhook := range s.webhooks {
+		if webhook.ChannelID == id {
+			delete(s.webhooks, webhookID)
+		}
+	}
+	delete(s.messages, id)
+	delete(s.channels, id)
+}
+
+// Messages
+
+func (c *call) getMessages() (any, error) {
+	if _, err := c.channel(); err != nil {
+		return nil, err
+	}
+	var query rest.GetMessagesQuery
+	if err := c.query(&query); err != nil {
+		return nil, err
+	}
+	limit, err := checkLimit(query.Limit, 50, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages are selected from the messages ordered oldest first, and
+	// returned newest first.
+	all := c.s.messages[c.id("channel.id")]
+	search := func(id discord.Snowflake) int {
+		i, _ := slices.BinarySearchFunc(all, id, func(m payloads.Message, id discord.Snowflake) int {
+			return compareIDs(m.ID, id)
+		})
+		return i
+	}
+	var page []payloads.Message
+	switch {
+	case query.Around != nil:
+		i := search(*query.Around)
+		start := max(0, i-limit/2)
+		page = all[start:min(len(all), start+limit)]
+	case query.After != nil:
+		i := search(*query.After)
+		if i < len(all) && all[i].ID == *query.After {
+			i++
+		}
+		page = all[i:min(len(all), i+limit)]
+	case query.Before != nil:
+		i := search(*query.Before)
+		page = all[max(0, i-limit):i]
+	default:
+		page = all[max(0, len(all)-limit):]
+	}
+
+	messages := slices.Clone(page)
+	slices.Reverse(messages)
+	if messages == nil {
+		messages = rest.GetChannelMessagesResult{}
+	}
+	return messages, nil
+}
+
+func (c *call) postMessage() (any, error) {
+	channel, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.PostChannelMessageJSONBody
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	content := ""
+	if body.Content != nil {
+		content = *body.Content
+	}
+	if err := checkMessage(content, len(body.Embeds)+len(body.Components)+len(body.StickerIDs)+len(c.req.Files)); err != nil {
+		return nil, err
+	}
+
+	if body.Nonce != nil && body.EnforceNonce != nil && *body.EnforceNonce {
+		for _, message := range c.s.messages[channel.ID] {
+			if message.Author.ID == c.s.bot.ID && message.Nonce == *body.Nonce {
+				return message, nil
+			}
+		}
+	}
+
+	message := payloads.Message{
+		ID:         c.s.newID(),
+		ChannelID:  channel.ID,
+		Author:     c.s.bot,
+		Content:    content,
+		Embeds:     body.Embeds,
+		Components: body.Components,
+	}
+	if body.TTS != nil {
+		message.TTS = *body.TTS
+	}
+	if body.Nonce != nil {
+		message.Nonce = *body.Nonce
+	}
+	if ref := body.MessageReference; ref != nil && ref.MessageID != nil {
+		channelID := channel.ID
+		if ref.ChannelID != nil {
+			channelID = *ref.ChannelID
+		}
+		i, ok := c.s.findMessage(channelID, *ref.MessageID)
+		if !ok {
+			return nil, invalidForm(rest.FieldError{Path: "message_reference", Code: "MESSAGE_REFERENCE_UNKNOWN_MESSAGE", Message: "Unknown message"})
+		}
+		referenced := c.s.messages[channelID][i]
+		message.Type = payloads.MessageTypeReply
+		message.MessageReference = ref
+		message.ReferencedMessage = &referenced
+	}
+	message.Attachments = c.attachments(channel.ID, message.ID, body.Attachments)
+	message.Timestamp = time.Now().UTC()
+
+	c.s.putMessage(message)
+	return c.s.messages[channel.ID][mustFind(c.s.findMessage(channel.ID, message.ID))], nil
+}
+
+// checkMessage returns an error for message content that is too long, or
+// for an empty message without other parts.
+func checkMessage(content string, parts int) error {
+	if content == "" && parts == 0 {
+		return rest.RESTError{Status: http.StatusBadRequest, Code: rest.CannotSendAnEmptyMessage, Message: "Cannot send an empty message"}
+	}
+	return checkLength("content", content, 0, discord.MaxMessageLength)
+}
+
+// attachments returns the attachments of the files of the request, with
+// the descriptions of their partial attachments.
+func (c *call) attachments(channelID, messageID discord.Snowflake, partials []payloads.PartialAttachment) []payloads.Attachment {
+	attachments := []payloads.Attachment{}
+	for _, file := range c.req.Files {
+		id := c.s.newID()
+		url := c.s.URL + "/attachments/" + channelID.String() + "/" + id.String() + "/" + file.Name
+		attachment := payloads.Attachment{
+			ID:       id,
+			Filename: file.Name,
+			Size:     len(file.Data),
+			URL:      url,
+			ProxyURL: url,
+		}
+		if file.ContentType != "" {
+			attachment.ContentType = rest.NewString(file.ContentType)
+		}
+		for _, partial := range partials {
+			if "files["+partial.ID.String()+"]" == file.Field {
+				attachment.Description = partial.Description
+			}
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments
+}
+
+func mustFind(i int, ok bool) int {
+	if !ok {
+		panic("resttest: stored message not found")
+	}
+	return i
+}
+
+func (c *call) getMessage() (any, error) {
+	message, err := c.message()
+	if err != nil {
+		return nil, err
+	}
+	return *message, nil
+}
+
+func (c *call) patchMessage() (any, error) {
+	message, err := c.message()
+	if err != nil {
+		return nil, err
+	}
+	if message.Author.ID != c.s.bot.ID {
+		return nil, rest.RESTError{
+			Status:  http.StatusForbidden,
+			Code:    rest.CannotEditAMessageAuthoredByAnotherUser,
+			Message: "Cannot edit a message authored by another user",
+		}
+	}
+	var body rest.PatchChannelMessageJSONBody
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Content != nil {
+		if err := checkLength("content", *body.Content, 0, discord.MaxMessageLength); err != nil {
+			return nil, err
+		}
+		message.Content = *body.Content
+	}
+	if body.Embeds != nil {
+		message.Embeds = body.Embeds
+	}
+	if body.Components != nil {
+		message.Components = body.Components
+	}
+	if body.Flags != nil {
+		message.Flags = body.Flags
+	}
+	if body.Attachments != nil || len(c.req.Files) > 0 {
+		// The listed attachments are kept, and the uploaded files added.
+		kept := []payloads.Attachment{}
+		for _, attachment := range message.Attachments {
+			if slices.ContainsFunc(body.Attachments, func(a payloads.PartialAttachment) bool { return a.ID == attachment.ID }) {
+				kept = append(kept, attachment)
+			}
+		}
+		message.Attachments = append(kept, c.attachments(message.ChannelID, message.ID, body.Attachments)...)
+	}
+	now := time.Now().UTC()
+	message.EditedTimestamp = &now
+	return *message, nil
+}
+
+func (c *call) deleteMessage() (any, error) {
+	message, err := c.message()
+	if err != nil {
+		return nil, err
+	}
+	c.s.removeMessages(message.ChannelID, message.ID)
+	return nil, nil
+}
+
+func (c *call) bulkDeleteMessages() (any, error) {
+	channel, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.PostChannelMessagesBulkDeleteJSONBody
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if len(body.Messages) < 2 || len(body.Messages) > 100 {
+		return nil, invalidForm(rest.FieldError{Path: "messages", Code: "BASE_TYPE_BAD_LENGTH", Message: "Must be between 2 and 100 in length."})
+	}
+	twoWeeksAgo := time.Now().Add(-14 * 24 * time.Hour)
+	for _, id := range body.Messages {
+		if created, err := id.Time(); err == nil && created.Before(twoWeeksAgo) {
+			return nil, rest.RESTError{
+				Status:  http.StatusBadRequest,
+				Code:    rest.AMessageProvidedWasTooOldToBulkDelete,
+				Message: "You can only bulk delete messages that are under 14 days old.",
+			}
+		}
+	}
+	c.s.removeMessages(channel.ID, body.Messages...)
+	return nil, nil
+}
+
+// removeMessages deletes messages of a channel. Unknown ids are ignored.
+func (s *Server) removeMessages(channelID discord.Snowflake, ids ...discord.Snowflake) {
+	s.messages[channelID] = slices.DeleteFunc(s.messages[channelID], func(m payloads.Message) bool {
+		return slices.Contains(ids, m.ID)
+	})
+}
+
+// Roles
+
+func (c *call) getRoles() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	return rest.GetGuildRolesResult(slices.Clone(guild.Roles)), nil
+}
+
+func (c *call) postRole() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.PostGuildRoleJSONBody
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+
+	role := payloads.Role{ID: c.s.newID(), Name: "new role", Position: 1, Icon: body.Icon, UnicodeEmoji: body.UnicodeEmoji}
+	if body.Name != nil {
+		if err := checkLength("name", *body.Name, 0, 100); err != nil {
+			return nil, err
+		}
+		role.Name = *body.Name
+	}
+	if body.Permissions != nil {
+		role.Permissions = *body.Permissions
+	} else if everyone := slices.IndexFunc(guild.Roles, func(r payloads.Role) bool { return r.ID == guild.ID }); everyone >= 0 {
+		role.Permissions = guild.Roles[everyone].Permissions
+	}
+	if body.Color != nil {
+		role.Color = *body.Color
+	}
+	if body.Hoist != nil {
+		role.Hoist = *body.Hoist
+	}
+	if body.Mentionable != nil {
+		role.Mentionable = *body.Mentionable
+	}
+	guild.Roles = append(guild.Roles, role)
+	return role, nil
+}
+
+func (c *call) getRole() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	i, err := c.role(guild)
+	if err != nil {
+		return nil, err
+	}
+	return guild.Roles[i], nil
+}
+
+func (c *call) patchRole() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	i, err := c.role(guild)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.patch(&guild.Roles[i]); err != nil {
+		return nil, err
+	}
+	return guild.Roles[i], nil
+}
+
+func (c *call) deleteRole() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	i, err := c.role(guild)
+	if err != nil {
+		return nil, err
+	}
+	if guild.Roles[i].ID == guild.ID {
+		return nil, rest.RESTError{Status: http.StatusBadRequest, Code: rest.InvalidRole, Message: "Invalid Role"}
+	}
+	roleID := guild.Roles[i].ID
+	guild.Roles = slices.Delete(guild.Roles, i, i+1)
+	for j := range c.s.members[guild.ID] {
+		member := &c.s.members[guild.ID][j]
+		member.Roles = slices.DeleteFunc(member.Roles, func(id discord.Snowflake) bool { return id == roleID })
+	}
+	return nil, nil
+}
+
+// Members
+
+func (c *call) getMembers() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	var query rest.GetGuildMembersQuery
+	if err := c.query(&query); err != nil {
+		return nil, err
+	}
+	limit, err := checkLimit(query.Limit, 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	members := rest.GetGuildMembersResult{}
+	for _, member := range c.s.members[guild.ID] {
+		if query.After != nil && compareIDs(member.User.ID, *query.After) <= 0 {
+			continue
+		}
+		if len(members) == limit {
+			break
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (c *call) getMember() (any, error) {
+	if _, err := c.guild(); err != nil {
+		return nil, err
+	}
+	member, err := c.member()
+	if err != nil {
+		return nil, err
+	}
+	return *member, nil
+}
+
+func (c *call) putMember() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.member(); err == nil {
+		// Users that are already members are left as they are.
+		return nil, nil
+	}
+	var body rest.PutGuildMemberJSONBody
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if body.AccessToken == "" {
+		return nil, invalidForm(rest.FieldError{Path: "access_token", Code: "BASE_TYPE_REQUIRED", Message: "This field is required"})
+	}
+
+	userID := c.id("user.id")
+	member := payloads.GuildMember{
+		User:     &payloads.User{ID: userID, Username: "user" + userID.String(), Discriminator: "0"},
+		Nick:     body.Nick,
+		Roles:    body.Roles,
+		JoinedAt: time.Now().UTC(),
+		Mute:     body.Mute,
+		Deaf:     body.Deaf,
+	}
+	if member.Roles == nil {
+		member.Roles = []discord.Snowflake{}
+	}
+	c.s.putMember(guild.ID, member)
+	c.status = http.StatusCreated
+	return member, nil
+}
+
+func (c *call) patchMember() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	member, err := c.member()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.PatchGuildMemberJSONBody
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	for i, roleID := range body.Roles {
+		if !slices.ContainsFunc(guild.Roles, func(r payloads.Role) bool { return r.ID == roleID }) {
+			return nil, invalidForm(rest.FieldError{Path: "roles." + strconv.Itoa(i), Code: "UNKNOWN_ROLE", Message: "Unknown role"})
+		}
+	}
+	if err := c.patch(member); err != nil {
+		return nil, err
+	}
+	return *member, nil
+}
+
+func (c *call) deleteMember() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.member(); err != nil {
+		return nil, err
+	}
+	c.s.members[guild.ID] = slices.DeleteFunc(c.s.members[guild.ID], func(m payloads.GuildMember) bool {
+		return m.User.ID == c.id("user.id")
+	})
+	return nil, nil
+}
+
+func (c *call) putMemberRole() (any, error) {
+	return c.updateMemberRoles(func(roles []discord.Snowflake, roleID discord.Snowflake) []discord.Snowflake {
+		if slices.Contains(roles, roleID) {
+			return roles
+		}
+		return append(roles, roleID)
+	})
+}
+
+func (c *call) deleteMemberRole() (any, error) {
+	return c.updateMemberRoles(func(roles []discord.Snowflake, roleID discord.Snowflake) []discord.Snowflake {
+		return slices.DeleteFunc(roles, func(id discord.Snowflake) bool { return id == roleID })
+	})
+}
+
+func (c *call) updateMemberRoles(update func(roles []discord.Snowflake, roleID discord.Snowflake) []discord.Snowflake) (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	member, err := c.member()
+	if err != nil {
+		return nil, err
+	}
+	i, err := c.role(guild)
+	if err != nil {
+		return nil, err
+	}
+	member.Roles = update(member.Roles, guild.Roles[i].ID)
+	return nil, nil
+}
+
+// Webhooks
+
+func (c *call) getChannelWebhooks() (any, error) {
+	channel, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	return c.s.webhooksWhere(func(w *payloads.Webhook) bool { return w.ChannelID == channel.ID }), nil
+}
+
+func (c *call) getGuildWebhooks() (any, error) {
+	guild, err := c.guild()
+	if err != nil {
+		return nil, err
+	}
+	return c.s.webhooksWhere(func(w *payloads.Webhook) bool { return w.GuildID != nil && *w.GuildID == guild.ID }), nil
+}
+
+// webhooksWhere returns the webhooks matching keep, ordered by id.
+func (s *Server) webhooksWhere(keep func(*payloads.Webhook) bool) []payloads.Webhook {
+	webhooks := []payloads.Webhook{}
+	for _, webhook := range s.webhooks {
+		if keep(webhook) {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	slices.SortFunc(webhooks, func(a, b payloads.Webhook) int { return compareIDs(a.ID, b.ID) })
+	return webhooks
+}
+
+func (c *call) postWebhook() (any, error) {
+	channel, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.CreateWebhookRequest
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if err := checkLength("name", body.Name, 1, 80); err != nil {
+		return nil, err
+	}
+
+	bot := c.s.bot
+	id := c.s.newID()
+	webhook := payloads.Webhook{
+		ID:        id,
+		Type:      payloads.WebhookTypeIncoming,
+		GuildID:   channel.GuildID,
+		ChannelID: channel.ID,
+		User:      &bot,
+		Name:      rest.NewString(body.Name),
+		Avatar:    body.Avatar,
+		Token:     rest.NewString("token-" + id.String()),
+	}
+	c.s.webhooks[webhook.ID] = &webhook
+	return webhook, nil
+}
+
+func (c *call) getWebhook() (any, error) {
+	webhook, err := c.webhook()
+	if err != nil {
+		return nil, err
+	}
+	return c.webhookResult(*webhook), nil
+}
+
+func (c *call) patchWebhook() (any, error) {
+	webhook, err := c.webhook()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.ModifyWebhookRequest
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Name != nil {
+		if err := checkLength("name", *body.Name, 1, 80); err != nil {
+			return nil, err
+		}
+		webhook.Name = body.Name
+	}
+	if body.Avatar != nil {
+		webhook.Avatar = body.Avatar
+	}
+	// Webhooks can only be moved with the bot token.
+	if _, withToken := c.match.Params["webhook.token"]; body.ChannelID != nil && !withToken {
+		if c.s.channels[*body.ChannelID] == nil {
+			return nil, notFound(rest.UnknownChannel)
+		}
+		webhook.ChannelID = *body.ChannelID
+	}
+	return c.webhookResult(*webhook), nil
+}
+
+// webhookResult returns webhook as returned by the route: without its user
+// when the route is authorized by the token.
+func (c *call) webhookResult(webhook payloads.Webhook) payloads.Webhook {
+	if _, withToken := c.match.Params["webhook.token"]; withToken {
+		webhook.User = nil
+	}
+	return webhook
+}
+
+func (c *call) deleteWebhook() (any, error) {
+	webhook, err := c.webhook()
+	if err != nil {
+		return nil, err
+	}
+	delete(c.s.webhooks, webhook.ID)
+	return nil, nil
+}
+
+func (c *call) executeWebhook() (any, error) {
+	webhook, err := c.webhook()
+	if err != nil {
+		return nil, err
+	}
+	var body rest.ExecuteWebhookRequest
+	if err := c.decode(&body); err != nil {
+		return nil, err
+	}
+	content := ""
+	if body.Content != nil {
+		content = *body.Content
+	}
+	parts := len(body.Embeds) + len(body.Components) + len(c.req.Files)
+	if body.Poll != nil {
+		parts++
+	}
+	if err := checkMessage(content, parts); err != nil {
+		return nil, err
+	}
+	if c.s.channels[webhook.ChannelID] == nil {
+		return nil, notFound(rest.UnknownChannel)
+	}
+
+	author := payloads.User{ID: webhook.ID, Discriminator: "0000", Bot: rest.NewBool(true)}
+	if webhook.Name != nil {
+		author.Username = *webhook.Name
+	}
+	if body.Username != nil {
+		author.Username = *body.Username
+	}
+	message := payloads.Message{
+		ID:         c.s.newID(),
+		ChannelID:  webhook.ChannelID,
+		Author:     author,
+		Content:    content,
+		Embeds:     body.Embeds,
+		Components: body.Components,
+		Flags:      body.Flags,
+		WebhookID:  rest.NewSnowflake(webhook.ID),
+		Timestamp:  time.Now().UTC(),
+	}
+	if body.TTS != nil {
+		message.TTS = *body.TTS
+	}
+	message.Attachments = c.attachments(message.ChannelID, message.ID, body.Attachments)
+	c.s.putMessage(message)
+
+	if c.req.Query.Get("wait") != "true" {
+		return nil, nil
+	}
+	return c.s.messages[message.ChannelID][mustFind(c.s.findMessage(message.ChannelID, message.ID))], nil
+}
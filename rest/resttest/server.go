@@ -0,0 +1,358 @@
+// Package resttest provides an in-memory fake of the Discord REST API, for
+// tests of code that uses the rest package.
+//
+// A Server serves the routes built by rest.RouteBuilder for guilds,
+// channels, messages, roles, members and webhooks from an in-memory model.
+// It responds with the Result shapes of the routes and with the JSON error
+// codes of the real API, such as rest.UnknownMessage, and records every
+// request for assertions:
+//
+//	srv := resttest.NewServer()
+//	defer srv.Close()
+//	guild := srv.AddGuild(payloads.Guild{PartialGuild: payloads.PartialGuild{Name: "Test"}})
+//	channel := srv.AddChannel(guild.ID, payloads.GuildTextChannel{})
+//
+//	client := srv.Client(rest.ClientConfig{})
+//	// Run the code under test with client...
+//
+//	req := srv.AssertRequested(t, http.MethodPost, rest.Routes.ChannelMessages(channel.ID))
+//
+// Routes of other resources respond with 404 Not Found.
+package resttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+	"github.com/kolosys/discord-types/rest"
+	"github.com/kolosys/discord-types/utils"
+)
+
+// Server is a fake Discord REST API. Its methods are safe for concurrent
+// use, including while it serves requests.
+type Server struct {
+	// URL is the base URL of the server, without the API version.
+	URL string
+
+	srv *httptest.Server
+	bot payloads.User
+
+	mu         sync.Mutex
+	lastID     int64
+	guilds     map[discord.Snowflake]*payloads.Guild
+	members    map[discord.Snowflake][]payloads.GuildMember
+	channels   map[discord.Snowflake]*payloads.GuildTextChannel
+	messages   map[discord.Snowflake][]payloads.Message
+	webhooks   map[discord.Snowflake]*payloads.Webhook
+	rateLimits []RateLimit
+	requests   []Request
+}
+
+// NewServer starts a Server. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		guilds:   make(map[discord.Snowflake]*payloads.Guild),
+		members:  make(map[discord.Snowflake][]payloads.GuildMember),
+		channels: make(map[discord.Snowflake]*payloads.GuildTextChannel),
+		messages: make(map[discord.Snowflake][]payloads.Message),
+		webhooks: make(map[discord.Snowflake]*payloads.Webhook),
+	}
+	s.bot = payloads.User{ID: s.newID(), Username: "resttest", Discriminator: "0", Bot: rest.NewBool(true)}
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a client of the server configured by cfg. The base URL is
+// set to the server, and the token defaults to "token".
+func (s *Server) Client(cfg rest.ClientConfig) *rest.Client {
+	cfg.BaseURL = s.URL + "/api/v" + rest.APIVersion
+	if cfg.Token == "" {
+		cfg.Token = "token"
+	}
+	return rest.NewClient(cfg)
+}
+
+// Bot returns the user of the client, which authors the messages it sends.
+func (s *Server) Bot() payloads.User {
+	return s.bot
+}
+
+// newID returns a snowflake for the current time, greater than every id
+// returned before. It must be called with s.mu held, or before s serves.
+func (s *Server) newID() discord.Snowflake {
+	id, _ := utils.SnowflakeFromTime(time.Now()).Int64()
+	s.lastID = max(id, s.lastID+1)
+	return discord.NewSnowflakeFromInt64(s.lastID)
+}
+
+// AddGuild adds a guild, with an @everyone role if it has no roles, and
+// returns it. The guild is given an id if it has none, and the bot as its
+// owner.
+func (s *Server) AddGuild(guild payloads.Guild) payloads.Guild {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if guild.ID == "" {
+		guild.ID = s.newID()
+	}
+	if guild.OwnerID == "" {
+		guild.OwnerID = s.bot.ID
+	}
+	if len(guild.Roles) == 0 {
+		guild.Roles = []payloads.Role{{ID: guild.ID, Name: "@everyone"}}
+	}
+	guild.Roles = slices.Clone(guild.Roles)
+	if guild.Emojis == nil {
+		guild.Emojis = []payloads.Emoji{}
+	}
+	if guild.Features == nil {
+		guild.Features = []payloads.GuildFeature{}
+	}
+	s.guilds[guild.ID] = &guild
+	return guild
+}
+
+// AddChannel adds a channel to a guild, or a channel outside guilds if
+// guildID is empty, and returns it. The channel is given an id if it has
+// none.
+func (s *Server) AddChannel(guildID discord.Snowflake, channel payloads.GuildTextChannel) payloads.GuildTextChannel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if channel.ID == "" {
+		channel.ID = s.newID()
+	}
+	if guildID != "" {
+		channel.GuildID = rest.NewSnowflake(guildID)
+	}
+	s.channels[channel.ID] = &channel
+	return channel
+}
+
+// AddMessage adds a message to its channel and returns it. The message is
+// given an id and a timestamp if it has none, and the bot as its author if
+// it has no author.
+func (s *Server) AddMessage(message payloads.Message) payloads.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if message.ID == "" {
+		message.ID = s.newID()
+	}
+	if message.Author.ID == "" {
+		message.Author = s.bot
+	}
+	s.putMessage(message)
+	return message
+}
+
+// AddRole adds a role to a guild and returns it. The role is given an id if
+// it has none.
+func (s *Server) AddRole(guildID discord.Snowflake, role payloads.Role) payloads.Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if role.ID == "" {
+		role.ID = s.newID()
+	}
+	if guild := s.guilds[guildID]; guild != nil {
+		guild.Roles = append(guild.Roles, role)
+	}
+	return role
+}
+
+// AddMember adds a member to a guild and returns it. The member is given a
+// user if it has none, and a join time if it has none.
+func (s *Server) AddMember(guildID discord.Snowflake, member payloads.GuildMember) payloads.GuildMember {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if member.User == nil {
+		id := s.newID()
+		member.User = &payloads.User{ID: id, Username: "user" + id.String(), Discriminator: "0"}
+	}
+	if member.JoinedAt.IsZero() {
+		member.JoinedAt = time.Now().UTC()
+	}
+	if member.Roles == nil {
+		member.Roles = []discord.Snowflake{}
+	}
+	s.putMember(guildID, member)
+	return member
+}
+
+// AddWebhook adds an incoming webhook to its channel and returns it. The
+// webhook is given an id and a token if it has none.
+func (s *Server) AddWebhook(webhook payloads.Webhook) payloads.Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if webhook.ID == "" {
+		webhook.ID = s.newID()
+	}
+	if webhook.Token == nil {
+		webhook.Token = rest.NewString("token-" + webhook.ID.String())
+	}
+	if webhook.Type == 0 {
+		webhook.Type = payloads.WebhookTypeIncoming
+	}
+	if channel := s.channels[webhook.ChannelID]; channel != nil {
+		webhook.GuildID = channel.GuildID
+	}
+	s.webhooks[webhook.ID] = &webhook
+	return webhook
+}
+
+// Guild returns a guild.
+func (s *Server) Guild(id discord.Snowflake) (payloads.Guild, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deref(s.guilds[id])
+}
+
+// Channel returns a channel.
+func (s *Server) Channel(id discord.Snowflake) (payloads.GuildTextChannel, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deref(s.channels[id])
+}
+
+// Messages returns the messages of a channel, oldest first.
+func (s *Server) Messages(channelID discord.Snowflake) []payloads.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages[channelID])
+}
+
+// Message returns a message of a channel.
+func (s *Server) Message(channelID, messageID discord.Snowflake) (payloads.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.findMessage(channelID, messageID)
+	if !ok {
+		return payloads.Message{}, false
+	}
+	return s.messages[channelID][i], true
+}
+
+// Member returns a member of a guild.
+func (s *Server) Member(guildID, userID discord.Snowflake) (payloads.GuildMember, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.findMember(guildID, userID)
+	if !ok {
+		return payloads.GuildMember{}, false
+	}
+	return s.members[guildID][i], true
+}
+
+// Webhook returns a webhook.
+func (s *Server) Webhook(id discord.Snowflake) (payloads.Webhook, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deref(s.webhooks[id])
+}
+
+func deref[T any](v *T) (T, bool) {
+	if v == nil {
+		var zero T
+		return zero, false
+	}
+	return *v, true
+}
+
+// putMessage adds or replaces a message, keeping the messages of its
+// channel ordered by id.
+func (s *Server) putMessage(message payloads.Message) {
+	if message.Timestamp.IsZero() {
+		message.Timestamp, _ = message.ID.Time()
+	}
+	// The API always returns these arrays, empty or not.
+	if message.Mentions == nil {
+		message.Mentions = []payloads.User{}
+	}
+	if message.MentionRoles == nil {
+		message.MentionRoles = []discord.Snowflake{}
+	}
+	if message.Attachments == nil {
+		message.Attachments = []payloads.Attachment{}
+	}
+	if message.Embeds == nil {
+		message.Embeds = []payloads.Embed{}
+	}
+	if channel := s.channels[message.ChannelID]; channel != nil {
+		message.GuildID = channel.GuildID
+		if channel.LastMessageID == nil || compareIDs(message.ID, *channel.LastMessageID) > 0 {
+			channel.LastMessageID = rest.NewSnowflake(message.ID)
+		}
+	}
+
+	messages := s.messages[message.ChannelID]
+	i, found := slices.BinarySearchFunc(messages, message.ID, func(m payloads.Message, id discord.Snowflake) int {
+		return compareIDs(m.ID, id)
+	})
+	if found {
+		messages[i] = message
+	} else {
+		s.messages[message.ChannelID] = slices.Insert(messages, i, message)
+	}
+}
+
+// putMember adds or replaces a member, keeping the members of the guild
+// ordered by user id.
+func (s *Server) putMember(guildID discord.Snowflake, member payloads.GuildMember) {
+	members := s.members[guildID]
+	i, found := slices.BinarySearchFunc(members, member.User.ID, func(m payloads.GuildMember, id discord.Snowflake) int {
+		return compareIDs(m.User.ID, id)
+	})
+	if found {
+		members[i] = member
+	} else {
+		s.members[guildID] = slices.Insert(members, i, member)
+	}
+}
+
+func (s *Server) findMessage(channelID, messageID discord.Snowflake) (int, bool) {
+	return slices.BinarySearchFunc(s.messages[channelID], messageID, func(m payloads.Message, id discord.Snowflake) int {
+		return compareIDs(m.ID, id)
+	})
+}
+
+func (s *Server) findMember(guildID, userID discord.Snowflake) (int, bool) {
+	return slices.BinarySearchFunc(s.members[guildID], userID, func(m payloads.GuildMember, id discord.Snowflake) int {
+		return compareIDs(m.User.ID, id)
+	})
+}
+
+// compareIDs compares two snowflakes numerically.
+func compareIDs(a, b discord.Snowflake) int {
+	x, _ := strconv.ParseUint(string(a), 10, 64)
+	y, _ := strconv.ParseUint(string(b), 10, 64)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// ServeHTTP implements http.Handler, so that the server can be mounted in
+// another server. Paths are relative to URL, with the API version.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serve(w, r)
+}
@@ -0,0 +1,318 @@
+package resttest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolosys/discord-types/discord"
+	"github.com/kolosys/discord-types/payloads"
+	"github.com/kolosys/discord-types/rest"
+)
+
+func TestHandlers(t *testing.T) {
+	routes := make(map[string]bool)
+	for _, d := range rest.RouteDescriptors() {
+		for _, m := range d.Methods {
+			routes[m.Method+" "+d.Template] = true
+		}
+	}
+	for key := range handlers {
+		if !routes[key] {
+			t.Errorf("handler %q has no route", key)
+		}
+	}
+}
+
+// seed starts a server with a guild, a text channel and a message.
+func seed(t *testing.T) (*Server, payloads.Guild, payloads.GuildTextChannel, payloads.Message) {
+	t.Helper()
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+
+	guild := srv.AddGuild(payloads.Guild{PartialGuild: payloads.PartialGuild{Name: "Test"}})
+	channel := payloads.GuildTextChannel{}
+	channel.Name = "general"
+	channel = srv.AddChannel(guild.ID, channel)
+	message := srv.AddMessage(payloads.Message{ChannelID: channel.ID, Content: "first"})
+	return srv, guild, channel, message
+}
+
+func TestServer_Messages(t *testing.T) {
+	srv, _, channel, first := seed(t)
+	c := srv.Client(rest.ClientConfig{})
+	ctx := context.Background()
+
+	sent, err := rest.Send[rest.PostChannelMessageResult](ctx, c, rest.Request{
+		Method: http.MethodPost,
+		Route:  rest.Routes.ChannelMessages(channel.ID),
+		Body: rest.PostChannelMessageJSONBody{
+			Content:          rest.NewString("reply"),
+			MessageReference: &payloads.MessageReference{MessageID: rest.NewSnowflake(first.ID)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if sent.Author.ID != srv.Bot().ID || sent.Type != payloads.MessageTypeReply || sent.ReferencedMessage == nil || sent.ReferencedMessage.ID != first.ID {
+		t.Errorf("sent = %+v", sent)
+	}
+	if got, _ := srv.Channel(channel.ID); got.LastMessageID == nil || *got.LastMessageID != sent.ID {
+		t.Errorf("last message id = %v, want %s", got.LastMessageID, sent.ID)
+	}
+
+	edited, err := rest.Send[rest.PatchChannelMessageResult](ctx, c, rest.Request{
+		Method: http.MethodPatch,
+		Route:  rest.Routes.ChannelMessage(channel.ID, sent.ID),
+		Body:   rest.PatchChannelMessageJSONBody{Content: rest.NewString("edited")},
+	})
+	if err != nil || edited.Content != "edited" || edited.EditedTimestamp == nil {
+		t.Errorf("edit = %+v, %v", edited, err)
+	}
+
+	messages, err := rest.Send[rest.GetChannelMessagesResult](ctx, c, rest.Request{
+		Method: http.MethodGet,
+		Route:  rest.Routes.ChannelMessages(channel.ID),
+	})
+	if err != nil || len(messages) != 2 || messages[0].ID != sent.ID || messages[1].ID != first.ID {
+		t.Errorf("messages = %+v, %v, want newest first", messages, err)
+	}
+
+	if err := c.Do(ctx, rest.Request{Method: http.MethodDelete, Route: rest.Routes.ChannelMessage(channel.ID, sent.ID)}, nil); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	if _, ok := srv.Message(channel.ID, sent.ID); ok {
+		t.Error("message not deleted")
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv, guild, channel, message := seed(t)
+	other := srv.AddMessage(payloads.Message{ChannelID: channel.ID, Author: payloads.User{ID: "1"}, Content: "other"})
+	c := srv.Client(rest.ClientConfig{Retry: rest.RetryPolicy{MaxAttempts: 1}})
+
+	tests := []struct {
+		name   string
+		req    rest.Request
+		status int
+		code   rest.RESTJSONErrorCode
+		path   string
+	}{
+		{
+			name:   "Unknown message",
+			req:    rest.Request{Method: http.MethodGet, Route: rest.Routes.ChannelMessage(channel.ID, "1")},
+			status: http.StatusNotFound,
+			code:   rest.UnknownMessage,
+		},
+		{
+			name:   "Unknown channel",
+			req:    rest.Request{Method: http.MethodGet, Route: rest.Routes.ChannelMessage("1", message.ID)},
+			status: http.StatusNotFound,
+			code:   rest.UnknownChannel,
+		},
+		{
+			name:   "Unknown role",
+			req:    rest.Request{Method: http.MethodDelete, Route: rest.Routes.GuildRole(guild.ID, "1")},
+			status: http.StatusNotFound,
+			code:   rest.UnknownRole,
+		},
+		{
+			name:   "Empty message",
+			req:    rest.Request{Method: http.MethodPost, Route: rest.Routes.ChannelMessages(channel.ID), Body: rest.PostChannelMessageJSONBody{}},
+			status: http.StatusBadRequest,
+			code:   rest.CannotSendAnEmptyMessage,
+		},
+		{
+			name: "Content too long",
+			req: rest.Request{Method: http.MethodPost, Route: rest.Routes.ChannelMessages(channel.ID),
+				Body: rest.PostChannelMessageJSONBody{Content: rest.NewString(strings.Repeat("a", discord.MaxMessageLength+1))}},
+			status: http.StatusBadRequest,
+			code:   rest.InvalidFormBodyOrContentType,
+			path:   "content",
+		},
+		{
+			name:   "Limit out of range",
+			req:    rest.Request{Method: http.MethodGet, Route: rest.Routes.ChannelMessages(channel.ID), Query: rest.GetMessagesQuery{Limit: rest.NewLimit(101)}},
+			status: http.StatusBadRequest,
+			code:   rest.InvalidFormBodyOrContentType,
+			path:   "limit",
+		},
+		{
+			name: "Message of another user",
+			req: rest.Request{Method: http.MethodPatch, Route: rest.Routes.ChannelMessage(channel.ID, other.ID),
+				Body: rest.PatchChannelMessageJSONBody{Content: rest.NewString("edited")}},
+			status: http.StatusForbidden,
+			code:   rest.CannotEditAMessageAuthoredByAnotherUser,
+		},
+		{
+			name:   "Delete @everyone",
+			req:    rest.Request{Method: http.MethodDelete, Route: rest.Routes.GuildRole(guild.ID, guild.ID)},
+			status: http.StatusBadRequest,
+			code:   rest.InvalidRole,
+		},
+		{
+			name:   "Unmodelled route",
+			req:    rest.Request{Method: http.MethodGet, Route: rest.Routes.GuildEmojis(guild.ID)},
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Do(context.Background(), tt.req, nil)
+			var restErr rest.RESTError
+			if !errors.As(err, &restErr) {
+				t.Fatalf("Do() error = %v, want a RESTError", err)
+			}
+			if restErr.Status != tt.status || restErr.Code != tt.code {
+				t.Errorf("Do() error = %+v, want %d %d", restErr, tt.status, tt.code)
+			}
+			if tt.code != 0 && !errors.Is(err, tt.code) {
+				t.Errorf("errors.Is(%v, %d) = false", err, tt.code)
+			}
+			if tt.path != "" && (len(restErr.Errors) != 1 || restErr.Errors[0].Path != tt.path) {
+				t.Errorf("Do() errors = %+v, want one at %s", restErr.Errors, tt.path)
+			}
+		})
+	}
+}
+
+func TestServer_InjectRateLimit(t *testing.T) {
+	srv, _, channel, message := seed(t)
+	var attempts []rest.RetryAttempt
+	c := srv.Client(rest.ClientConfig{Retry: rest.RetryPolicy{
+		OnAttempt: func(a rest.RetryAttempt) { attempts = append(attempts, a) },
+	}})
+	srv.InjectRateLimit(RateLimit{
+		Method:     http.MethodGet,
+		Route:      "/channels/{channel.id}/messages/{message.id}",
+		RetryAfter: 10 * time.Millisecond,
+	})
+
+	route := rest.Routes.ChannelMessage(channel.ID, message.ID)
+	got, err := rest.Send[rest.GetChannelMessageResult](context.Background(), c, rest.Request{Method: http.MethodGet, Route: route})
+	if err != nil || got.ID != message.ID {
+		t.Fatalf("Send() = %+v, %v", got, err)
+	}
+	if len(attempts) != 2 || attempts[0].Delay != 10*time.Millisecond {
+		t.Errorf("attempts = %+v, want a retry after 10ms", attempts)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 2 || requests[0].Status != http.StatusTooManyRequests || requests[1].Status != http.StatusOK {
+		t.Fatalf("requests = %+v", requests)
+	}
+	if h := requests[0].Header; h.Get("Authorization") != "Bot token" {
+		t.Errorf("Authorization = %q", h.Get("Authorization"))
+	}
+	srv.AssertRequestCount(t, http.MethodGet, route, 2)
+}
+
+func TestServer_Assertions(t *testing.T) {
+	srv, guild, _, _ := seed(t)
+	c := srv.Client(rest.ClientConfig{})
+
+	role, err := rest.Send[rest.PostGuildRoleResult](context.Background(), c, rest.Request{
+		Method: http.MethodPost,
+		Route:  rest.Routes.GuildRoles(guild.ID),
+		Body:   rest.PostGuildRoleJSONBody{Name: rest.NewString("Moderators")},
+		Reason: "Modérateurs nécessaires",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	req := srv.AssertRequested(t, http.MethodPost, "/guilds/{guild.id}/roles")
+	var body rest.PostGuildRoleJSONBody
+	if err := req.DecodeBody(&body); err != nil || body.Name == nil || *body.Name != "Moderators" {
+		t.Errorf("body = %+v, %v", body, err)
+	}
+	if req.Route.Params["guild.id"] != guild.ID.String() || req.Reason != "Modérateurs nécessaires" {
+		t.Errorf("request = %+v", req)
+	}
+	if got, _ := srv.Guild(guild.ID); len(got.Roles) != 2 || got.Roles[1].ID != role.ID {
+		t.Errorf("roles = %+v", got.Roles)
+	}
+	srv.AssertNotRequested(t, http.MethodDelete, rest.Routes.GuildRole(guild.ID, role.ID))
+
+	srv.ResetRequests()
+	if requests := srv.Requests(); len(requests) != 0 {
+		t.Errorf("requests = %+v after reset", requests)
+	}
+}
+
+func TestServer_Members(t *testing.T) {
+	srv, guild, _, _ := seed(t)
+	role := srv.AddRole(guild.ID, payloads.Role{Name: "Members"})
+	c := srv.Client(rest.ClientConfig{})
+	ctx := context.Background()
+	const userID = discord.Snowflake("80351110224678912")
+
+	err := c.Do(ctx, rest.Request{
+		Method: http.MethodPut,
+		Route:  rest.Routes.GuildMember(guild.ID, userID.String()),
+		Body:   rest.PutGuildMemberJSONBody{AccessToken: "oauth"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("add member error = %v", err)
+	}
+	if req := srv.AssertRequested(t, http.MethodPut, "/guilds/{guild.id}/members/{user.id}"); req.Status != http.StatusCreated {
+		t.Errorf("add member status = %d, want 201", req.Status)
+	}
+
+	if err := c.Do(ctx, rest.Request{Method: http.MethodPut, Route: rest.Routes.GuildMemberRole(guild.ID, userID, role.ID)}, nil); err != nil {
+		t.Fatalf("add role error = %v", err)
+	}
+	members, err := rest.Send[rest.GetGuildMembersResult](ctx, c, rest.Request{
+		Method: http.MethodGet,
+		Route:  rest.Routes.GuildMembers(guild.ID),
+		Query:  rest.GetGuildMembersQuery{Limit: rest.NewLimit(10)},
+	})
+	if err != nil || len(members) != 1 || members[0].User.ID != userID || len(members[0].Roles) != 1 || members[0].Roles[0] != role.ID {
+		t.Errorf("members = %+v, %v", members, err)
+	}
+
+	if err := c.Do(ctx, rest.Request{Method: http.MethodDelete, Route: rest.Routes.GuildRole(guild.ID, role.ID)}, nil); err != nil {
+		t.Fatalf("delete role error = %v", err)
+	}
+	if member, _ := srv.Member(guild.ID, userID); len(member.Roles) != 0 {
+		t.Errorf("member roles = %v after deleting the role", member.Roles)
+	}
+}
+
+func TestServer_Webhooks(t *testing.T) {
+	srv, _, channel, _ := seed(t)
+	webhook := srv.AddWebhook(payloads.Webhook{ChannelID: channel.ID, Name: rest.NewString("Captain Hook")})
+	c := srv.Client(rest.ClientConfig{})
+	ctx := context.Background()
+
+	message, err := rest.Send[payloads.Message](ctx, c, rest.Request{
+		Method: http.MethodPost,
+		Route:  rest.Routes.Webhook(webhook.ID, *webhook.Token),
+		Query:  url.Values{"wait": {"true"}},
+		Body:   rest.ExecuteWebhookRequest{Content: rest.NewString("Ahoy")},
+		Files:  []rest.File{{Name: "map.txt", Reader: strings.NewReader("X marks the spot")}},
+	})
+	if err != nil {
+		t.Fatalf("execute error = %v", err)
+	}
+	if message.WebhookID == nil || *message.WebhookID != webhook.ID || message.Author.Username != "Captain Hook" {
+		t.Errorf("message = %+v", message)
+	}
+	if len(message.Attachments) != 1 || message.Attachments[0].Filename != "map.txt" || message.Attachments[0].Size != 16 {
+		t.Errorf("attachments = %+v", message.Attachments)
+	}
+	req := srv.AssertRequested(t, http.MethodPost, "/webhooks/{webhook.id}/{webhook.token}")
+	if len(req.Files) != 1 || string(req.Files[0].Data) != "X marks the spot" {
+		t.Errorf("files = %+v", req.Files)
+	}
+
+	err = c.Do(ctx, rest.Request{Method: http.MethodGet, Route: rest.Routes.Webhook(webhook.ID, "wrong")}, nil)
+	if !errors.Is(err, rest.InvalidWebhookTokenProvided) {
+		t.Errorf("wrong token error = %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+// Package rest provides Discord REST API types and utilities.
+//
+// This file contains the retry policy of the client for transient
+// failures.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Retry defaults.
+const (
+	// DefaultMaxAttempts is the number of attempts of a request, including
+	// the first.
+	DefaultMaxAttempts = 3
+
+	// DefaultMinBackoff is the wait before the first retry, before jitter.
+	DefaultMinBackoff = 250 * time.Millisecond
+
+	// DefaultMaxBackoff caps the wait between attempts, before jitter.
+	DefaultMaxBackoff = 10 * time.Second
+)
+
+// RetryPolicy configures how a Client retries requests that fail
+// transiently. The zero RetryPolicy uses the defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a request, including the
+	// first. Defaults to DefaultMaxAttempts; set it to 1 to disable retries.
+	MaxAttempts int
+
+	// MinBackoff is the wait before the first retry, doubled for each
+	// further retry up to MaxBackoff. Each wait is jittered between half
+	// and all of its value. Defaults to DefaultMinBackoff and
+	// DefaultMaxBackoff. Responses with a Retry-After wait exactly as long
+	// instead.
+	MinBackoff, MaxBackoff time.Duration
+
+	// ShouldRetry reports whether a request that failed with err may be
+	// sent again. Defaults to DefaultShouldRetry.
+	ShouldRetry func(req Request, err error) bool
+
+	// OnAttempt, if set, is called after every attempt of a request, for
+	// metrics.
+	OnAttempt func(RetryAttempt)
+}
+
+// RetryAttempt describes an attempt of a request, as reported to
+// RetryPolicy.OnAttempt.
+type RetryAttempt struct {
+	// Method and Route are those of the request.
+	Method, Route string
+
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+
+	// Err is the error of the attempt, or nil if it succeeded.
+	Err error
+
+	// Duration is the time the attempt took, including any wait for the
+	// rate limiter.
+	Duration time.Duration
+
+	// Retry reports whether the request is sent again, after Delay.
+	Retry bool
+	Delay time.Duration
+}
+
+// DefaultShouldRetry reports whether req may be sent again after it failed
+// with err. It retries:
+//
+//   - 429 responses, which Discord rejected without processing.
+//   - Server errors, overloaded resources and network errors, for
+//     idempotent methods (GET, HEAD, OPTIONS, PUT and DELETE) and for POSTs
+//     of a message with a nonce and enforce_nonce, which Discord does not
+//     create twice. Other POSTs and PATCHes may have taken effect, and are
+//     not retried.
+//
+// Canceled and timed out contexts are never retried.
+func DefaultShouldRetry(req Request, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var restErr RESTError
+	if errors.As(err, &restErr) {
+		if restErr.Status == http.StatusTooManyRequests {
+			return true
+		}
+		if !IsRetryable(err) {
+			return false
+		}
+	} else if urlErr := (*url.Error)(nil); !errors.As(err, &urlErr) {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return enforcesNonce(req.Body)
+	}
+	return false
+}
+
+// enforcesNonce reports whether body has a nonce and enforce_nonce.
+func enforcesNonce(body any) bool {
+	if body == nil {
+		return false
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return false
+	}
+	var message struct {
+		Nonce        any  `json:"nonce"`
+		EnforceNonce bool `json:"enforce_nonce"`
+	}
+	return json.Unmarshal(data, &message) == nil && message.Nonce != nil && message.EnforceNonce
+}
+
+// withDefaults returns p with its unset fields set to the defaults.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.MinBackoff <= 0 {
+		p.MinBackoff = DefaultMinBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	if p.ShouldRetry == nil {
+		p.ShouldRetry = DefaultShouldRetry
+	}
+	return p
+}
+
+// delay returns the wait before the retry following attempt, which failed
+// with err.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var restErr RESTError
+	if errors.As(err, &restErr) && restErr.RetryAfter > 0 {
+		return restErr.RetryAfter
+	}
+
+	backoff := p.MaxBackoff
+	if shift := attempt - 1; shift < 32 && p.MinBackoff<<shift < p.MaxBackoff {
+		backoff = p.MinBackoff << shift
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// rewindFiles returns a function that seeks the readers of files back to
+// their current offsets, or false if one of them cannot seek, in which
+// case the files cannot be sent again.
+func rewindFiles(files []File) (func() error, bool) {
+	seekers := make([]io.Seeker, len(files))
+	offsets := make([]int64, len(files))
+	for i, f := range files {
+		seeker, ok := f.Reader.(io.Seeker)
+		if !ok {
+			return nil, false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, false
+		}
+		seekers[i], offsets[i] = seeker, offset
+	}
+	return func() error {
+		for i, seeker := range seekers {
+			if _, err := seeker.Seek(offsets[i], io.SeekStart); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, true
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,233 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is an http.RoundTripper implemented by a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// fastRetries retries with short waits, recording the attempts.
+func fastRetries(attempts *[]RetryAttempt) RetryPolicy {
+	return RetryPolicy{
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 4 * time.Millisecond,
+		OnAttempt:  func(a RetryAttempt) { *attempts = append(*attempts, a) },
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      Request
+		failures []int
+		attempts int
+		wantErr  bool
+	}{
+		{
+			name:     "GET after gateway errors",
+			req:      Request{Method: http.MethodGet, Route: Routes.ChannelMessage(channelID, messageID)},
+			failures: []int{http.StatusBadGateway, http.StatusInternalServerError},
+			attempts: 3,
+		},
+		{
+			name:     "Attempts exhausted",
+			req:      Request{Method: http.MethodDelete, Route: Routes.ChannelMessage(channelID, messageID)},
+			failures: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			attempts: 3,
+			wantErr:  true,
+		},
+		{
+			name:     "Client error",
+			req:      Request{Method: http.MethodGet, Route: Routes.ChannelMessage(channelID, messageID)},
+			failures: []int{http.StatusNotFound},
+			attempts: 1,
+			wantErr:  true,
+		},
+		{
+			name: "POST without nonce",
+			req: Request{Method: http.MethodPost, Route: Routes.ChannelMessages(channelID),
+				Body: PostChannelMessageJSONBody{Content: NewString("Hello"), Nonce: NewString("1")}},
+			failures: []int{http.StatusInternalServerError},
+			attempts: 1,
+			wantErr:  true,
+		},
+		{
+			name: "POST with enforced nonce",
+			req: Request{Method: http.MethodPost, Route: Routes.ChannelMessages(channelID),
+				Body: PostChannelMessageJSONBody{Content: NewString("Hello"), Nonce: NewString("1"), EnforceNonce: NewBool(true)}},
+			failures: []int{http.StatusInternalServerError},
+			attempts: 2,
+		},
+		{
+			name:     "PATCH",
+			req:      Request{Method: http.MethodPatch, Route: Routes.ChannelMessage(channelID, messageID)},
+			failures: []int{http.StatusBadGateway},
+			attempts: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "Rate limited POST",
+			req:      Request{Method: http.MethodPost, Route: Routes.ChannelMessages(channelID)},
+			failures: []int{http.StatusTooManyRequests},
+			attempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			var attempts []RetryAttempt
+			c := newTestClient(t, ClientConfig{Token: "token", Retry: fastRetries(&attempts)}, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= len(tt.failures) {
+					if status := tt.failures[requests-1]; status == http.StatusTooManyRequests {
+						w.Header().Set("Retry-After", "0")
+						w.WriteHeader(status)
+						io.WriteString(w, `{"message":"You are being rate limited.","retry_after":0.005,"global":false}`)
+					} else {
+						w.WriteHeader(status)
+					}
+					return
+				}
+				io.WriteString(w, `{"id":"334385199974967042"}`)
+			})
+
+			message, err := Send[GetChannelMessageResult](context.Background(), c, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && message.ID != messageID {
+				t.Errorf("Send() = %+v", message)
+			}
+			if requests != tt.attempts || len(attempts) != tt.attempts {
+				t.Fatalf("requests = %d, attempts = %+v, want %d", requests, attempts, tt.attempts)
+			}
+			for i, a := range attempts {
+				last := i == len(attempts)-1
+				if a.Attempt != i+1 || a.Retry == last || a.Method != tt.req.Method || a.Route != tt.req.Route {
+					t.Errorf("attempt %d = %+v", i, a)
+				}
+				// Rate limits wait for their retry_after instead of the backoff.
+				if !last && (a.Delay <= 0 || a.Delay > 5*time.Millisecond) {
+					t.Errorf("attempt %d delay = %v", i, a.Delay)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	var attempts []RetryAttempt
+	requests := 0
+	c := newTestClient(t, ClientConfig{Token: "token", Retry: fastRetries(&attempts)}, func(w http.ResponseWriter, r *http.Request) {
+		if requests++; requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			io.WriteString(w, `{"message":"You are being rate limited.","retry_after":0.05,"global":false}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := c.Do(context.Background(), Request{Method: http.MethodGet, Route: Routes.Gateway()}, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(attempts) != 2 || attempts[0].Delay != 50*time.Millisecond {
+		t.Errorf("attempts = %+v, want a retry after 50ms", attempts)
+	}
+
+	t.Run("Deadline", func(t *testing.T) {
+		attempts, requests = nil, 0
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := c.Do(ctx, Request{Method: http.MethodGet, Route: Routes.Gateway()}, nil)
+		var restErr RESTError
+		if !errors.As(err, &restErr) || restErr.Status != http.StatusTooManyRequests || restErr.RetryAfter != 50*time.Millisecond {
+			t.Errorf("Do() error = %v, want the 429", err)
+		}
+		if requests != 1 || len(attempts) != 1 || attempts[0].Retry {
+			t.Errorf("requests = %d, attempts = %+v, want no retry past the deadline", requests, attempts)
+		}
+	})
+}
+
+func TestClient_RetryNetworkError(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		if req.Body != nil {
+			data, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(data))
+		}
+		if calls.Add(1) == 1 {
+			return nil, errors.New("connection reset by peer")
+		}
+		return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}}, nil
+	})
+
+	var attempts []RetryAttempt
+	c := NewClient(ClientConfig{
+		Token:      "token",
+		BaseURL:    "https://discord.test/api/v10",
+		HTTPClient: &http.Client{Transport: transport},
+		Retry:      fastRetries(&attempts),
+	})
+
+	err := c.Do(context.Background(), Request{
+		Method: http.MethodPut,
+		Route:  Routes.ChannelMessages(channelID),
+		Files:  []File{{Name: "a.txt", Reader: bytes.NewReader([]byte("attached"))}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(attempts) != 2 || attempts[0].Err == nil {
+		t.Errorf("attempts = %+v, want a retry of the network error", attempts)
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[0], "\r\n\r\nattached\r\n") || !strings.Contains(bodies[1], "\r\n\r\nattached\r\n") {
+		t.Errorf("bodies = %q, want the file sent twice", bodies)
+	}
+
+	t.Run("Unseekable files", func(t *testing.T) {
+		calls.Store(0)
+		attempts = nil
+		err := c.Do(context.Background(), Request{
+			Method: http.MethodPut,
+			Route:  Routes.ChannelMessages(channelID),
+			Files:  []File{{Name: "a.txt", Reader: unsized{strings.NewReader("attached")}}},
+		}, nil)
+		if err == nil || len(attempts) != 1 {
+			t.Errorf("Do() error = %v, attempts = %+v, want one failed attempt", err, attempts)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts = nil
+		if err := c.Do(ctx, Request{Method: http.MethodGet, Route: Routes.Gateway()}, nil); !errors.Is(err, context.Canceled) {
+			t.Errorf("Do() error = %v, want context.Canceled", err)
+		}
+		if len(attempts) != 1 {
+			t.Errorf("attempts = %+v, want no retry", attempts)
+		}
+	})
+}
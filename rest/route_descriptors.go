@@ -0,0 +1,1056 @@
+// Package rest provides Discord REST API types and utilities.
+//
+// This file contains route descriptors, which describe the template,
+// methods and rate limit parameters of every route built by RouteBuilder.
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/kolosys/discord-types/payloads"
+)
+
+// RouteMethod describes an HTTP method of a route.
+type RouteMethod struct {
+	// Method is the HTTP method, such as http.MethodGet.
+	Method string
+
+	// Query is the type of the query struct, such as GetMessagesQuery, or
+	// nil if the method takes no query.
+	Query reflect.Type
+
+	// Body is the type of the JSON body, or nil if the method takes no
+	// body or the package has no type for it.
+	Body reflect.Type
+
+	// Result is the type of the response body, or nil if the method
+	// responds with 204 No Content or the package has no type for it.
+	Result reflect.Type
+
+	// AuditLogReason reports whether the method records the
+	// X-Audit-Log-Reason header, sent for Request.Reason, in the audit log
+	// of the guild.
+	AuditLogReason bool
+
+	// Multipart reports whether the method accepts a multipart/form-data
+	// body with files.
+	Multipart bool
+
+	// Auth reports whether the method needs the Authorization header.
+	// Webhook and interaction routes with a token in the path do not.
+	Auth bool
+}
+
+// RouteDescriptor describes a route built by RouteBuilder.
+type RouteDescriptor struct {
+	// Name is the name of the RouteBuilder method that builds the route.
+	// Methods that build several templates, such as Webhook with and
+	// without a token, have a descriptor for each of them.
+	Name string
+
+	// Template is the path of the route with its parameters in braces, as
+	// in the Discord documentation: /channels/{channel.id}/messages.
+	Template string
+
+	// Methods are the HTTP methods of the route.
+	Methods []RouteMethod
+
+	// MajorParameters are the parameters of Template that Discord keeps
+	// separate rate limits for, if any.
+	//
+	// See: https://discord.com/developers/docs/topics/rate-limits#rate-limits
+	MajorParameters []string
+
+	segments []string
+}
+
+// Method returns the description of method on d.
+func (d RouteDescriptor) Method(method string) (RouteMethod, bool) {
+	i := slices.IndexFunc(d.Methods, func(m RouteMethod) bool { return m.Method == method })
+	if i < 0 {
+		return RouteMethod{}, false
+	}
+	return d.Methods[i], true
+}
+
+// RouteDescriptors returns the descriptors of every route built by
+// RouteBuilder.
+func RouteDescriptors() []RouteDescriptor {
+	return slices.Clone(routeDescriptors)
+}
+
+// RouteMatch is a route descriptor matched by a concrete path.
+type RouteMatch struct {
+	RouteDescriptor
+
+	// Params maps the parameters of the template, without braces, to their
+	// unescaped values in the path.
+	Params map[string]string
+}
+
+// Major returns the values of the major parameters of m joined by "/", or
+// "" if the route has none.
+func (m RouteMatch) Major() string {
+	values := make([]string, len(m.MajorParameters))
+	for i, param := range m.MajorParameters {
+		values[i] = m.Params[param]
+	}
+	return strings.Join(values, "/")
+}
+
+// MatchRoute returns the descriptor of the route of path, which may be a
+// route as returned by RouteBuilder, a path with the /api/v10 prefix or a
+// full URL. Literal segments take precedence over parameters, so
+// /users/@me matches the template /users/@me rather than /users/{user.id}.
+//
+// The interaction followup routes share their templates with the webhook
+// routes, and match those.
+func MatchRoute(path string) (RouteMatch, bool) {
+	segments := pathSegments(path)
+
+	var best *RouteDescriptor
+	for i := range routeDescriptors {
+		d := &routeDescriptors[i]
+		if matchSegments(d.segments, segments) && (best == nil || moreSpecific(d.segments, best.segments)) {
+			best = d
+		}
+	}
+	if best == nil {
+		return RouteMatch{}, false
+	}
+
+	m := RouteMatch{RouteDescriptor: *best, Params: make(map[string]string)}
+	for i, segment := range best.segments {
+		if param, ok := templateParam(segment); ok {
+			value, err := url.PathUnescape(segments[i])
+			if err != nil {
+				value = segments[i]
+			}
+			m.Params[param] = value
+		}
+	}
+	return m, true
+}
+
+// pathSegments returns the segments of path after the API prefix.
+func pathSegments(path string) []string {
+	if u, err := url.Parse(path); err == nil {
+		path = u.EscapedPath()
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[0] == "api" {
+		segments = segments[1:]
+		if len(segments) > 0 && strings.HasPrefix(segments[0], "v") {
+			segments = segments[1:]
+		}
+	}
+	if len(segments) == 1 && segments[0] == "" {
+		return nil
+	}
+	return segments
+}
+
+func matchSegments(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, segment := range template {
+		if _, ok := templateParam(segment); ok {
+			if segments[i] == "" {
+				return false
+			}
+		} else if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// moreSpecific reports whether template a has a literal segment where b
+// has a parameter, before the other way around.
+func moreSpecific(a, b []string) bool {
+	for i := range a {
+		_, aParam := templateParam(a[i])
+		_, bParam := templateParam(b[i])
+		if aParam != bParam {
+			return bParam
+		}
+	}
+	return false
+}
+
+func templateParam(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// none marks the absence of a query, body or result type.
+type none struct{}
+
+// routeFlags are the flags of a RouteMethod.
+type routeFlags uint8
+
+const (
+	// reason marks methods that accept X-Audit-Log-Reason.
+	reason routeFlags = 1 << iota
+
+	// files marks methods that accept multipart bodies.
+	files
+
+	// noAuth marks methods that do not need the Authorization header.
+	noAuth
+)
+
+// method returns a RouteMethod with the given query, body and result types.
+func method[Query, Body, Result any](name string, flags routeFlags) RouteMethod {
+	return RouteMethod{
+		Method:         name,
+		Query:          typeOf[Query](),
+		Body:           typeOf[Body](),
+		Result:         typeOf[Result](),
+		AuditLogReason: flags&reason != 0,
+		Multipart:      flags&files != 0,
+		Auth:           flags&noAuth == 0,
+	}
+}
+
+func typeOf[T any]() reflect.Type {
+	t := reflect.TypeFor[T]()
+	if t == reflect.TypeFor[none]() {
+		return nil
+	}
+	return t
+}
+
+var (
+	channelMajor = []string{"channel.id"}
+	threadMajor  = []string{"thread.id"}
+	guildMajor   = []string{"guild.id"}
+	webhookMajor = []string{"webhook.id", "webhook.token"}
+)
+
+var routeDescriptors = []RouteDescriptor{
+	// Applications
+	{
+		Name:     "ApplicationRoleConnectionMetadata",
+		Template: "/applications/{application.id}/role-connections/metadata",
+		Methods: []RouteMethod{
+			method[none, none, []payloads.ApplicationRoleConnectionMetadata](http.MethodGet, 0),
+			method[none, []payloads.ApplicationRoleConnectionMetadata, []payloads.ApplicationRoleConnectionMetadata](http.MethodPut, 0),
+		},
+	},
+	{
+		Name:     "CurrentApplication",
+		Template: "/applications/@me",
+		Methods: []RouteMethod{
+			method[none, none, GetCurrentApplicationResult](http.MethodGet, 0),
+			method[none, PatchCurrentApplicationJSONBody, PatchCurrentApplicationResult](http.MethodPatch, 0),
+		},
+	},
+	{
+		Name:     "ApplicationCommands",
+		Template: "/applications/{application.id}/commands",
+		Methods: []RouteMethod{
+			method[GetApplicationCommandsQuery, none, GetApplicationCommandsResult](http.MethodGet, 0),
+			method[none, PutApplicationCommandsJSONBody, PutApplicationCommandsResult](http.MethodPut, 0),
+			method[none, PostApplicationCommandJSONBody, PostApplicationCommandResult](http.MethodPost, 0),
+		},
+	},
+	{
+		Name:     "ApplicationCommand",
+		Template: "/applications/{application.id}/commands/{command.id}",
+		Methods: []RouteMethod{
+			method[none, none, GetApplicationCommandResult](http.MethodGet, 0),
+			method[none, PatchApplicationCommandJSONBody, PatchApplicationCommandResult](http.MethodPatch, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:     "ApplicationGuildCommands",
+		Template: "/applications/{application.id}/guilds/{guild.id}/commands",
+		Methods: []RouteMethod{
+			method[GetGuildApplicationCommandsQuery, none, GetGuildApplicationCommandsResult](http.MethodGet, 0),
+			method[none, PutGuildApplicationCommandsJSONBody, PutGuildApplicationCommandsResult](http.MethodPut, 0),
+			method[none, PostGuildApplicationCommandJSONBody, PostGuildApplicationCommandResult](http.MethodPost, 0),
+		},
+	},
+	{
+		Name:     "Entitlements",
+		Template: "/applications/{application.id}/entitlements",
+		Methods: []RouteMethod{
+			method[GetEntitlementsQuery, none, []payloads.Entitlement](http.MethodGet, 0),
+			method[none, none, payloads.Entitlement](http.MethodPost, 0),
+		},
+	},
+	{
+		Name:     "Entitlement",
+		Template: "/applications/{application.id}/entitlements/{entitlement.id}",
+		Methods: []RouteMethod{
+			method[none, none, payloads.Entitlement](http.MethodGet, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:     "ConsumeEntitlement",
+		Template: "/applications/{application.id}/entitlements/{entitlement.id}/consume",
+		Methods:  []RouteMethod{method[none, none, none](http.MethodPost, 0)},
+	},
+	{
+		Name:     "SKUs",
+		Template: "/applications/{application.id}/skus",
+		Methods:  []RouteMethod{method[none, none, []payloads.SKU](http.MethodGet, 0)},
+	},
+	{
+		Name:     "ApplicationEmojis",
+		Template: "/applications/{application.id}/emojis",
+		Methods: []RouteMethod{
+			method[none, none, GetApplicationEmojisResult](http.MethodGet, 0),
+			method[none, PostApplicationEmojiJSONBody, PostApplicationEmojiResult](http.MethodPost, 0),
+		},
+	},
+	{
+		Name:     "ApplicationEmoji",
+		Template: "/applications/{application.id}/emojis/{emoji.id}",
+		Methods: []RouteMethod{
+			method[none, none, GetApplicationEmojiResult](http.MethodGet, 0),
+			method[none, PatchApplicationEmojiJSONBody, PatchApplicationEmojiResult](http.MethodPatch, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+
+	// Channels
+	{
+		Name:            "Channel",
+		Template:        "/channels/{channel.id}",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetChannelResult](http.MethodGet, 0),
+			method[none, PatchChannelJSONBody, PatchChannelResult](http.MethodPatch, reason),
+			method[none, none, DeleteChannelResult](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "ChannelMessages",
+		Template:        "/channels/{channel.id}/messages",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[GetMessagesQuery, none, GetChannelMessagesResult](http.MethodGet, 0),
+			method[none, PostChannelMessageJSONBody, PostChannelMessageResult](http.MethodPost, files),
+		},
+	},
+	{
+		Name:            "ChannelMessage",
+		Template:        "/channels/{channel.id}/messages/{message.id}",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetChannelMessageResult](http.MethodGet, 0),
+			method[none, PatchChannelMessageJSONBody, PatchChannelMessageResult](http.MethodPatch, files),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "ChannelMessageCrosspost",
+		Template:        "/channels/{channel.id}/messages/{message.id}/crosspost",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, none, PostChannelMessageCrosspostResult](http.MethodPost, 0)},
+	},
+	{
+		Name:            "ChannelMessageOwnReaction",
+		Template:        "/channels/{channel.id}/messages/{message.id}/reactions/{emoji}/@me",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, none, none](http.MethodPut, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:            "ChannelMessageUserReaction",
+		Template:        "/channels/{channel.id}/messages/{message.id}/reactions/{emoji}/{user.id}",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, none, none](http.MethodDelete, 0)},
+	},
+	{
+		Name:            "ChannelMessageReaction",
+		Template:        "/channels/{channel.id}/messages/{message.id}/reactions/{emoji}",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[GetReactionsQuery, none, GetChannelMessageReactionsResult](http.MethodGet, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:            "ChannelMessageAllReactions",
+		Template:        "/channels/{channel.id}/messages/{message.id}/reactions",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, none, none](http.MethodDelete, 0)},
+	},
+	{
+		Name:            "ChannelBulkDelete",
+		Template:        "/channels/{channel.id}/messages/bulk-delete",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, PostChannelMessagesBulkDeleteJSONBody, none](http.MethodPost, reason)},
+	},
+	{
+		Name:            "ChannelPermission",
+		Template:        "/channels/{channel.id}/permissions/{overwrite.id}",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, PutChannelPermissionJSONBody, none](http.MethodPut, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "ChannelInvites",
+		Template:        "/channels/{channel.id}/invites",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetChannelInvitesResult](http.MethodGet, 0),
+			method[none, PostChannelInviteJSONBody, PostChannelInviteResult](http.MethodPost, reason),
+		},
+	},
+	{
+		Name:            "ChannelFollowers",
+		Template:        "/channels/{channel.id}/followers",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, PostChannelFollowersJSONBody, PostChannelFollowersResult](http.MethodPost, reason)},
+	},
+	{
+		Name:            "ChannelTyping",
+		Template:        "/channels/{channel.id}/typing",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, none, none](http.MethodPost, 0)},
+	},
+	{
+		Name:            "ChannelMessagesPins",
+		Template:        "/channels/{channel.id}/messages/pins",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, none, GetChannelPinsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "ChannelMessagesPin",
+		Template:        "/channels/{channel.id}/messages/pins/{message.id}",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, none, none](http.MethodPut, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "ChannelRecipient",
+		Template:        "/channels/{channel.id}/recipients/{user.id}",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, none, none](http.MethodPut, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:            "ChannelWebhooks",
+		Template:        "/channels/{channel.id}/webhooks",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetChannelWebhooksResponse](http.MethodGet, 0),
+			method[none, CreateWebhookRequest, CreateWebhookResponse](http.MethodPost, reason),
+		},
+	},
+	{
+		Name:            "SendSoundboardSound",
+		Template:        "/channels/{channel.id}/send-soundboard-sound",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, PostSendSoundboardSoundJSONBody, PostSendSoundboardSoundResult](http.MethodPost, 0)},
+	},
+	{
+		Name:            "PollAnswerVoters",
+		Template:        "/channels/{channel.id}/polls/{message.id}/answers/{answer_id}",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[GetPollAnswerVotersQuery, none, GetPollAnswerVotersResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "ExpirePoll",
+		Template:        "/channels/{channel.id}/polls/{message.id}/expire",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, none, PostEndPollResult](http.MethodPost, 0)},
+	},
+
+	// Threads
+	{
+		Name:            "Threads",
+		Template:        "/channels/{channel.id}/threads",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, PostChannelThreadsJSONBody, PostChannelThreadsResult](http.MethodPost, reason|files)},
+	},
+	{
+		Name:            "Threads",
+		Template:        "/channels/{channel.id}/messages/{message.id}/threads",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[none, PostChannelMessageThreadsJSONBody, PostChannelMessageThreadsResult](http.MethodPost, reason)},
+	},
+	{
+		Name:            "ChannelThreads",
+		Template:        "/channels/{channel.id}/threads/archived/public",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[GetThreadsQuery, none, GetChannelThreadsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "ChannelThreads",
+		Template:        "/channels/{channel.id}/threads/archived/private",
+		MajorParameters: channelMajor,
+		Methods:         []RouteMethod{method[GetThreadsQuery, none, GetChannelThreadsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "ChannelJoinedArchivedThreads",
+		Template:        "/channels/{channel.id}/users/@me/threads/archived/private",
+		MajorParameters: channelMajor,
+		Methods: []RouteMethod{
+			method[GetChannelJoinedPrivateArchivedThreadsQuery, none, GetChannelJoinedPrivateArchivedThreadsResult](http.MethodGet, 0),
+		},
+	},
+	{
+		Name:            "ThreadMembers",
+		Template:        "/channels/{thread.id}/thread-members",
+		MajorParameters: threadMajor,
+		Methods:         []RouteMethod{method[none, none, []payloads.ThreadMember](http.MethodGet, 0)},
+	},
+	{
+		Name:            "ThreadMembers",
+		Template:        "/channels/{thread.id}/thread-members/@me",
+		MajorParameters: threadMajor,
+		Methods: []RouteMethod{
+			method[none, none, none](http.MethodPut, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:            "ThreadMembers",
+		Template:        "/channels/{thread.id}/thread-members/{user.id}",
+		MajorParameters: threadMajor,
+		Methods: []RouteMethod{
+			method[none, none, payloads.ThreadMember](http.MethodGet, 0),
+			method[none, none, none](http.MethodPut, 0),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+
+	// Guilds
+	{
+		Name:            "Guild",
+		Template:        "/guilds/{guild.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[GetGuildQuery, none, GetGuildResult](http.MethodGet, 0),
+			method[none, PatchGuildJSONBody, PatchGuildResult](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:            "GuildAuditLog",
+		Template:        "/guilds/{guild.id}/audit-logs",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[GetAuditLogQuery, none, payloads.AuditLog](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildAutoModerationRules",
+		Template:        "/guilds/{guild.id}/auto-moderation/rules",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, []payloads.AutoModerationRule](http.MethodGet, 0),
+			method[none, none, payloads.AutoModerationRule](http.MethodPost, reason),
+		},
+	},
+	{
+		Name:            "GuildAutoModerationRule",
+		Template:        "/guilds/{guild.id}/auto-moderation/rules/{rule.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, payloads.AutoModerationRule](http.MethodGet, 0),
+			method[none, none, payloads.AutoModerationRule](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "GuildChannels",
+		Template:        "/guilds/{guild.id}/channels",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildChannelsResult](http.MethodGet, 0),
+			method[none, PostGuildChannelJSONBody, PostGuildChannelResult](http.MethodPost, reason),
+			method[none, PatchGuildChannelPositionsJSONBody, none](http.MethodPatch, 0),
+		},
+	},
+	{
+		Name:            "GuildMember",
+		Template:        "/guilds/{guild.id}/members/{user.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildMemberResult](http.MethodGet, 0),
+			method[none, PutGuildMemberJSONBody, PutGuildMemberResult](http.MethodPut, 0),
+			method[none, PatchGuildMemberJSONBody, PatchGuildMemberResult](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "GuildMember",
+		Template:        "/guilds/{guild.id}/members/@me",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, PatchCurrentGuildMemberJSONBody, PatchCurrentGuildMemberResult](http.MethodPatch, reason)},
+	},
+	{
+		Name:            "GuildMembers",
+		Template:        "/guilds/{guild.id}/members",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[GetGuildMembersQuery, none, GetGuildMembersResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildMembersSearch",
+		Template:        "/guilds/{guild.id}/members/search",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[SearchGuildMembersQuery, none, SearchGuildMembersResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildMemberRole",
+		Template:        "/guilds/{guild.id}/members/{user.id}/roles/{role.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, none](http.MethodPut, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "GuildBans",
+		Template:        "/guilds/{guild.id}/bans",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[GetBansQuery, none, GetGuildBansResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildBan",
+		Template:        "/guilds/{guild.id}/bans/{user.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildBanResult](http.MethodGet, 0),
+			method[none, PutGuildBanJSONBody, none](http.MethodPut, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "GuildBulkBan",
+		Template:        "/guilds/{guild.id}/bulk-ban",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, PostGuildBulkBanJSONBody, PostGuildBulkBanResult](http.MethodPost, reason)},
+	},
+	{
+		Name:            "GuildRoles",
+		Template:        "/guilds/{guild.id}/roles",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildRolesResult](http.MethodGet, 0),
+			method[none, PostGuildRoleJSONBody, PostGuildRoleResult](http.MethodPost, reason),
+			method[none, PatchGuildRolePositionsJSONBody, PatchGuildRolePositionsResult](http.MethodPatch, reason),
+		},
+	},
+	{
+		Name:            "GuildRole",
+		Template:        "/guilds/{guild.id}/roles/{role.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, payloads.Role](http.MethodGet, 0),
+			method[none, PatchGuildRoleJSONBody, PatchGuildRoleResult](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "GuildEmojis",
+		Template:        "/guilds/{guild.id}/emojis",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildEmojisResult](http.MethodGet, 0),
+			method[none, PostGuildEmojiJSONBody, PostGuildEmojiResult](http.MethodPost, reason),
+		},
+	},
+	{
+		Name:            "GuildEmoji",
+		Template:        "/guilds/{guild.id}/emojis/{emoji.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildEmojiResult](http.MethodGet, 0),
+			method[none, PatchGuildEmojiJSONBody, PatchGuildEmojiResult](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "GuildPreview",
+		Template:        "/guilds/{guild.id}/preview",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildPreviewResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildPrune",
+		Template:        "/guilds/{guild.id}/prune",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[GetGuildPruneCountQuery, none, GetGuildPruneCountResult](http.MethodGet, 0),
+			method[none, PostGuildPruneJSONBody, PostGuildPruneResult](http.MethodPost, reason),
+		},
+	},
+	{
+		Name:            "GuildVoiceRegions",
+		Template:        "/guilds/{guild.id}/regions",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildVoiceRegionsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildInvites",
+		Template:        "/guilds/{guild.id}/invites",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildInvitesResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildIntegrations",
+		Template:        "/guilds/{guild.id}/integrations",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildIntegrationsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildIntegration",
+		Template:        "/guilds/{guild.id}/integrations/{integration.id}",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, none](http.MethodDelete, reason)},
+	},
+	{
+		Name:            "GuildWidgetSettings",
+		Template:        "/guilds/{guild.id}/widget",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildWidgetSettingsResult](http.MethodGet, 0),
+			method[none, PatchGuildWidgetSettingsJSONBody, PatchGuildWidgetSettingsResult](http.MethodPatch, reason),
+		},
+	},
+	{
+		Name:            "GuildWidgetJSON",
+		Template:        "/guilds/{guild.id}/widget.json",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildWidgetResult](http.MethodGet, noAuth)},
+	},
+	{
+		Name:            "GuildVanityURL",
+		Template:        "/guilds/{guild.id}/vanity-url",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildVanityURLResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildWidgetImage",
+		Template:        "/guilds/{guild.id}/widget.png",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[GetGuildWidgetImageQuery, none, none](http.MethodGet, noAuth)},
+	},
+	{
+		Name:            "GuildActiveThreads",
+		Template:        "/guilds/{guild.id}/threads/active",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildActiveThreadsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildTemplates",
+		Template:        "/guilds/{guild.id}/templates",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, []payloads.Template](http.MethodGet, 0),
+			method[none, none, payloads.Template](http.MethodPost, 0),
+		},
+	},
+	{
+		Name:            "GuildTemplate",
+		Template:        "/guilds/{guild.id}/templates/{template.code}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, payloads.Template](http.MethodPut, 0),
+			method[none, none, payloads.Template](http.MethodPatch, 0),
+			method[none, none, payloads.Template](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:     "Template",
+		Template: "/guilds/templates/{template.code}",
+		Methods: []RouteMethod{
+			method[none, none, payloads.Template](http.MethodGet, noAuth),
+			method[none, none, payloads.Guild](http.MethodPost, 0),
+		},
+	},
+	{
+		Name:            "GuildWebhooks",
+		Template:        "/guilds/{guild.id}/webhooks",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[none, none, GetGuildWebhooksResponse](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildStickers",
+		Template:        "/guilds/{guild.id}/stickers",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildStickersResult](http.MethodGet, 0),
+			method[none, PostGuildStickerJSONBody, PostGuildStickerResult](http.MethodPost, reason|files),
+		},
+	},
+	{
+		Name:            "GuildSticker",
+		Template:        "/guilds/{guild.id}/stickers/{sticker.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildStickerResult](http.MethodGet, 0),
+			method[none, PatchGuildStickerJSONBody, PatchGuildStickerResult](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "GuildScheduledEvents",
+		Template:        "/guilds/{guild.id}/scheduled-events",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, []payloads.GuildScheduledEvent](http.MethodGet, 0),
+			method[none, none, payloads.GuildScheduledEvent](http.MethodPost, reason),
+		},
+	},
+	{
+		Name:            "GuildScheduledEvent",
+		Template:        "/guilds/{guild.id}/scheduled-events/{event.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, payloads.GuildScheduledEvent](http.MethodGet, 0),
+			method[none, none, payloads.GuildScheduledEvent](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, 0),
+		},
+	},
+	{
+		Name:            "GuildScheduledEventUsers",
+		Template:        "/guilds/{guild.id}/scheduled-events/{event.id}/users",
+		MajorParameters: guildMajor,
+		Methods:         []RouteMethod{method[GetScheduledEventUsersQuery, none, []payloads.GuildScheduledEventUser](http.MethodGet, 0)},
+	},
+	{
+		Name:            "GuildSoundboardSounds",
+		Template:        "/guilds/{guild.id}/soundboard-sounds",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildSoundboardSoundsResult](http.MethodGet, 0),
+			method[none, PostGuildSoundboardSoundJSONBody, PostGuildSoundboardSoundResult](http.MethodPost, reason),
+		},
+	},
+	{
+		Name:            "GuildSoundboardSound",
+		Template:        "/guilds/{guild.id}/soundboard-sounds/{sound.id}",
+		MajorParameters: guildMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetGuildSoundboardSoundResult](http.MethodGet, 0),
+			method[none, PatchGuildSoundboardSoundJSONBody, PatchGuildSoundboardSoundResult](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+
+	// Users
+	{
+		Name:     "User",
+		Template: "/users/@me",
+		Methods: []RouteMethod{
+			method[none, none, GetCurrentUserResult](http.MethodGet, 0),
+			method[none, PatchCurrentUserJSONBody, PatchCurrentUserResult](http.MethodPatch, 0),
+		},
+	},
+	{
+		Name:     "User",
+		Template: "/users/{user.id}",
+		Methods:  []RouteMethod{method[none, none, GetUserResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "UserGuilds",
+		Template: "/users/@me/guilds",
+		Methods:  []RouteMethod{method[GetGuildsQuery, none, GetCurrentUserGuildsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "UserGuild",
+		Template: "/users/@me/guilds/{guild.id}",
+		Methods:  []RouteMethod{method[none, none, none](http.MethodDelete, 0)},
+	},
+	{
+		Name:     "UserGuildMember",
+		Template: "/users/@me/guilds/{guild.id}/member",
+		Methods:  []RouteMethod{method[none, none, GetCurrentUserGuildMemberResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "UserChannels",
+		Template: "/users/@me/channels",
+		Methods:  []RouteMethod{method[none, PostCreateDMJSONBody, PostCreateDMResult](http.MethodPost, 0)},
+	},
+	{
+		Name:     "UserConnections",
+		Template: "/users/@me/connections",
+		Methods:  []RouteMethod{method[none, none, GetCurrentUserConnectionsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "UserApplicationRoleConnection",
+		Template: "/users/@me/applications/{application.id}/role-connection",
+		Methods: []RouteMethod{
+			method[none, none, GetCurrentUserApplicationRoleConnectionResult](http.MethodGet, 0),
+			method[none, PutCurrentUserApplicationRoleConnectionJSONBody, PutCurrentUserApplicationRoleConnectionResult](http.MethodPut, 0),
+		},
+	},
+
+	// Webhooks and interactions
+	{
+		Name:            "Webhook",
+		Template:        "/webhooks/{webhook.id}",
+		MajorParameters: []string{"webhook.id"},
+		Methods: []RouteMethod{
+			method[none, none, GetWebhookResponse](http.MethodGet, 0),
+			method[none, ModifyWebhookRequest, ModifyWebhookResponse](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:            "Webhook",
+		Template:        "/webhooks/{webhook.id}/{webhook.token}",
+		MajorParameters: webhookMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetWebhookResponse](http.MethodGet, noAuth),
+			method[none, ModifyWebhookRequest, ModifyWebhookResponse](http.MethodPatch, noAuth),
+			method[none, none, none](http.MethodDelete, noAuth),
+			method[none, ExecuteWebhookRequest, ExecuteWebhookResponse](http.MethodPost, files|noAuth),
+		},
+	},
+	{
+		Name:            "WebhookMessage",
+		Template:        "/webhooks/{webhook.id}/{webhook.token}/messages/@original",
+		MajorParameters: webhookMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetInteractionOriginalResponseResult](http.MethodGet, noAuth),
+			method[none, PatchInteractionOriginalResponseJSONBody, PatchInteractionOriginalResponseResult](http.MethodPatch, files|noAuth),
+			method[none, none, none](http.MethodDelete, noAuth),
+		},
+	},
+	{
+		Name:            "WebhookMessage",
+		Template:        "/webhooks/{webhook.id}/{webhook.token}/messages/{message.id}",
+		MajorParameters: webhookMajor,
+		Methods: []RouteMethod{
+			method[none, none, GetInteractionFollowupResult](http.MethodGet, noAuth),
+			method[none, PatchInteractionFollowupJSONBody, PatchInteractionFollowupResult](http.MethodPatch, files|noAuth),
+			method[none, none, none](http.MethodDelete, noAuth),
+		},
+	},
+	{
+		Name:            "WebhookPlatform",
+		Template:        "/webhooks/{webhook.id}/{webhook.token}/github",
+		MajorParameters: webhookMajor,
+		Methods:         []RouteMethod{method[none, none, none](http.MethodPost, noAuth)},
+	},
+	{
+		Name:            "WebhookPlatform",
+		Template:        "/webhooks/{webhook.id}/{webhook.token}/slack",
+		MajorParameters: webhookMajor,
+		Methods:         []RouteMethod{method[none, none, none](http.MethodPost, noAuth)},
+	},
+	{
+		Name:     "InteractionCallback",
+		Template: "/interactions/{interaction.id}/{interaction.token}/callback",
+		Methods: []RouteMethod{
+			method[none, PostInteractionCallbackJSONBody, PostInteractionCallbackResult](http.MethodPost, files|noAuth),
+		},
+	},
+
+	// Other resources
+	{
+		Name:     "Gateway",
+		Template: "/gateway",
+		Methods:  []RouteMethod{method[none, none, GetGatewayResult](http.MethodGet, noAuth)},
+	},
+	{
+		Name:     "GatewayBot",
+		Template: "/gateway/bot",
+		Methods:  []RouteMethod{method[none, none, GetGatewayBotResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "VoiceRegions",
+		Template: "/voice/regions",
+		Methods:  []RouteMethod{method[none, none, GetVoiceRegionsResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "Invite",
+		Template: "/invites/{invite.code}",
+		Methods: []RouteMethod{
+			method[GetInviteQuery, none, GetInviteResult](http.MethodGet, noAuth),
+			method[none, none, DeleteInviteResult](http.MethodDelete, reason),
+		},
+	},
+	{
+		Name:     "Sticker",
+		Template: "/stickers/{sticker.id}",
+		Methods:  []RouteMethod{method[none, none, GetStickerResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "StickerPacks",
+		Template: "/sticker-packs",
+		Methods:  []RouteMethod{method[none, none, GetStickerPacksResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "StickerPack",
+		Template: "/sticker-packs/{pack.id}",
+		Methods:  []RouteMethod{method[none, none, GetStickerPackResult](http.MethodGet, 0)},
+	},
+	{
+		Name:     "SoundboardDefaultSounds",
+		Template: "/soundboard-default-sounds",
+		Methods:  []RouteMethod{method[none, none, []payloads.SoundboardSound](http.MethodGet, 0)},
+	},
+	{
+		Name:     "StageInstances",
+		Template: "/stage-instances",
+		Methods:  []RouteMethod{method[none, none, payloads.StageInstance](http.MethodPost, reason)},
+	},
+	{
+		Name:     "StageInstance",
+		Template: "/stage-instances/{channel.id}",
+		Methods: []RouteMethod{
+			method[none, none, payloads.StageInstance](http.MethodGet, 0),
+			method[none, none, payloads.StageInstance](http.MethodPatch, reason),
+			method[none, none, none](http.MethodDelete, reason),
+		},
+	},
+
+	// OAuth2
+	{
+		Name:     "OAuth2CurrentApplication",
+		Template: "/oauth2/applications/@me",
+		Methods:  []RouteMethod{method[none, none, GetCurrentBotApplicationInformationResponse](http.MethodGet, 0)},
+	},
+	{
+		Name:     "OAuth2CurrentAuthorization",
+		Template: "/oauth2/@me",
+		Methods:  []RouteMethod{method[none, none, GetCurrentAuthorizationInformationResponse](http.MethodGet, 0)},
+	},
+	{
+		Name:     "OAuth2Authorization",
+		Template: "/oauth2/authorize",
+		Methods:  []RouteMethod{method[none, none, none](http.MethodGet, noAuth)},
+	},
+	{
+		Name:     "OAuth2TokenExchange",
+		Template: "/oauth2/token",
+		Methods:  []RouteMethod{method[none, none, none](http.MethodPost, noAuth)},
+	},
+	{
+		Name:     "OAuth2TokenRevocation",
+		Template: "/oauth2/token/revoke",
+		Methods:  []RouteMethod{method[none, none, none](http.MethodPost, noAuth)},
+	},
+}
+
+func init() {
+	for i := range routeDescriptors {
+		d := &routeDescriptors[i]
+		d.segments = strings.Split(strings.Trim(d.Template, "/"), "/")
+	}
+}
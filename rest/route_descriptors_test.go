@@ -0,0 +1,184 @@
+package rest
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kolosys/discord-types/discord"
+)
+
+func TestRouteDescriptors(t *testing.T) {
+	// Every RouteBuilder method builds a route that matches a descriptor
+	// of the same name.
+	strArgs := map[string]string{"ChannelThreads": "private", "WebhookPlatform": "slack"}
+	builder := reflect.ValueOf(Routes)
+	for i := range builder.NumMethod() {
+		name := builder.Type().Method(i).Name
+		m := builder.Method(i)
+		args := make([]reflect.Value, m.Type().NumIn())
+		for j := range args {
+			switch in := m.Type().In(j); in {
+			case reflect.TypeFor[discord.Snowflake]():
+				args[j] = reflect.ValueOf(channelID)
+			case reflect.TypeFor[string]():
+				s, ok := strArgs[name]
+				if !ok {
+					s = "token"
+				}
+				args[j] = reflect.ValueOf(s)
+			case reflect.TypeFor[int]():
+				args[j] = reflect.ValueOf(1)
+			default:
+				args[j] = reflect.Zero(in)
+			}
+		}
+		route := m.Call(args)[0].String()
+
+		match, ok := MatchRoute(route)
+		if !ok {
+			t.Errorf("%s: MatchRoute(%q) found no descriptor", name, route)
+			continue
+		}
+		if match.Name != name {
+			t.Errorf("%s: MatchRoute(%q) = %s %s", name, route, match.Name, match.Template)
+		}
+	}
+
+	templates := make(map[string]bool)
+	for _, d := range RouteDescriptors() {
+		if templates[d.Template] {
+			t.Errorf("template %s is described twice", d.Template)
+		}
+		templates[d.Template] = true
+		if len(d.Methods) == 0 {
+			t.Errorf("%s has no methods", d.Template)
+		}
+		for _, param := range d.MajorParameters {
+			if !strings.Contains(d.Template, "{"+param+"}") {
+				t.Errorf("%s has no major parameter %s", d.Template, param)
+			}
+		}
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		template string
+		params   map[string]string
+		major    string
+	}{
+		{
+			name:     "Route",
+			path:     Routes.ChannelMessage(channelID, messageID),
+			template: "/channels/{channel.id}/messages/{message.id}",
+			params:   map[string]string{"channel.id": "290926798999357250", "message.id": "334385199974967042"},
+			major:    "290926798999357250",
+		},
+		{
+			name:     "URL",
+			path:     RouteBases.API + "/guilds/41771983423143937/members/search?query=Nelly",
+			template: "/guilds/{guild.id}/members/search",
+			params:   map[string]string{"guild.id": "41771983423143937"},
+			major:    "41771983423143937",
+		},
+		{
+			name:     "Literal before parameter",
+			path:     "/api/v10/users/@me",
+			template: "/users/@me",
+			params:   map[string]string{},
+		},
+		{
+			name:     "Pins",
+			path:     Routes.ChannelMessagesPins(channelID),
+			template: "/channels/{channel.id}/messages/pins",
+			params:   map[string]string{"channel.id": "290926798999357250"},
+			major:    "290926798999357250",
+		},
+		{
+			name:     "Escaped emoji",
+			path:     Routes.ChannelMessageOwnReaction(channelID, messageID, "🔥"),
+			template: "/channels/{channel.id}/messages/{message.id}/reactions/{emoji}/@me",
+			params:   map[string]string{"channel.id": "290926798999357250", "message.id": "334385199974967042", "emoji": "🔥"},
+			major:    "290926798999357250",
+		},
+		{
+			name:     "Webhook token",
+			path:     Routes.WebhookMessage("223704706495545344", "3d89bb7572e0fb30d8128367b3b1b44fecd1726de135cbe28a41f8b2f777c372ba2939e72279b94526ff5d1bd4358d65cf11", "@original"),
+			template: "/webhooks/{webhook.id}/{webhook.token}/messages/@original",
+			params: map[string]string{
+				"webhook.id":    "223704706495545344",
+				"webhook.token": "3d89bb7572e0fb30d8128367b3b1b44fecd1726de135cbe28a41f8b2f777c372ba2939e72279b94526ff5d1bd4358d65cf11",
+			},
+			major: "223704706495545344/3d89bb7572e0fb30d8128367b3b1b44fecd1726de135cbe28a41f8b2f777c372ba2939e72279b94526ff5d1bd4358d65cf11",
+		},
+		{
+			name:     "Guild template",
+			path:     Routes.Template("hgM48av5Q69A"),
+			template: "/guilds/templates/{template.code}",
+			params:   map[string]string{"template.code": "hgM48av5Q69A"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := MatchRoute(tt.path)
+			if !ok {
+				t.Fatalf("MatchRoute(%q) found no descriptor", tt.path)
+			}
+			if m.Template != tt.template {
+				t.Errorf("Template = %s, want %s", m.Template, tt.template)
+			}
+			if !reflect.DeepEqual(m.Params, tt.params) {
+				t.Errorf("Params = %v, want %v", m.Params, tt.params)
+			}
+			if got := m.Major(); got != tt.major {
+				t.Errorf("Major() = %q, want %q", got, tt.major)
+			}
+		})
+	}
+
+	for _, path := range []string{"/", "/channels", "/channels/290926798999357250/unknown", "/guilds//roles"} {
+		if m, ok := MatchRoute(path); ok {
+			t.Errorf("MatchRoute(%q) = %s, want no match", path, m.Template)
+		}
+	}
+}
+
+func TestRouteDescriptor_Method(t *testing.T) {
+	m, ok := MatchRoute(Routes.ChannelMessages(channelID))
+	if !ok {
+		t.Fatal("no descriptor for ChannelMessages")
+	}
+
+	post, ok := m.Method(http.MethodPost)
+	if !ok {
+		t.Fatal("Method(POST) not found")
+	}
+	want := RouteMethod{
+		Method:    http.MethodPost,
+		Body:      reflect.TypeFor[PostChannelMessageJSONBody](),
+		Result:    reflect.TypeFor[PostChannelMessageResult](),
+		Multipart: true,
+		Auth:      true,
+	}
+	if post != want {
+		t.Errorf("Method(POST) = %+v, want %+v", post, want)
+	}
+
+	get, _ := m.Method(http.MethodGet)
+	if get.Query != reflect.TypeFor[GetMessagesQuery]() || get.Body != nil {
+		t.Errorf("Method(GET) = %+v", get)
+	}
+	if _, ok := m.Method(http.MethodDelete); ok {
+		t.Error("Method(DELETE) found on a route without DELETE")
+	}
+
+	deleteChannel, _ := MatchRoute(Routes.Channel(channelID))
+	if method, _ := deleteChannel.Method(http.MethodDelete); !method.AuditLogReason {
+		t.Error("DELETE /channels/{channel.id} does not accept an audit log reason")
+	}
+}
@@ -1,7 +1,7 @@
 // Package rest provides Discord REST API types and utilities.
 //
 // This file contains comprehensive specialized REST API request and response types.
-// Includes: Emoji, Sticker, Soundboard, Poll, User, Voice, Invite, Application, OAuth2
+// Includes: Emoji, Sticker, Soundboard, Poll, User, Voice, Invite, Application, OAuth2, Gateway
 package rest
 
 import (
@@ -192,9 +192,9 @@ type PostSendSoundboardSoundResult struct{} // Empty response
 // GetPollAnswerVotersQuery represents query parameters for GET /channels/{channel.id}/polls/{message.id}/answers/{answer.id}
 type GetPollAnswerVotersQuery struct {
 	// Get users after this user ID
-	After *discord.Snowflake `json:"after,omitempty"`
+	After *discord.Snowflake `url:"after,omitempty"`
 	// Max number of users to return (1-100, default 25)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 }
 
 // GetPollAnswerVotersResult represents the response from GET /channels/{channel.id}/polls/{message.id}/answers/{answer.id}
@@ -231,13 +231,13 @@ type PatchCurrentUserResult = payloads.User
 // GetCurrentUserGuildsQuery represents query parameters for GET /users/@me/guilds
 type GetCurrentUserGuildsQuery struct {
 	// Get guilds before this guild ID
-	Before *discord.Snowflake `json:"before,omitempty"`
+	Before *discord.Snowflake `url:"before,omitempty"`
 	// Get guilds after this guild ID
-	After *discord.Snowflake `json:"after,omitempty"`
+	After *discord.Snowflake `url:"after,omitempty"`
 	// Max number of guilds to return (1-200, default 200)
-	Limit *int `json:"limit,omitempty"`
+	Limit *int `url:"limit,omitempty"`
 	// Whether to include approximate member and presence counts in response
-	WithCounts *bool `json:"with_counts,omitempty"`
+	WithCounts *bool `url:"with_counts,omitempty"`
 }
 
 // GetCurrentUserGuildsResult represents the response from GET /users/@me/guilds
@@ -291,11 +291,11 @@ type GetVoiceRegionsResult = []payloads.VoiceRegion
 // GetInviteQuery represents query parameters for GET /invites/{invite.code}
 type GetInviteQuery struct {
 	// Whether the invite should contain approximate member counts
-	WithCounts *bool `json:"with_counts,omitempty"`
+	WithCounts *bool `url:"with_counts,omitempty"`
 	// Whether the invite should contain the expiration date
-	WithExpiration *bool `json:"with_expiration,omitempty"`
+	WithExpiration *bool `url:"with_expiration,omitempty"`
 	// The guild scheduled event to include with the invite
-	GuildScheduledEventID *discord.Snowflake `json:"guild_scheduled_event_id,omitempty"`
+	GuildScheduledEventID *discord.Snowflake `url:"guild_scheduled_event_id,omitempty"`
 }
 
 // GetInviteResult represents the response from GET /invites/{invite.code}
@@ -337,3 +337,13 @@ type PatchCurrentApplicationJSONBody struct {
 
 // PatchCurrentApplicationResult represents the response from PATCH /applications/@me
 type PatchCurrentApplicationResult = PartialApplication
+
+// ====================
+// Gateway Types
+// ====================
+
+// GetGatewayResult represents the response from GET /gateway
+type GetGatewayResult = payloads.GatewayInfo
+
+// GetGatewayBotResult represents the response from GET /gateway/bot
+type GetGatewayBotResult = payloads.GatewayBotInfo
@@ -528,22 +528,6 @@ type GetCurrentAuthorizationInformationResponse struct {
 	User        *payloads.User     `json:"user,omitempty"`
 }
 
-// ====================
-// Error Types
-// ====================
-
-// RESTError represents a REST API error response
-type RESTError struct {
-	Code    int                    `json:"code"`
-	Message string                 `json:"message"`
-	Errors  map[string]interface{} `json:"errors,omitempty"`
-}
-
-// Error implements the error interface
-func (e RESTError) Error() string {
-	return e.Message
-}
-
 // ====================
 // Common Response Types
 // ====================